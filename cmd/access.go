@@ -11,18 +11,34 @@ import (
 )
 
 var (
-	accessSvc *tunnel.Service
+	accessSvc        *tunnel.Service
+	tokenDuration    string
+	tokenOutput      string
+	matrixUser       string
+	matrixService    string
+	matrixFormat     string
+	forceDelete      bool
+	exportFormat     string
+	syncPrune        bool
+	accessLogsSince  string
+	accessLogsDenied bool
+	accessLogsUser   string
+	accessLogsFollow bool
 )
 
 var accessCmd = &cobra.Command{
 	Use:   "access",
-	Short: "Manage Cloudflare Access groups",
+	Short: "Manage Cloudflare Access groups and service tokens",
 	Example: `  orb access create friends user1@example.com,user2@example.com
   orb access list
-  orb access delete friends`,
+  orb access delete friends
+  orb access sync groups.yaml
+  orb access token create ci --duration 1y
+  orb access matrix --user alice@example.com
+  orb access logs api --denied`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		accessSvc, err = tunnel.NewService()
+		accessSvc, err = newTunnelService(cmd)
 		return err
 	},
 }
@@ -30,22 +46,57 @@ var accessCmd = &cobra.Command{
 func init() {
 	updateGroupCmd.Flags().StringP("add", "a", "", "Comma-separated emails to add")
 	updateGroupCmd.Flags().StringP("remove", "r", "", "Comma-separated emails to remove")
+	deleteGroupCmd.Flags().BoolVar(&forceDelete, "force", false, "Revert services still using the group to private, then delete it")
 
 	accessCmd.AddCommand(createGroupCmd)
 	accessCmd.AddCommand(listGroupsCmd)
 	accessCmd.AddCommand(deleteGroupCmd)
 	accessCmd.AddCommand(updateGroupCmd)
 	accessCmd.AddCommand(showGroupCmd)
+	accessCmd.AddCommand(tokenCmd)
+	accessCmd.AddCommand(matrixCmd)
+	accessCmd.AddCommand(exportCmd)
+	accessCmd.AddCommand(syncCmd)
+	accessCmd.AddCommand(accessLogsCmd)
+	accessCmd.AddCommand(ownersCmd)
+	ownersCmd.AddCommand(syncOwnersCmd)
+
+	tokenCmd.AddCommand(createTokenCmd)
+	tokenCmd.AddCommand(listTokensCmd)
+	tokenCmd.AddCommand(rotateTokenCmd)
+	tokenCmd.AddCommand(revokeTokenCmd)
+	tokenCmd.AddCommand(attachTokenCmd)
+	tokenCmd.AddCommand(detachTokenCmd)
+
+	createTokenCmd.Flags().StringVar(&tokenDuration, "duration", "1y", "How long the token is valid (e.g., 720h, 90d, 1y, forever)")
+	for _, c := range []*cobra.Command{createTokenCmd, rotateTokenCmd} {
+		c.Flags().StringVarP(&tokenOutput, "output", "o", "", "Write the client ID and secret to this file (mode 0600) instead of printing them")
+	}
+
+	matrixCmd.Flags().StringVarP(&matrixUser, "user", "u", "", "Only what this email address can reach")
+	matrixCmd.Flags().StringVarP(&matrixService, "service", "s", "", "Only who can reach this subdomain")
+	matrixCmd.Flags().StringVarP(&matrixFormat, "format", "f", tunnel.MatrixTable, "Output format: table, csv or json")
+
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", tunnel.GroupsYAML, "Output format: yaml or csv")
+	syncCmd.Flags().BoolVar(&syncPrune, "prune", false, "Delete groups that are not in the file (unless a service or another group still uses them)")
+
+	accessLogsCmd.Flags().StringVar(&accessLogsSince, "since", "24h", "How far back to look (e.g., 30m, 24h, 7d)")
+	accessLogsCmd.Flags().BoolVar(&accessLogsDenied, "denied", false, "Only show denied logins")
+	accessLogsCmd.Flags().StringVarP(&accessLogsUser, "user", "u", "", "Only show logins by this email address")
+	accessLogsCmd.Flags().BoolVarP(&accessLogsFollow, "follow", "f", false, "Keep polling for new logins")
+
+	addDryRunFlag(createGroupCmd, updateGroupCmd, deleteGroupCmd, syncCmd, syncOwnersCmd, createTokenCmd, rotateTokenCmd, revokeTokenCmd, attachTokenCmd, detachTokenCmd)
 }
 
 var createGroupCmd = &cobra.Command{
-	Use:   "create <group-name> <emails>",
-	Short: "Create an Access group with email addresses",
+	Use:   "create <group-name> <members>",
+	Short: "Create an Access group with email addresses, email domains or other groups",
 	Example: `  orb access create friends user1@example.com,user2@example.com
+  orb access create staff email-domain:example.com,group:contractors
   orb access create hackathon2025 alice@example.com,bob@example.com,charlie@example.com`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return accessSvc.CreateAccessGroup(args[0], args[1])
+		return accessSvc.CreateAccessGroup(cmd.Context(), args[0], args[1])
 	},
 }
 
@@ -55,18 +106,22 @@ var listGroupsCmd = &cobra.Command{
 	Args:                  cobra.NoArgs,
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return accessSvc.ListAccessGroups()
+		return accessSvc.ListAccessGroups(cmd.Context())
 	},
 }
 
 var deleteGroupCmd = &cobra.Command{
-	Use:                   "delete <group-name>",
-	Short:                 "Delete an Access group",
-	Example:               "  orb access delete friends",
-	Args:                  cobra.ExactArgs(1),
-	DisableFlagsInUseLine: true,
+	Use:   "delete <group-name>",
+	Short: "Delete an Access group no service uses",
+	Long: `Delete an Access group. While exposed services still let the group in (or
+require or exclude it), the services are listed and nothing is deleted; --force
+reverts them to private first. A group other groups include is never deleted -
+remove it from them first.`,
+	Example: `  orb access delete friends
+  orb access delete friends --force                          # Revert its services to private first`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return accessSvc.DeleteAccessGroup(args[0])
+		return accessSvc.DeleteAccessGroup(cmd.Context(), args[0], forceDelete)
 	},
 }
 
@@ -102,7 +157,7 @@ var updateGroupCmd = &cobra.Command{
 			}
 		}
 
-		return accessSvc.UpdateAccessGroupMembers(args[0], addEmails, removeEmails)
+		return accessSvc.UpdateAccessGroupMembers(cmd.Context(), args[0], addEmails, removeEmails)
 	},
 }
 
@@ -113,7 +168,7 @@ var showGroupCmd = &cobra.Command{
 	Args:                  cobra.ExactArgs(1),
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		members, err := accessSvc.GetAccessGroupMembers(args[0])
+		members, err := accessSvc.GetAccessGroupMembers(cmd.Context(), args[0])
 		if err != nil {
 			return err
 		}
@@ -125,3 +180,189 @@ var showGroupCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var exportCmd = &cobra.Command{
+	Use:   "export [group-name]",
+	Short: "Print Access groups and their members as YAML or CSV",
+	Example: `  orb access export > groups.yaml                             # Start a groups file for sync
+  orb access export friends
+  orb access export --format csv`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var group string
+		if len(args) == 1 {
+			group = args[0]
+		}
+		return accessSvc.ExportAccessGroups(cmd.Context(), group, exportFormat)
+	},
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync <file>",
+	Short: "Reconcile Access groups to a YAML file",
+	Long: `Make every group in the file have exactly the listed members, creating groups
+as needed. The members each group gains and loses are shown before they are
+applied. Members are email addresses, email-domain:<domain>, group:<name> or any
+other rule --allow accepts. Groups not in the file are kept unless --prune is
+set, and never deleted while a service or another group still uses them; a
+group in the file cannot include one --prune would delete.
+
+  groups:
+    contractors:
+      - alice@acme.com
+      - email-domain:acme.com
+    friends:
+      - bob@example.com
+      - group:contractors`,
+	Example: `  orb access sync groups.yaml --dry-run                       # Review the changes
+  orb access sync groups.yaml
+  orb access sync groups.yaml --prune                         # Also delete groups not in the file`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.SyncAccessGroups(cmd.Context(), args[0], syncPrune)
+	},
+}
+
+var accessLogsCmd = &cobra.Command{
+	Use:   "logs [subdomain]",
+	Short: "Show who logged in through Cloudflare Access and whether they got in",
+	Long: `Show the Cloudflare Access authentication decisions for an exposed service
+(or every one): when, who, from which IP and country, and whether they were
+allowed or denied. Use it when someone says "I can't get in".`,
+	Example: `  orb access logs
+  orb access logs api --since 2h
+  orb access logs api --denied --user alice@example.com      # Why can't alice get in?
+  orb access logs -f                                         # Watch logins as they happen`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var subdomain string
+		if len(args) == 1 {
+			subdomain = args[0]
+		}
+		return accessSvc.AccessLogs(cmd.Context(), subdomain, tunnel.AccessLogOptions{
+			Since:  accessLogsSince,
+			Denied: accessLogsDenied,
+			User:   accessLogsUser,
+			Follow: accessLogsFollow,
+		})
+	},
+}
+
+var ownersCmd = &cobra.Command{
+	Use:   "owners",
+	Short: "Show who owns protected services and which still have other owners",
+	Long: `The owners are let into every protected service by its first policy, whatever
+else it allows. They come from OWNER_EMAIL (comma-separated; USER_EMAIL is read
+too) and OWNER_GROUP, an Access group such as your admins. Services exposed
+before the owners changed keep their old owners until synced.`,
+	Example: `  orb access owners
+  orb access owners sync --dry-run`,
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.ShowOwners(cmd.Context())
+	},
+}
+
+var syncOwnersCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Migrate the owner policy of every protected service to the configured owners",
+	Example: `  OWNER_EMAIL=alice@example.com,bob@example.com orb access owners sync
+  orb access owners sync --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.SyncOwners(cmd.Context())
+	},
+}
+
+var matrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Show who can reach which service",
+	Long: `Join every exposed service's Access policies, group memberships, public
+ingress and pending expiries into one hostname × principal report. Rows for a
+user's email domain and for everyone count as access for --user.`,
+	Example: `  orb access matrix
+  orb access matrix --user alice@example.com                 # What can alice reach?
+  orb access matrix --service api                            # Who can reach api?
+  orb access matrix --format csv > access-review.csv         # For a quarterly review`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.PrintAccessMatrix(cmd.Context(), tunnel.MatrixFilter{User: matrixUser, Service: matrixService}, matrixFormat)
+	},
+}
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage Access service tokens for CI jobs and scripts",
+	Long: `Service tokens let machines reach protected services without an interactive
+login: clients send the CF-Access-Client-Id and CF-Access-Client-Secret headers.
+A token reaches a service once attached to it, through a non_identity policy.`,
+	Example: `  orb access token create ci --duration 1y -o ~/.config/orb/ci.env
+  orb access token attach ci api
+  orb access token list
+  orb access token rotate ci
+  orb access token revoke ci`,
+}
+
+var createTokenCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a service token and show its secret once",
+	Example: `  orb access token create ci
+  orb access token create deploy-bot --duration 90d
+  orb access token create ci -o ci.env                        # Write the secret to a file`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.CreateServiceToken(cmd.Context(), args[0], tokenDuration, tokenOutput)
+	},
+}
+
+var listTokensCmd = &cobra.Command{
+	Use:                   "list",
+	Short:                 "List service tokens, where they are attached and when they expire",
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.ListServiceTokens(cmd.Context())
+	},
+}
+
+var rotateTokenCmd = &cobra.Command{
+	Use:   "rotate <name>",
+	Short: "Replace a service token's secret and renew its expiry",
+	Example: `  orb access token rotate ci
+  orb access token rotate ci -o ci.env`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.RotateServiceToken(cmd.Context(), args[0], tokenOutput)
+	},
+}
+
+var revokeTokenCmd = &cobra.Command{
+	Use:     "revoke <name>",
+	Short:   "Detach a service token from every service and delete it",
+	Example: "  orb access token revoke ci",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.RevokeServiceToken(cmd.Context(), args[0])
+	},
+}
+
+var attachTokenCmd = &cobra.Command{
+	Use:     "attach <name> <subdomain>",
+	Short:   "Let a service token reach a protected service",
+	Example: "  orb access token attach ci api",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.AttachServiceToken(cmd.Context(), args[0], args[1])
+	},
+}
+
+var detachTokenCmd = &cobra.Command{
+	Use:     "detach <name> <subdomain>",
+	Short:   "Stop a service token from reaching a service",
+	Example: "  orb access token detach ci api",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return accessSvc.DetachServiceToken(cmd.Context(), args[0], args[1])
+	},
+}
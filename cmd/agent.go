@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"orb/internal/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentInterval time.Duration
+	agentOnce     bool
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Apply access expiries and TTLs as they fall due",
+	Long: `Run in the foreground and apply pending expiries (--expires and --ttl) when
+they fall due, including ones missed while the machine was off.
+
+Expiries are kept in ~/.config/orb/expiries.json. How they are applied is set
+by EXPIRY_BACKEND in ~/.config/orb/.env:
+
+  systemd  Each expiry gets a transient systemd --user timer (default).
+           Needs a user systemd instance; timers do not survive a reboot.
+  agent    No timers are created; this agent applies expiries from the store.
+           Run it as a service, e.g. a systemd --user unit with Restart=always.
+
+With either backend, every tunnel, access and db command that changes
+something (without --dry-run) first applies any expiries that are already
+overdue. An expiry that fails to apply is retried with backoff, from 30s
+doubling up to 1h.`,
+	Example: `  orb agent                    # Run until Ctrl-C
+  orb agent --interval 30s     # Check more often
+  orb agent --once             # Apply overdue expiries and exit (e.g. from cron)`,
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := tunnel.NewService(tunnelOptions())
+		if err != nil {
+			return err
+		}
+
+		if agentOnce {
+			applied, err := svc.RunDueExpiries(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✔ Applied %d overdue expiry(ies)\n", applied)
+			return nil
+		}
+		return svc.RunAgent(cmd.Context(), agentInterval)
+	},
+}
+
+func init() {
+	agentCmd.Flags().DurationVar(&agentInterval, "interval", time.Minute, "How often to check for due expiries")
+	agentCmd.Flags().BoolVar(&agentOnce, "once", false, "Apply overdue expiries and exit")
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"orb/internal/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	connectLocalPort string
+	connectType      string
+)
+
+var connectCmd = &cobra.Command{
+	Use:   "connect <subdomain>",
+	Short: "Reach a TCP, SSH, RDP or SMB service through Cloudflare Access",
+	Long: `Run the cloudflared access client for an exposed TCP, SSH, RDP or SMB service
+and keep it running (restarting it if it exits) until Ctrl+C. It listens on a
+local port and prints how to connect - for databases, a ready connection string.
+
+The service type is read from the tunnel config when the service is exposed
+from this machine; elsewhere give it with --type. Only DOMAIN needs to be set
+(and cloudflared installed) - no Cloudflare API token is used.`,
+	Example: `  orb connect mydb                                           # e.g. psql "postgresql://postgres@localhost:5432/postgres"
+  orb connect mydb --local-port 15432                        # A local postgres already uses 5432
+  orb connect box --type ssh                                 # ssh -p 2222 <user>@localhost
+  orb connect desktop --type rdp`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := tunnel.NewConnectService()
+		if err != nil {
+			return err
+		}
+		return svc.Connect(cmd.Context(), args[0], tunnel.ConnectOptions{LocalPort: connectLocalPort, Type: connectType})
+	},
+}
+
+func init() {
+	connectCmd.Flags().StringVarP(&connectLocalPort, "local-port", "p", "", "Local port to listen on (default: the service's port for tcp, 2222 ssh, 3389 rdp, 4445 smb)")
+	connectCmd.Flags().StringVarP(&connectType, "type", "t", "", "Service type when it is not exposed from this machine: tcp, ssh, rdp or smb")
+}
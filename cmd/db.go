@@ -68,11 +68,12 @@ Databases are exposed with TCP service type and private access by default.`,
 	Example: `  orb db expose postgres mydb
   orb db expose postgres mydb --port 5433
   orb db expose mysql mydb --access team
-  orb db expose redis cache --access team --expires 24h`,
+  orb db expose redis cache --access team --expires 24h
+  orb db expose postgres scratch --ttl 4h`,
 	Args: cobra.ExactArgs(2),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbSvc, err = tunnel.NewService()
+		dbSvc, err = newTunnelService(cmd)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -95,9 +96,14 @@ Databases are exposed with TCP service type and private access by default.`,
 		}
 
 		expires, _ := cmd.Flags().GetString("expires")
+		ttl, _ := cmd.Flags().GetString("ttl")
 
 		fmt.Printf("Exposing %s database...\n", defaults.description)
-		return dbSvc.Expose(subdomain, port, defaults.serviceType, access, expires)
+		return dbSvc.Expose(cmd.Context(), subdomain, port, defaults.serviceType, tunnel.ExposeOptions{
+			Access:  access,
+			Expires: expires,
+			TTL:     ttl,
+		})
 	},
 }
 
@@ -135,7 +141,7 @@ The database will be created with:
 	Args: cobra.ExactArgs(2),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbMgr, err = database.NewService()
+		dbMgr, err = database.NewService(dryRun)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -154,7 +160,7 @@ var dbListManagedCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbMgr, err = database.NewService()
+		dbMgr, err = database.NewService(dryRun)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -170,7 +176,7 @@ var dbStartCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbMgr, err = database.NewService()
+		dbMgr, err = database.NewService(dryRun)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -186,7 +192,7 @@ var dbStopCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbMgr, err = database.NewService()
+		dbMgr, err = database.NewService(dryRun)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -207,7 +213,7 @@ Use --keep-data to preserve the data directory.`,
 	DisableFlagsInUseLine: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbMgr, err = database.NewService()
+		dbMgr, err = database.NewService(dryRun)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -226,7 +232,7 @@ var dbLogsCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbMgr, err = database.NewService()
+		dbMgr, err = database.NewService(dryRun)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -244,7 +250,7 @@ var dbInfoCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbMgr, err = database.NewService()
+		dbMgr, err = database.NewService(dryRun)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -290,7 +296,7 @@ Opens the appropriate CLI tool for each database type:
 	Args:    cobra.ExactArgs(1),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		dbMgr, err = database.NewService()
+		dbMgr, err = database.NewService(dryRun)
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -303,6 +309,7 @@ func init() {
 	dbExposeCmd.Flags().StringP("port", "p", "", "Port to expose (defaults to standard port for db type)")
 	dbExposeCmd.Flags().StringP("access", "a", "private", "Access level: public, private, or group name")
 	dbExposeCmd.Flags().StringP("expires", "e", "", "Auto-revoke group access after duration (e.g., 1h, 24h)")
+	dbExposeCmd.Flags().String("ttl", "", "Unexpose the database entirely after duration (e.g., 2h, 7d)")
 
 	// Create command flags
 	dbCreateCmd.Flags().StringP("port", "p", "", "Port to bind (defaults to standard port for db type)")
@@ -314,6 +321,8 @@ func init() {
 	dbLogsCmd.Flags().BoolP("follow", "f", false, "Follow log output")
 	dbLogsCmd.Flags().IntP("lines", "n", 100, "Number of lines to show")
 
+	addDryRunFlag(dbExposeCmd, dbCreateCmd, dbDeleteCmd)
+
 	// Add all subcommands
 	dbCmd.AddCommand(dbExposeCmd)
 	dbCmd.AddCommand(dbTypesCmd)
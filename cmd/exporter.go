@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"time"
+
+	"orb/internal/database"
+	"orb/internal/exporter"
+	"orb/internal/scheduler"
+	"orb/internal/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exporterListen   string
+	exporterInterval time.Duration
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Serve Prometheus metrics about exposed services, expiries, databases and schedules",
+	Long: `Run in the foreground and serve Prometheus metrics on /metrics.
+
+Metrics are collected every --interval and cached, so scrapes do not probe
+services or call the Cloudflare API themselves:
+
+  orb_services{access}                          Exposed services by access kind
+  orb_service_info{hostname,target,access}      One per exposed service
+  orb_service_up{hostname}                      Whether the HTTPS probe returned 2xx/3xx
+  orb_service_probe_status_code{hostname}       Probe status code (0 if it failed)
+  orb_service_probe_duration_seconds{hostname}  Probe latency
+  orb_expiry_seconds{subdomain,kind,target}     Seconds until a pending expiry
+  orb_database_running{name,type,port}          Whether a managed database is running
+  orb_schedule_last_run_timestamp_seconds{name} Start of the last recorded run
+  orb_schedule_last_exit_code{name}             Exit code of the last run
+  orb_schedule_last_success_timestamp_seconds{name}  Start of the last successful run
+  orb_collector_success{collector}              Whether a collection succeeded
+
+Schedule runs are recorded by ` + "`orb schedule run`" + `, which cron runs for
+schedules added with ` + "`orb schedule add`" + `.`,
+	Example: `  orb exporter                     # Listen on :9469
+  orb exporter --listen 127.0.0.1:9469
+  orb exporter --interval 1m       # Probe services less often`,
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tunnelSvc, err := tunnel.NewService(tunnelOptions())
+		if err != nil {
+			return err
+		}
+		dbSvc, err := database.NewService(false)
+		if err != nil {
+			return err
+		}
+		scheduleSvc, err := scheduler.NewService(false)
+		if err != nil {
+			return err
+		}
+
+		exp, err := exporter.New(tunnelSvc, dbSvc, scheduleSvc, exporterInterval)
+		if err != nil {
+			return err
+		}
+		return exp.Run(cmd.Context(), exporterListen)
+	},
+}
+
+func init() {
+	exporterCmd.Flags().StringVar(&exporterListen, "listen", exporter.DefaultListenAddress, "Address to serve metrics on")
+	exporterCmd.Flags().DurationVar(&exporterInterval, "interval", 30*time.Second, "How often to collect metrics")
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historySince   string
+	historySubject string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the local audit log of changes made with orb",
+	Long: `Show who changed what and when.
+
+Every change made by orb (exposing and unexposing services, access changes,
+Access groups, databases and schedules) is appended to a local audit log,
+including revocations run automatically when --expires lapses. Each entry
+records the time, user, command, subject, before/after values and the IDs of
+the Cloudflare objects involved.
+
+The log is kept at $XDG_STATE_HOME/orb/audit.jsonl (default ~/.local/state/orb/audit.jsonl).`,
+	Example: `  orb history                  # All recorded changes
+  orb history --since 24h      # Changes in the last day
+  orb history --subject api    # Changes to api.<domain>
+  orb history --subject friends --since 7d`,
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := audit.Filter{Subject: historySubject}
+		if historySince != "" {
+			since, err := tunnel.ParseExpiresDuration(historySince)
+			if err != nil {
+				return fmt.Errorf("invalid --since %q (use e.g. 30m, 24h, 7d)", historySince)
+			}
+			filter.Since = time.Now().Add(-since)
+		}
+
+		log, err := audit.Open()
+		if err != nil {
+			return err
+		}
+		return log.History(filter)
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only show changes newer than this (e.g., 30m, 24h, 7d)")
+	historyCmd.Flags().StringVar(&historySubject, "subject", "", "Only show changes to this subdomain, group, database or schedule")
+}
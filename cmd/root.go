@@ -1,11 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/tunnel"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	// apiTimeout bounds each Cloudflare API request, retries included
+	apiTimeout time.Duration
+	// dryRun makes mutating commands print their planned changes instead of applying them
+	dryRun bool
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "orb",
 	Short: "CLI for managing Cloudflare Tunnels with Zero Trust access control",
@@ -13,17 +28,64 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// cancel in-flight API calls and commands on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().DurationVar(&apiTimeout, "timeout", 30*time.Second, "Timeout for each Cloudflare API request, including retries (0 disables)")
+
 	rootCmd.AddCommand(tunnelCmd)
 	rootCmd.AddCommand(accessCmd)
 	rootCmd.AddCommand(scheduleCmd)
 	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(agentCmd)
+	rootCmd.AddCommand(connectCmd)
+	rootCmd.AddCommand(sshConfigCmd)
+	rootCmd.AddCommand(exporterCmd)
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 }
+
+// addDryRunFlag registers --dry-run on mutating commands
+func addDryRunFlag(cmds ...*cobra.Command) {
+	for _, c := range cmds {
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "Print what would change without applying it")
+	}
+}
+
+// isMutating reports whether a command changes things, i.e. it has --dry-run
+func isMutating(cmd *cobra.Command) bool {
+	return cmd.Flags().Lookup("dry-run") != nil
+}
+
+// tunnelOptions returns the tunnel service settings from global flags
+func tunnelOptions() tunnel.Options {
+	return tunnel.Options{Timeout: apiTimeout, DryRun: dryRun}
+}
+
+// newTunnelService creates the tunnel service and, for commands that change
+// things, first applies any expiries that fell due while no timer or agent was
+// running (e.g. the machine was off)
+func newTunnelService(cmd *cobra.Command) (*tunnel.Service, error) {
+	svc, err := tunnel.NewService(tunnelOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	// read-only commands (those without --dry-run) and dry runs leave everything as is,
+	// and commands started by an expiry timer apply that expiry themselves
+	if isMutating(cmd) && !dryRun && os.Getenv(audit.TriggerEnv) == "" {
+		if _, err := svc.RunDueExpiries(cmd.Context()); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Warning: failed to apply overdue expiries: %v\n", err)
+		}
+	}
+	return svc, nil
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+
 	"orb/internal/scheduler"
 
 	"github.com/spf13/cobra"
@@ -11,13 +13,22 @@ var schedulerSvc *scheduler.Service
 var scheduleCmd = &cobra.Command{
 	Use:   "schedule",
 	Short: "Manage scheduled tasks using cron",
+	Long: `Manage scheduled tasks using cron.
+
+Cron runs each task through ` + "`orb schedule run`" + `, which records its last result
+(shown by ` + "`orb schedule list`" + ` and exported by ` + "`orb exporter`" + `).
+Entries added by older versions run the command directly; switch them over with
+` + "`orb schedule migrate`" + `.`,
 	Example: `  orb schedule add backup "0 2 * * *" "./backup.sh"     # Daily at 2am
   orb schedule add hourly-sync "0 * * * *" "sync.py"    # Every hour
   orb schedule list                                      # Show all schedules
-  orb schedule remove backup                             # Remove a schedule`,
+  orb schedule run backup                                # Run a schedule now
+  orb schedule remove backup                             # Remove a schedule
+  orb schedule remove backup --dry-run                   # Preview the crontab change
+  orb schedule migrate                                   # Record runs of older entries too`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		schedulerSvc, err = scheduler.NewService()
+		schedulerSvc, err = scheduler.NewService(dryRun)
 		return err
 	},
 }
@@ -26,6 +37,10 @@ func init() {
 	scheduleCmd.AddCommand(scheduleAddCmd)
 	scheduleCmd.AddCommand(scheduleRemoveCmd)
 	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+	scheduleCmd.AddCommand(scheduleMigrateCmd)
+
+	addDryRunFlag(scheduleAddCmd, scheduleRemoveCmd, scheduleMigrateCmd)
 }
 
 var scheduleAddCmd = &cobra.Command{
@@ -70,3 +85,39 @@ var scheduleListCmd = &cobra.Command{
 		return schedulerSvc.List()
 	},
 }
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a scheduled task now and record its result",
+	Long: `Run a scheduled task's command and record its start time, duration and exit code.
+
+This is what cron runs; it exits with the command's exit status.`,
+	Example:               "  orb schedule run backup",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		code, err := schedulerSvc.Run(args[0])
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			os.Exit(code)
+		}
+		return nil
+	},
+}
+
+var scheduleMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Run schedules added by older versions through orb schedule run",
+	Long: `Rewrite the crontab entries of schedules added before ` + "`orb schedule run`" + ` existed,
+which run their command directly, so that their runs are recorded too.
+
+Entries run the orb found on PATH, so it must be installed there.`,
+	Example: `  orb schedule migrate --dry-run   # Preview the crontab change
+  orb schedule migrate`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return schedulerSvc.Migrate()
+	},
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"orb/internal/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	sshConfigInstall   bool
+	sshConfigUninstall bool
+)
+
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Generate SSH client config for ssh services",
+	Long: `Print a Host entry for every ssh service in the tunnel config, reached through
+"ProxyCommand cloudflared access ssh --hostname %h", so that "ssh box.example.com"
+logs in through Cloudflare Access.
+
+With --install the entries are merged into ~/.ssh/config between marker comments,
+leaving the rest of the file alone. From then on expose, update and unexpose keep
+the block in sync. Only CONFIG_PATH needs to be set - no Cloudflare API token is used.`,
+	Example: `  orb ssh-config                             # Print the Host entries
+  orb ssh-config >> ~/.ssh/config            # Add them once by hand
+  orb ssh-config --install                   # Manage them in ~/.ssh/config
+  orb ssh-config --install --dry-run         # Show the change to ~/.ssh/config
+  orb ssh-config --uninstall                 # Remove orb's block`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sshConfigInstall && sshConfigUninstall {
+			return fmt.Errorf("use either --install or --uninstall")
+		}
+		svc, err := tunnel.NewSSHConfigService(dryRun)
+		if err != nil {
+			return err
+		}
+		if sshConfigUninstall {
+			return svc.UninstallSSHConfig()
+		}
+		return svc.SSHConfig(sshConfigInstall)
+	},
+}
+
+func init() {
+	sshConfigCmd.Flags().BoolVar(&sshConfigInstall, "install", false, "Merge the Host entries into ~/.ssh/config and keep them in sync")
+	sshConfigCmd.Flags().BoolVar(&sshConfigUninstall, "uninstall", false, "Remove orb's Host entries from ~/.ssh/config")
+	addDryRunFlag(sshConfigCmd)
+}
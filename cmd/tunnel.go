@@ -14,9 +14,36 @@ var (
 	exposeType    string
 	exposeAccess  string
 	exposeExpires string
+	exposeTTL     string
+	publicPaths   []string
+	allowRules    []string
+	requireRules  []string
+	excludeRules  []string
+	accessReset   bool
+	accessExpires string
+	addGroup      string
+	removeGroup   string
+	expiryKind    string
+	expiryTarget  string
+	shareFor      string
+	sessionDur    string
+	idps          []string
+	autoRedirect  bool
+	corsOrigins   []string
+	corsMethods   []string
+	corsHeaders   []string
+	corsCreds     bool
+	noCORS        bool
+	launcher      bool
+	denyMessage   string
+	denyURL       string
 	updateType    string
 	logsFollow    bool
 	logsLines     int
+	routeNote     string
+	expectConns   int
+	metricsOff    bool
+	statsWatch    bool
 	serviceDesc   = fmt.Sprintf("Service type: %s", strings.Join(tunnel.ValidServiceTypes, ", "))
 )
 
@@ -27,10 +54,17 @@ var tunnelCmd = &cobra.Command{
   orb tunnel expose api 8080 --access friends # Restrict to a group
   orb tunnel unexpose api                     # Remove the subdomain
   orb tunnel list                             # Show all services with health
+  orb tunnel extend demo 1h                   # Push back a --ttl deadline
+  orb tunnel expiries                         # Show pending expiries
+  orb tunnel access api                       # Show who can reach api
+  orb tunnel inspect api                      # Show target, login settings and policies
+  orb tunnel access api friends               # Switch api to group access in place
+  orb tunnel access api --add-group qa        # Let one more group in
+  orb tunnel share api bob@x.com --for 4h     # Let one person in for an afternoon
   orb tunnel revoke-access api                # Revoke group access`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
-		tunnelSvc, err = tunnel.NewService()
+		tunnelSvc, err = newTunnelService(cmd)
 		return err
 	},
 }
@@ -43,28 +77,146 @@ func init() {
 	tunnelCmd.AddCommand(healthCmd)
 	tunnelCmd.AddCommand(restartCmd)
 	tunnelCmd.AddCommand(statusCmd)
+	tunnelCmd.AddCommand(connectionsCmd)
+	tunnelCmd.AddCommand(metricsCmd)
+	tunnelCmd.AddCommand(statsCmd)
 	tunnelCmd.AddCommand(logsCmd)
 	tunnelCmd.AddCommand(revokeAccessCmd)
+	tunnelCmd.AddCommand(tunnelAccessCmd)
+	tunnelCmd.AddCommand(settingsCmd)
+	tunnelCmd.AddCommand(inspectCmd)
+	tunnelCmd.AddCommand(shareCmd)
+	tunnelCmd.AddCommand(sharesCmd)
+	tunnelCmd.AddCommand(unshareCmd)
+	tunnelCmd.AddCommand(extendCmd)
+	tunnelCmd.AddCommand(expiriesCmd)
+	expiriesCmd.AddCommand(expiriesExtendCmd)
+	expiriesCmd.AddCommand(expiriesCancelCmd)
+	tunnelCmd.AddCommand(routeCmd)
+	routeCmd.AddCommand(routeAddCmd)
+	routeCmd.AddCommand(routeListCmd)
+	routeCmd.AddCommand(routeRemoveCmd)
 
 	exposeCmd.Flags().StringVarP(&exposeType, "type", "t", tunnel.DefaultServiceType, serviceDesc)
-	exposeCmd.Flags().StringVarP(&exposeAccess, "access", "a", tunnel.DefaultAccessLevel, "Access level: public, private, or group name")
+	exposeCmd.Flags().StringVarP(&exposeAccess, "access", "a", tunnel.DefaultAccessLevel, "Access level: public, private, or group names (e.g., team,contractors:7d)")
 	exposeCmd.Flags().StringVarP(&exposeExpires, "expires", "e", "", "Temporary access duration (e.g., 1h, 24h, 7d) - reverts to private after")
+	exposeCmd.Flags().StringVar(&exposeTTL, "ttl", "", "Exposure lifetime (e.g., 30m, 2h, 7d) - unexposes entirely after")
+	exposeCmd.Flags().StringSliceVar(&publicPaths, "public-path", nil, "Path reachable without login on a protected service, e.g. /webhook/* (repeatable)")
+	addRuleFlags(exposeCmd, tunnelAccessCmd)
+	addSettingsFlags(exposeCmd, settingsCmd)
+	settingsCmd.Flags().BoolVar(&noCORS, "no-cors", false, "Remove the CORS settings")
+	tunnelAccessCmd.Flags().BoolVar(&accessReset, "reset", false, "Remove all --allow, --require and --exclude rules")
+	tunnelAccessCmd.Flags().StringVarP(&accessExpires, "expires", "e", "", "Temporary group access duration (e.g., 1h, 24h, 7d) - reverts to private after (with --add-group: removes that group after)")
+	tunnelAccessCmd.Flags().StringVar(&addGroup, "add-group", "", "Grant one more Access group access, keeping the others")
+	tunnelAccessCmd.Flags().StringVar(&removeGroup, "remove-group", "", "Take one Access group's access away, keeping the others")
+	shareCmd.Flags().StringVar(&shareFor, "for", "", "How long the address keeps access (e.g., 30m, 4h, 2d)")
 	updateCmd.Flags().StringVarP(&updateType, "type", "t", tunnel.DefaultServiceType, serviceDesc)
 	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow logs in real-time")
 	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 50, "Number of lines to show")
+	connectionsCmd.Flags().IntVar(&expectConns, "expect", 0, "Connections expected in total (default: 4 per connector)")
+	metricsCmd.Flags().BoolVar(&metricsOff, "off", false, "Remove the metrics address from the config")
+	statsCmd.Flags().BoolVarP(&statsWatch, "watch", "w", false, "Keep refreshing until Ctrl+C")
+	routeAddCmd.Flags().StringVarP(&routeNote, "comment", "c", "", "What the network is (e.g., lab)")
+	for _, c := range []*cobra.Command{expiriesExtendCmd, expiriesCancelCmd} {
+		c.Flags().StringVarP(&expiryKind, "kind", "k", "", "Expiry to act on: access, ttl or group (needed only if several are pending)")
+		c.Flags().StringVar(&expiryTarget, "target", "", "Group of a group expiry (needed only if several groups expire)")
+	}
+	addDryRunFlag(exposeCmd, unexposeCmd, updateCmd, revokeAccessCmd, tunnelAccessCmd, settingsCmd, shareCmd, unshareCmd, extendCmd, expiriesExtendCmd, expiriesCancelCmd, routeAddCmd, routeRemoveCmd, metricsCmd)
+}
+
+// addRuleFlags registers the Access rule flags shared by expose and access
+func addRuleFlags(cmds ...*cobra.Command) {
+	for _, c := range cmds {
+		c.Flags().StringSliceVar(&allowRules, "allow", nil, "Also allow: email:, email-domain:, ip:, country:, service-token:, group: or everyone (repeatable)")
+		c.Flags().StringSliceVar(&requireRules, "require", nil, "Require every one of these rules for non-owner access (repeatable)")
+		c.Flags().StringSliceVar(&excludeRules, "exclude", nil, "Deny non-owner access matching any of these rules (repeatable)")
+	}
+}
+
+// addSettingsFlags registers the Access application setting flags shared by expose and settings
+func addSettingsFlags(cmds ...*cobra.Command) {
+	for _, c := range cmds {
+		c.Flags().StringVar(&sessionDur, "session-duration", "", "How long a login lasts (e.g., 30m, 8h, 720h; Cloudflare default 24h)")
+		c.Flags().StringSliceVar(&idps, "idp", nil, "Identity providers offered at login, by name (repeatable; all to offer every one)")
+		c.Flags().BoolVar(&autoRedirect, "auto-redirect", false, "Skip the login page and go straight to the only --idp")
+		c.Flags().StringSliceVar(&corsOrigins, "cors-origin", nil, "Origin allowed to call the service from a browser, or * (repeatable)")
+		c.Flags().StringSliceVar(&corsMethods, "cors-method", nil, "HTTP method allowed in CORS requests, or * (repeatable)")
+		c.Flags().StringSliceVar(&corsHeaders, "cors-header", nil, "Request header allowed in CORS requests, or * (repeatable)")
+		c.Flags().BoolVar(&corsCreds, "cors-credentials", false, "Allow CORS requests with cookies or authorization headers")
+		c.Flags().BoolVar(&launcher, "launcher", true, "Show the service in the Access App Launcher")
+		c.Flags().StringVar(&denyMessage, "deny-message", "", "Message shown when someone is denied access")
+		c.Flags().StringVar(&denyURL, "deny-url", "", "Redirect denied visitors to this URL instead")
+	}
+}
+
+// settingsChange returns the Access application settings set on the command line
+func settingsChange(cmd *cobra.Command) tunnel.SettingsChange {
+	var change tunnel.SettingsChange
+	flags := cmd.Flags()
+	if flags.Changed("session-duration") {
+		change.SessionDuration = &sessionDur
+	}
+	if flags.Changed("idp") {
+		change.IdentityProviders = &idps
+	}
+	if flags.Changed("auto-redirect") {
+		change.AutoRedirect = &autoRedirect
+	}
+	if flags.Changed("cors-origin") {
+		change.CORSOrigins = &corsOrigins
+	}
+	if flags.Changed("cors-method") {
+		change.CORSMethods = &corsMethods
+	}
+	if flags.Changed("cors-header") {
+		change.CORSHeaders = &corsHeaders
+	}
+	if flags.Changed("cors-credentials") {
+		change.CORSCredentials = &corsCreds
+	}
+	if flags.Changed("launcher") {
+		change.Launcher = &launcher
+	}
+	if flags.Changed("deny-message") {
+		change.DenyMessage = &denyMessage
+	}
+	if flags.Changed("deny-url") {
+		change.DenyURL = &denyURL
+	}
+	change.NoCORS = noCORS
+	return change
 }
 
 var exposeCmd = &cobra.Command{
 	Use:   "expose <subdomain> <port>",
 	Short: "Expose a local port at subdomain." + tunnel.Domain,
-	Example: `  orb tunnel expose api 8080                            # Public access
-  orb tunnel expose api 8080 --access private           # Only you can access
-  orb tunnel expose api 8080 --access friends           # Group access (permanent)
-  orb tunnel expose api 8080 --access friends -e 24h    # Group access for 24 hours
-  orb tunnel expose db 5432 --type tcp                  # TCP service (non-HTTP)`,
+	Example: `  orb tunnel expose api 8080                                  # Public access
+  orb tunnel expose api 8080 --access private                 # Only you can access
+  orb tunnel expose api 8080 --access friends                 # Group access (permanent)
+  orb tunnel expose api 8080 --access friends -e 24h          # Group access for 24 hours
+  orb tunnel expose api 8080 -a team,contractors:7d           # Two groups, contractors for a week
+  orb tunnel expose api 8080 --allow email-domain:example.com # You plus a whole domain
+  orb tunnel expose api 8080 -a friends --require country:CA  # Group access from Canada only
+  orb tunnel expose hook 9000 --allow service-token:ci        # Machine access with a service token
+  orb tunnel expose app 3000 -a private --public-path /hook/* # Private, but /hook/* needs no login
+  orb tunnel expose app 3000 -a team --session-duration 8h    # Log in again every 8 hours
+  orb tunnel expose demo 3000 --ttl 2h                        # Removed entirely after 2 hours
+  orb tunnel expose db 5432 --type tcp                        # TCP service (non-HTTP)
+  orb tunnel expose api 8080 --access friends --dry-run       # Preview changes only`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return tunnelSvc.Expose(args[0], args[1], exposeType, exposeAccess, exposeExpires)
+		rules, err := tunnel.ParseAccessRules(allowRules, requireRules, excludeRules)
+		if err != nil {
+			return err
+		}
+		return tunnelSvc.Expose(cmd.Context(), args[0], args[1], exposeType, tunnel.ExposeOptions{
+			Access:      exposeAccess,
+			Expires:     exposeExpires,
+			TTL:         exposeTTL,
+			Rules:       rules,
+			PublicPaths: publicPaths,
+			Settings:    settingsChange(cmd),
+		})
 	},
 }
 
@@ -75,7 +227,7 @@ var unexposeCmd = &cobra.Command{
 	Args:                  cobra.ExactArgs(1),
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return tunnelSvc.Unexpose(args[0])
+		return tunnelSvc.Unexpose(cmd.Context(), args[0])
 	},
 }
 
@@ -85,7 +237,7 @@ var updateCmd = &cobra.Command{
 	Example: "  orb tunnel update api 9090\n  orb tunnel update api 9090 --type tcp",
 	Args:    cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return tunnelSvc.Update(args[0], args[1], updateType)
+		return tunnelSvc.Update(cmd.Context(), args[0], args[1], updateType)
 	},
 }
 
@@ -95,7 +247,7 @@ var listCmd = &cobra.Command{
 	Args:                  cobra.NoArgs,
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return tunnelSvc.List()
+		return tunnelSvc.List(cmd.Context())
 	},
 }
 
@@ -106,7 +258,7 @@ var healthCmd = &cobra.Command{
 	Args:                  cobra.ExactArgs(1),
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return tunnelSvc.Health(args[0])
+		return tunnelSvc.Health(cmd.Context(), args[0])
 	},
 }
 
@@ -116,7 +268,7 @@ var restartCmd = &cobra.Command{
 	Args:                  cobra.NoArgs,
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return tunnelSvc.Restart()
+		return tunnelSvc.Restart(cmd.Context())
 	},
 }
 
@@ -126,7 +278,68 @@ var statusCmd = &cobra.Command{
 	Args:                  cobra.NoArgs,
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return tunnelSvc.Status()
+		return tunnelSvc.Status(cmd.Context())
+	},
+}
+
+var connectionsCmd = &cobra.Command{
+	Use:   "connections",
+	Short: "Show the tunnel's connectors and edge connections",
+	Long: `Show every cloudflared connector (replica) running the tunnel, wherever it runs,
+as Cloudflare sees it: connector ID, origin IP, version, the data centers (colos)
+it is connected to and since when.
+
+Each connector normally keeps 4 connections up; a warning is printed when fewer
+connections are up than expected.`,
+	Example: `  orb tunnel connections
+  orb tunnel connections --expect 8    # Two replicas should be running`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.Connections(cmd.Context(), expectConns)
+	},
+}
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [address]",
+	Short: "Show or set the address cloudflared serves Prometheus metrics on",
+	Long: `Show or set the metrics address (host:port) in the cloudflared config. Setting
+it restarts cloudflared, which then serves Prometheus metrics at
+http://<address>/metrics - read by ` + "`orb tunnel stats`" + `.`,
+	Example: `  orb tunnel metrics                     # Show the address
+  orb tunnel metrics ` + tunnel.DefaultMetricsAddress + `     # Serve metrics locally
+  orb tunnel metrics --off               # Stop serving metrics`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if metricsOff {
+			if len(args) > 0 {
+				return fmt.Errorf("give either an address or --off")
+			}
+			return tunnelSvc.SetMetrics(cmd.Context(), "")
+		}
+		if len(args) == 0 {
+			return tunnelSvc.ShowMetrics()
+		}
+		return tunnelSvc.SetMetrics(cmd.Context(), args[0])
+	},
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [subdomain]",
+	Short: "Show request rate, error rate and status codes from cloudflared metrics",
+	Long: `Scrape cloudflared's Prometheus metrics twice a few seconds apart and show the
+request rate, error rate, active requests and status-code breakdown, per service
+where cloudflared labels its metrics by hostname and for the whole tunnel
+otherwise. Needs a metrics address (see ` + "`orb tunnel metrics`" + `).`,
+	Example: `  orb tunnel stats
+  orb tunnel stats api
+  orb tunnel stats --watch               # Refresh until Ctrl+C`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subdomain := ""
+		if len(args) > 0 {
+			subdomain = args[0]
+		}
+		return tunnelSvc.Stats(cmd.Context(), subdomain, statsWatch)
 	},
 }
 
@@ -146,17 +359,248 @@ Examples:
 		if len(args) > 0 {
 			subdomain = args[0]
 		}
-		return tunnelSvc.Logs(subdomain, logsLines, logsFollow)
+		return tunnelSvc.Logs(cmd.Context(), subdomain, logsLines, logsFollow)
 	},
 }
 
 var revokeAccessCmd = &cobra.Command{
 	Use:                   "revoke-access <subdomain>",
-	Short:                 "Revoke group access, reverting to private (owner-only)",
+	Short:                 "Revoke group access, --allow rules, shares and public paths, reverting to private (owner-only)",
 	Example:               "  orb tunnel revoke-access api",
 	Args:                  cobra.ExactArgs(1),
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return tunnelSvc.RevokeAccess(args[0])
+		return tunnelSvc.RevokeAccess(cmd.Context(), args[0])
+	},
+}
+
+var tunnelAccessCmd = &cobra.Command{
+	Use:   "access <subdomain> [public|private|groups]",
+	Short: "Show or change the access level and rules of an exposed subdomain",
+	Long: `Show who can reach an exposed subdomain, change its access level, add or
+remove a group, or replace its rules.
+
+A group level may name several groups, each optionally with its own expiry:
+"team,contractors:7d" grants both groups and removes contractors after a week
+while team keeps access.
+
+Changing the level only creates, updates or deletes the subdomain's Access
+application and policies - ingress, DNS and cloudflared are left alone. If a
+policy call fails halfway, the previous policies are restored.
+
+Rules are written as type:value:
+  email:alice@example.com    A single address
+  email-domain:example.com   Anyone who logs in with an address at the domain
+  ip:203.0.113.0/24          An IP address or range
+  country:CA                 A two-letter country code
+  service-token:ci           Requests carrying a service token (no login)
+  group:friends              An Access group
+  everyone                   Anyone who logs in
+
+--allow grants access, --require rules must all match and --exclude rules deny.
+Require and exclude apply to group access too; the owner is never affected.
+Setting rules replaces the current ones.`,
+	Example: `  orb tunnel access api                                   # Show the rules
+  orb tunnel access api friends                           # Public → group, in place
+  orb tunnel access api team --expires 24h                # Group access for a day
+  orb tunnel access api team,contractors:7d               # Two groups, contractors for a week
+  orb tunnel access api --add-group qa                    # Add a group, keeping the others
+  orb tunnel access api --add-group qa --expires 3d       # Add a group for three days
+  orb tunnel access api --remove-group contractors        # Remove just one group
+  orb tunnel access api private                           # Owner only
+  orb tunnel access api public                            # Remove the Access application
+  orb tunnel access api --allow email-domain:example.com  # Replace the allow rules
+  orb tunnel access api --allow ip:203.0.113.0/24 --exclude country:RU
+  orb tunnel access api --reset                           # Back to owner and group only`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rules, err := tunnel.ParseAccessRules(allowRules, requireRules, excludeRules)
+		if err != nil {
+			return err
+		}
+		if addGroup != "" || removeGroup != "" {
+			if len(args) == 2 || accessReset || !rules.IsZero() || (addGroup != "" && removeGroup != "") {
+				return fmt.Errorf("--add-group and --remove-group cannot be combined with each other, an access level or rules")
+			}
+			if removeGroup != "" {
+				if accessExpires != "" {
+					return fmt.Errorf("--expires cannot be used with --remove-group")
+				}
+				return tunnelSvc.RemoveGroup(cmd.Context(), args[0], removeGroup)
+			}
+			return tunnelSvc.AddGroup(cmd.Context(), args[0], addGroup, accessExpires)
+		}
+		if len(args) == 2 {
+			if accessReset || !rules.IsZero() {
+				return fmt.Errorf("change the access level and the rules in separate commands")
+			}
+			return tunnelSvc.ChangeAccess(cmd.Context(), args[0], args[1], accessExpires)
+		}
+		if accessExpires != "" {
+			return fmt.Errorf("--expires needs an access level (e.g., orb tunnel access %s friends --expires 24h)", args[0])
+		}
+		if accessReset && !rules.IsZero() {
+			return fmt.Errorf("--reset cannot be combined with --allow, --require or --exclude")
+		}
+		if !accessReset && rules.IsZero() {
+			return tunnelSvc.ShowAccess(cmd.Context(), args[0])
+		}
+		return tunnelSvc.SetAccess(cmd.Context(), args[0], rules)
+	},
+}
+
+var settingsCmd = &cobra.Command{
+	Use:   "settings <subdomain>",
+	Short: "Change the login settings of a protected service",
+	Long: `Change the settings of a service's Access application - session duration,
+identity providers, CORS, App Launcher visibility and what denied visitors see.
+Settings not given keep their current value; policies are left alone.`,
+	Example: `  orb tunnel settings api --session-duration 8h
+  orb tunnel settings api --idp github --auto-redirect        # Straight to GitHub login
+  orb tunnel settings api --idp all --auto-redirect=false     # Offer every provider again
+  orb tunnel settings api --cors-origin https://app.x.com --cors-method GET,POST
+  orb tunnel settings api --no-cors
+  orb tunnel settings api --launcher=false --deny-url https://x.com/ask-for-access`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.SetAppSettings(cmd.Context(), args[0], settingsChange(cmd))
+	},
+}
+
+var inspectCmd = &cobra.Command{
+	Use:                   "inspect <subdomain>",
+	Short:                 "Show a service's target, login settings, policies and expiries",
+	Example:               "  orb tunnel inspect api",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.Inspect(cmd.Context(), args[0])
+	},
+}
+
+var shareCmd = &cobra.Command{
+	Use:   "share <subdomain> <email>",
+	Short: "Give one email address access to a service for a limited time",
+	Long: `Give one email address access to a protected service without creating an
+Access group. The grant is its own Access policy and is removed automatically
+once --for has passed; sharing again with the same address moves the deadline.`,
+	Example: `  orb tunnel share api bob@x.com --for 4h
+  orb tunnel share api bob@x.com --for 2d     # Extend an existing share`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.Share(cmd.Context(), args[0], args[1], shareFor)
+	},
+}
+
+var sharesCmd = &cobra.Command{
+	Use:                   "shares <subdomain>",
+	Short:                 "List who a service is shared with and until when",
+	Example:               "  orb tunnel shares api",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.Shares(cmd.Context(), args[0])
+	},
+}
+
+var unshareCmd = &cobra.Command{
+	Use:     "unshare <subdomain> <email>",
+	Short:   "Remove an email address's access before its time is up",
+	Example: "  orb tunnel unshare api bob@x.com",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.Unshare(cmd.Context(), args[0], args[1])
+	},
+}
+
+var extendCmd = &cobra.Command{
+	Use:                   "extend <subdomain> <duration>",
+	Short:                 "Push back the TTL of a service exposed with --ttl",
+	Example:               "  orb tunnel extend demo 1h\n  orb tunnel extend demo 2d",
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.ExtendExpiry(cmd.Context(), args[0], tunnel.ExpiryTTL, "", args[1])
+	},
+}
+
+var expiriesCmd = &cobra.Command{
+	Use:   "expiries",
+	Short: "Show pending access expiries and TTLs",
+	Long: `Show when group access reverts to private (--expires), when individual
+groups lose access (--access team,contractors:7d) and when time-limited
+exposures are removed (--ttl).`,
+	Example: `  orb tunnel expiries                        # List pending expiries
+  orb tunnel expiries extend api 24h         # Push back api's expiry by a day
+  orb tunnel expiries extend demo 1h -k ttl  # Pick one when several are pending
+  orb tunnel expiries cancel api -k group --target contractors
+  orb tunnel expiries cancel api             # Keep api's current access`,
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.ListExpiries(cmd.Context())
+	},
+}
+
+var expiriesExtendCmd = &cobra.Command{
+	Use:     "extend <subdomain> <duration>",
+	Short:   "Push back a pending expiry",
+	Example: "  orb tunnel expiries extend api 24h\n  orb tunnel expiries extend demo 1h --kind ttl",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.ExtendExpiry(cmd.Context(), args[0], expiryKind, expiryTarget, args[1])
+	},
+}
+
+var expiriesCancelCmd = &cobra.Command{
+	Use:     "cancel <subdomain>",
+	Short:   "Cancel a pending expiry, keeping the current access or exposure",
+	Example: "  orb tunnel expiries cancel api\n  orb tunnel expiries cancel demo --kind ttl",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.CancelExpiry(cmd.Context(), args[0], expiryKind, expiryTarget)
+	},
+}
+
+var routeCmd = &cobra.Command{
+	Use:   "route",
+	Short: "Route private networks through the tunnel to WARP clients",
+	Long: `Reach internal services by their private IPs from devices running WARP,
+instead of exposing each one on a public hostname.
+
+Adding a route turns on warp-routing in the cloudflared config (restarting
+cloudflared); removing the last one turns it off again.`,
+	Example: `  orb tunnel route add 10.0.0.0/24 --comment lab
+  orb tunnel route list
+  orb tunnel route remove 10.0.0.0/24`,
+}
+
+var routeAddCmd = &cobra.Command{
+	Use:     "add <network>",
+	Short:   "Route a private network (CIDR or IP) through the tunnel",
+	Example: "  orb tunnel route add 10.0.0.0/24 --comment lab\n  orb tunnel route add 192.168.1.20         # A single host (/32)",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.AddRoute(cmd.Context(), args[0], routeNote)
+	},
+}
+
+var routeListCmd = &cobra.Command{
+	Use:                   "list",
+	Short:                 "List the private networks routed through the tunnel",
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.ListRoutes(cmd.Context())
+	},
+}
+
+var routeRemoveCmd = &cobra.Command{
+	Use:     "remove <network>",
+	Short:   "Stop routing a private network through the tunnel",
+	Example: "  orb tunnel route remove 10.0.0.0/24",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tunnelSvc.RemoveRoute(cmd.Context(), args[0])
 	},
 }
@@ -0,0 +1,292 @@
+// Package audit keeps an append-only local log of the changes orb makes
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// TriggerEnv names the environment variable that marks a change as automated
+// (e.g. ORB_TRIGGER=expiry for revocations run by an expiry timer)
+const TriggerEnv = "ORB_TRIGGER"
+
+// Entry is a single recorded change
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	User    string            `json:"user"`
+	Trigger string            `json:"trigger,omitempty"`
+	Command string            `json:"command"`
+	Action  string            `json:"action"`  // e.g. "tunnel.expose", "access.update"
+	Subject string            `json:"subject"` // hostname, group, database or schedule name
+	Before  map[string]string `json:"before,omitempty"`
+	After   map[string]string `json:"after,omitempty"`
+	IDs     map[string]string `json:"ids,omitempty"` // Cloudflare and docker object IDs
+}
+
+// Log is an append-only JSON Lines file of entries
+type Log struct {
+	path string
+}
+
+// Filter selects entries when reading the log
+type Filter struct {
+	Since   time.Time // zero means no lower bound
+	Subject string    // matches the subject exactly or its first label (e.g. "api" matches api.example.com)
+}
+
+// DefaultPath returns the audit log location in orb's state directory
+func DefaultPath() (string, error) {
+	if stateDir := os.Getenv("XDG_STATE_HOME"); stateDir != "" {
+		return filepath.Join(stateDir, "orb", "audit.jsonl"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".local", "state", "orb", "audit.jsonl"), nil
+}
+
+// Open returns the audit log at its default path
+func Open() (*Log, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return New(path), nil
+}
+
+// New returns an audit log stored at path
+func New(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the location of the log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends an entry, filling in the time, user, trigger and command line.
+// Recording to a nil Log is a no-op.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.User == "" {
+		e.User = currentUser()
+	}
+	if e.Trigger == "" {
+		e.Trigger = os.Getenv(TriggerEnv)
+	}
+	if e.Command == "" {
+		e.Command = commandLine(os.Args)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	// a single write keeps concurrent appends from interleaving
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries matching filter, oldest first
+func (l *Log) Read(filter Filter) ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil // nothing recorded yet
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // skip a torn or hand-edited line rather than hiding the rest
+		}
+		if filter.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// History prints the entries matching filter as a table, oldest first
+func (l *Log) History(filter Filter) error {
+	entries, err := l.Read(filter)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No recorded changes")
+		fmt.Printf("  Log: %s\n", l.path)
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Time", "User", "Action", "Subject", "Change", "IDs")
+
+	for _, e := range entries {
+		who := e.User
+		if e.Trigger != "" {
+			who = fmt.Sprintf("%s (%s)", e.User, e.Trigger)
+		}
+		if err := table.Append(
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			who,
+			e.Action,
+			e.Subject,
+			e.Change(),
+			e.ObjectIDs(),
+		); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nHistory (%d change(s)):\n", len(entries))
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+
+	return nil
+}
+
+// Match reports whether an entry passes the filter
+func (f Filter) Match(e Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.Subject != "" && e.Subject != f.Subject && !strings.HasPrefix(e.Subject, f.Subject+".") {
+		return false
+	}
+	return true
+}
+
+// Fields builds a before/after or ID map from key/value pairs, dropping empty values
+func Fields(kv ...string) map[string]string {
+	fields := make(map[string]string)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			fields[kv[i]] = kv[i+1]
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// Change summarises the before and after values (e.g. "access: friends → private")
+func (e Entry) Change() string {
+	keys := make(map[string]bool)
+	for k := range e.Before {
+		keys[k] = true
+	}
+	for k := range e.After {
+		keys[k] = true
+	}
+
+	var parts []string
+	for _, k := range sortedKeys(keys) {
+		before, hadBefore := e.Before[k]
+		after, hasAfter := e.After[k]
+		switch {
+		case hadBefore && hasAfter && before == after:
+			continue
+		case hadBefore && hasAfter:
+			parts = append(parts, fmt.Sprintf("%s: %s → %s", k, before, after))
+		case hasAfter:
+			parts = append(parts, fmt.Sprintf("%s: %s", k, after))
+		default:
+			parts = append(parts, fmt.Sprintf("%s: %s → -", k, before))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ObjectIDs renders the recorded object IDs one per line
+func (e Entry) ObjectIDs() string {
+	keys := make(map[string]bool)
+	for k := range e.IDs {
+		keys[k] = true
+	}
+
+	var parts []string
+	for _, k := range sortedKeys(keys) {
+		parts = append(parts, fmt.Sprintf("%s: %s", k, e.IDs[k]))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// currentUser returns the invoking user, looking through sudo
+func currentUser() string {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// commandLine renders the process arguments as a shell-like command line
+func commandLine(args []string) string {
+	parts := []string{"orb"}
+	if len(args) > 1 {
+		for _, arg := range args[1:] {
+			if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+				arg = strconv.Quote(arg)
+			}
+			parts = append(parts, arg)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -22,7 +22,10 @@ var KnownKeys = []ConfigKey{
 	{Name: "CLOUDFLARE_API_TOKEN", Description: "Cloudflare API token", Required: true},
 	{Name: "CLOUDFLARE_ZONE_ID", Description: "Cloudflare Zone ID", Required: true},
 	{Name: "CLOUDFLARE_ACCOUNT_ID", Description: "Cloudflare Account ID", Required: true},
-	{Name: "USER_EMAIL", Description: "Your email (for private access)", Required: false},
+	{Name: "OWNER_EMAIL", Description: "Owner emails, comma-separated (always let into protected services)", Required: false},
+	{Name: "OWNER_GROUP", Description: "Access group of owners, e.g. admins (always let into protected services)", Required: false},
+	{Name: "USER_EMAIL", Description: "Older name for OWNER_EMAIL (both are read)", Required: false},
+	{Name: "EXPIRY_BACKEND", Description: "How expiries are applied: systemd (timers) or agent (orb agent)", Required: false},
 }
 
 // Service manages orb configuration
@@ -9,6 +9,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"orb/internal/audit"
+	"orb/internal/plan"
 )
 
 // DBConfig represents a managed database instance
@@ -60,10 +63,13 @@ var SupportedDBs = map[string]DBType{
 type Service struct {
 	configDir string
 	dataDir   string
+	audit     *audit.Log
+	dryRun    bool
 }
 
 // NewService creates a new database service
-func NewService() (*Service, error) {
+// With dryRun set, Create and Delete print the planned changes instead of applying them
+func NewService(dryRun bool) (*Service, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
@@ -80,12 +86,26 @@ func NewService() (*Service, error) {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
+	auditLog, err := audit.Open()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Service{
 		configDir: configDir,
 		dataDir:   dataDir,
+		audit:     auditLog,
+		dryRun:    dryRun,
 	}, nil
 }
 
+// record appends a completed change to the audit log without failing the change
+func (s *Service) record(entry audit.Entry) {
+	if err := s.audit.Record(entry); err != nil {
+		fmt.Printf("⚠ Warning: failed to record change in audit log: %v\n", err)
+	}
+}
+
 // checkDocker verifies Docker is available
 func (s *Service) checkDocker() error {
 	cmd := exec.Command("docker", "info")
@@ -126,11 +146,7 @@ func (s *Service) Create(dbType, name, port string) error {
 		return err
 	}
 
-	// Create data directory for this database
 	dataPath := filepath.Join(s.dataDir, name)
-	if err := os.MkdirAll(dataPath, 0700); err != nil {
-		return fmt.Errorf("failed to create data directory: %w", err)
-	}
 
 	// Build docker run command
 	containerName := fmt.Sprintf("orb-db-%s", name)
@@ -149,6 +165,21 @@ func (s *Service) Create(dbType, name, port string) error {
 
 	args = append(args, dbConfig.Image)
 
+	if s.dryRun {
+		p := plan.New()
+		p.Add("Filesystem", plan.Create, "data directory %s", dataPath)
+		p.Add("Filesystem", plan.Create, "config %s", filepath.Join(s.configDir, name+".json"))
+		p.Add("Docker", plan.Create, "container %s", containerName)
+		p.Note("Docker", "docker %s", strings.Join(args, " "))
+		p.Print()
+		return nil
+	}
+
+	// Create data directory for this database
+	if err := os.MkdirAll(dataPath, 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
 	cmd := exec.Command("docker", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -172,6 +203,13 @@ func (s *Service) Create(dbType, name, port string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
+	s.record(audit.Entry{
+		Action:  "db.create",
+		Subject: name,
+		After:   audit.Fields("type", dbType, "port", port, "data", dataPath),
+		IDs:     audit.Fields("container", containerID),
+	})
+
 	fmt.Printf("✔ Created %s database %q\n", dbType, name)
 	fmt.Printf("  Port: %s\n", port)
 	fmt.Printf("  Data: %s\n", dataPath)
@@ -224,6 +262,26 @@ func (s *Service) List() error {
 	return nil
 }
 
+// DBStatus is a managed database and the state of its container
+type DBStatus struct {
+	DBConfig
+	Status string // docker's container state (e.g. "running", "exited"), or "unknown"
+}
+
+// Statuses returns every managed database with the state of its container
+func (s *Service) Statuses() ([]DBStatus, error) {
+	configs, err := s.getAllConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]DBStatus, 0, len(configs))
+	for _, cfg := range configs {
+		statuses = append(statuses, DBStatus{DBConfig: cfg, Status: s.getContainerStatus(cfg.Name)})
+	}
+	return statuses, nil
+}
+
 // getContainerStatus checks if the container is running
 func (s *Service) getContainerStatus(name string) string {
 	containerName := fmt.Sprintf("orb-db-%s", name)
@@ -300,6 +358,20 @@ func (s *Service) Delete(name string, keepData bool) error {
 	}
 
 	containerName := fmt.Sprintf("orb-db-%s", name)
+	configPath := filepath.Join(s.configDir, name+".json")
+
+	if s.dryRun {
+		p := plan.New()
+		p.Add("Docker", plan.Delete, "container %s (docker rm -f %s)", containerName, containerName)
+		if keepData {
+			p.Note("Filesystem", "data directory %s is kept", cfg.DataDir)
+		} else {
+			p.Add("Filesystem", plan.Delete, "data directory %s", cfg.DataDir)
+		}
+		p.Add("Filesystem", plan.Delete, "config %s", configPath)
+		p.Print()
+		return nil
+	}
 
 	// Remove container
 	cmd := exec.Command("docker", "rm", "-f", containerName)
@@ -315,9 +387,20 @@ func (s *Service) Delete(name string, keepData bool) error {
 	}
 
 	// Remove config
-	configPath := filepath.Join(s.configDir, name+".json")
 	os.Remove(configPath)
 
+	dataAfter := "deleted"
+	if keepData {
+		dataAfter = "kept"
+	}
+	s.record(audit.Entry{
+		Action:  "db.delete",
+		Subject: name,
+		Before:  audit.Fields("type", cfg.Type, "port", cfg.Port, "data", cfg.DataDir),
+		After:   audit.Fields("data", dataAfter),
+		IDs:     audit.Fields("container", cfg.ContainerID),
+	})
+
 	fmt.Printf("✔ Deleted database %q\n", name)
 	if keepData {
 		fmt.Printf("  Data preserved at: %s\n", cfg.DataDir)
@@ -0,0 +1,63 @@
+package dns
+
+import (
+	"context"
+	"time"
+)
+
+// API is the set of Cloudflare operations orb performs. *Client implements it
+// against the real API; dnstest.Fake implements it in memory for offline tests.
+// Mutating calls return the IDs of the objects they created or deleted.
+type API interface {
+	// Tunnels
+	GetTunnelName(ctx context.Context, tunnelID string) (string, error)
+	ListTunnelConnectors(ctx context.Context, tunnelID string) ([]Connector, error)
+
+	// Private network routes (WARP routing), identified by their network
+	ListTunnelRoutes(ctx context.Context, tunnelID string) ([]Route, error)
+	CreateTunnelRoute(ctx context.Context, tunnelID, network, comment string) error
+	DeleteTunnelRoute(ctx context.Context, network string) error
+
+	// DNS
+	CreateDNSRoute(ctx context.Context, tunnelID, hostname string) (string, error)
+	RemoveDNSRoute(ctx context.Context, tunnelID, hostname string) ([]string, error)
+
+	// Access applications and policies
+	CreateAccessPolicy(ctx context.Context, hostname, accessLevel string, owners []Rule, rules AccessRules, settings AppSettings) (string, error)
+	SetAccessRules(ctx context.Context, hostname string, owners []Rule, rules AccessRules) (string, error)
+	GetAccess(ctx context.Context, hostname string) (*Access, error)
+	ListAccess(ctx context.Context, hostnames []string) (map[string]*Access, error)
+	ApplyAccess(ctx context.Context, hostname string, desired *Access) (string, error)
+	CreatePublicPath(ctx context.Context, hostname, path string) (string, error)
+	GetAppSettings(ctx context.Context, hostname string) (*AppSettings, error)
+	SetAppSettings(ctx context.Context, hostname string, settings AppSettings) error
+	GetAccessInfo(ctx context.Context, hostname string) string
+	RemoveAccessPolicy(ctx context.Context, hostname string) (string, error)
+	RevokeGroupAccess(ctx context.Context, hostname string) ([]string, error)
+
+	// Access groups
+	CreateAccessGroup(ctx context.Context, groupName, emails string) (string, error)
+	ListAccessGroups(ctx context.Context) ([]Group, error)
+	UpdateAccessGroupMembers(ctx context.Context, groupName string, addEmails, removeEmails []string) error
+	GetAccessGroupMembers(ctx context.Context, groupName string) ([]string, error)
+	GetAccessGroupRules(ctx context.Context, groupName string) ([]Rule, error)
+	SetAccessGroupRules(ctx context.Context, groupName string, members []Rule) (string, error)
+	DeleteAccessGroup(ctx context.Context, groupName string) (string, error)
+
+	// Access service tokens
+	CreateServiceToken(ctx context.Context, name, duration string) (*ServiceToken, error)
+	ListServiceTokens(ctx context.Context) ([]ServiceToken, error)
+	RotateServiceToken(ctx context.Context, name string) (*ServiceToken, error)
+	DeleteServiceToken(ctx context.Context, name string) (string, error)
+
+	// Access authentication logs
+	GetAccessLogs(ctx context.Context, hostname string, since time.Time) ([]AccessLogEntry, error)
+}
+
+// Group is a Cloudflare Access group
+type Group struct {
+	ID   string
+	Name string
+}
+
+var _ API = (*Client)(nil)
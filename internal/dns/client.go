@@ -2,10 +2,13 @@ package dns
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
-	"os/exec"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/cloudflare/cloudflare-go"
 )
@@ -18,8 +21,13 @@ type Client struct {
 }
 
 // New creates a new Cloudflare DNS client
-func New() (*Client, error) {
-	api, err := cloudflare.NewWithAPIToken(os.Getenv("CLOUDFLARE_API_TOKEN"))
+// timeout bounds each API request, retries included (0 means no limit)
+func New(timeout time.Duration) (*Client, error) {
+	api, err := cloudflare.NewWithAPIToken(os.Getenv("CLOUDFLARE_API_TOKEN"),
+		cloudflare.HTTPClient(&http.Client{Transport: newRetryTransport(timeout)}),
+		// retries are handled by retryTransport, which honors Retry-After
+		cloudflare.UsingRetryPolicy(0, 0, 0),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloudflare client: %w", err)
 	}
@@ -32,9 +40,7 @@ func New() (*Client, error) {
 }
 
 // GetTunnelName retrieves the tunnel name from the Cloudflare API using the tunnel ID
-func (c *Client) GetTunnelName(tunnelID string) (string, error) {
-	ctx := context.Background()
-
+func (c *Client) GetTunnelName(ctx context.Context, tunnelID string) (string, error) {
 	tunnel, err := c.api.GetTunnel(ctx, cloudflare.AccountIdentifier(c.accountID), tunnelID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get tunnel: %w", err)
@@ -43,10 +49,8 @@ func (c *Client) GetTunnelName(tunnelID string) (string, error) {
 	return tunnel.Name, nil
 }
 
-// CreateDNSRoute creates a CNAME DNS record for the tunnel
-func (c *Client) CreateDNSRoute(tunnelID, hostname string) error {
-	ctx := context.Background()
-
+// CreateDNSRoute creates a CNAME DNS record for the tunnel and returns its ID
+func (c *Client) CreateDNSRoute(ctx context.Context, tunnelID, hostname string) (string, error) {
 	target := fmt.Sprintf("%s.cfargotunnel.com", tunnelID)
 
 	params := cloudflare.CreateDNSRecordParams{
@@ -57,443 +61,820 @@ func (c *Client) CreateDNSRoute(tunnelID, hostname string) error {
 		TTL:     1,
 	}
 
-	_, err := c.api.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(c.zoneID), params)
+	record, err := c.api.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(c.zoneID), params)
 	if err != nil {
-		return fmt.Errorf("failed to create DNS record: %w", err)
+		return "", fmt.Errorf("failed to create DNS record: %w", err)
 	}
 
-	return nil
+	return record.ID, nil
 }
 
-// RemoveDNSRoute removes CNAME DNS record for the tunnel
-func (c *Client) RemoveDNSRoute(tunnelID, hostname string) error {
-	ctx := context.Background()
-
-	records, _, err := c.api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(c.zoneID), cloudflare.ListDNSRecordsParams{
+// RemoveDNSRoute removes CNAME DNS record for the tunnel and returns the deleted record IDs
+func (c *Client) RemoveDNSRoute(ctx context.Context, tunnelID, hostname string) ([]string, error) {
+	records, err := c.listDNSRecords(ctx, cloudflare.ListDNSRecordsParams{
 		Name: hostname,
 		Type: "CNAME",
 	})
 	if err != nil {
-		return fmt.Errorf("failed to list DNS records: %w", err)
+		return nil, fmt.Errorf("failed to list DNS records: %w", err)
 	}
 
 	if len(records) == 0 {
-		return fmt.Errorf("no DNS record found for hostname: %s", hostname)
+		return nil, fmt.Errorf("no DNS record found for hostname: %s", hostname)
 	}
 
+	var ids []string
 	for _, record := range records {
 		err := c.api.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(c.zoneID), record.ID)
 		if err != nil {
-			return fmt.Errorf("failed to delete DNS record: %w", err)
+			return ids, fmt.Errorf("failed to delete DNS record: %w", err)
 		}
+		ids = append(ids, record.ID)
 	}
 
-	return nil
+	return ids, nil
 }
 
-// FlushLocalDNSCache flushes the local DNS cache to pick up new DNS records
-func (c *Client) FlushLocalDNSCache() error {
-	// Try systemd-resolved first (Ubuntu/Debian)
-	cmd := exec.Command("sudo", "systemd-resolve", "--flush-caches")
-	if err := cmd.Run(); err == nil {
-		return nil
+// CreateAccessPolicy creates a Cloudflare Access policy for a hostname
+// accessLevel can be "public", "private", or one or more comma-separated group
+// names; owners are let in by the precedence-1 owner policy (emails or an admin group);
+// rules add allow, require and exclude conditions for everyone but the owners
+// Returns the ID of the created application (empty for public)
+func (c *Client) CreateAccessPolicy(ctx context.Context, hostname, accessLevel string, owners []Rule, rules AccessRules, settings AppSettings) (string, error) {
+	// If access level is public and nothing else is allowed, don't create a policy
+	if accessLevel == "public" && len(rules.Allow) == 0 {
+		return "", nil
 	}
 
-	// Try resolvectl (newer systemd)
-	cmd = exec.Command("sudo", "resolvectl", "flush-caches")
-	if err := cmd.Run(); err == nil {
-		return nil
+	dir := &directory{c: c}
+
+	// Look up the groups before creating anything so a typo leaves no orphaned application
+	groups := GroupRules(accessLevel)
+	for _, group := range groups {
+		if _, err := dir.groupID(ctx, group.Value); err != nil {
+			return "", err
+		}
 	}
 
-	// If both fail, it's not critical - DNS will eventually refresh
-	return nil
-}
+	// Resolve the settings (identity provider names) before creating anything too
+	fields, err := dir.settingsToAPI(ctx, settings)
+	if err != nil {
+		return "", err
+	}
 
-// RestartCloudflaredService restarts the cloudflared service to apply DNS changes
-func (c *Client) RestartCloudflaredService(tunnelName, hostname string) error {
-	serviceName := fmt.Sprintf("cloudflared-%s", tunnelName)
-	cmd := exec.Command("sudo", "systemctl", "restart", serviceName)
-	output, err := cmd.CombinedOutput()
+	// Create the access application
+	createdApp, err := c.api.CreateAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.CreateAccessApplicationParams{
+		Name:                   fmt.Sprintf("orb-%s", hostname),
+		Domain:                 hostname,
+		Type:                   "self_hosted",
+		SessionDuration:        fields.SessionDuration,
+		AllowedIdps:            fields.AllowedIdps,
+		AutoRedirectToIdentity: fields.AutoRedirectToIdentity,
+		CorsHeaders:            fields.CorsHeaders,
+		AppLauncherVisible:     fields.AppLauncherVisible,
+		CustomDenyMessage:      fields.CustomDenyMessage,
+		CustomDenyURL:          fields.CustomDenyURL,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to restart %s service: %w\nOutput: %s", serviceName, err, string(output))
+		return "", fmt.Errorf("failed to create access application: %w", err)
 	}
-	return nil
+
+	// Always create owner policy first (precedence 1 - highest priority, cannot be altered)
+	if err := c.createPolicy(ctx, dir, createdApp.ID, policyName(hostname, policyOwner), "allow", 1, owners, nil, nil); err != nil {
+		return "", fmt.Errorf("failed to create owner access policy: %w", err)
+	}
+
+	// If groups were given, also add group access (precedence 2) - one policy includes them all
+	if len(groups) > 0 {
+		if err := c.createPolicy(ctx, dir, createdApp.ID, policyName(hostname, policyGroup), "allow", 2, groups, rules.Require, rules.Exclude); err != nil {
+			return "", fmt.Errorf("failed to create group access policy: %w", err)
+		}
+	}
+
+	// Allow rules get their own policies (precedence 3 and 4)
+	if err := c.writeRulePolicies(ctx, dir, createdApp.ID, hostname, rules, nil); err != nil {
+		return "", err
+	}
+
+	return createdApp.ID, nil
 }
 
-// GetServiceStatus returns the status of the cloudflared service
-func (c *Client) GetServiceStatus(tunnelName string) (string, error) {
-	serviceName := fmt.Sprintf("cloudflared-%s", tunnelName)
-	cmd := exec.Command("systemctl", "status", serviceName, "--no-pager")
-	output, err := cmd.CombinedOutput()
+// SetAccessRules replaces the allow, require and exclude rules of a hostname,
+// keeping its owner and group policies. A public hostname gets an application
+// with an owner policy for owners first. Returns the ID of the application.
+func (c *Client) SetAccessRules(ctx context.Context, hostname string, owners []Rule, rules AccessRules) (string, error) {
+	app, err := c.findAccessApplication(ctx, hostname)
 	if err != nil {
-		// systemctl status returns exit code 3 if service is not running, but still outputs status
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 3 {
-			return string(output), nil
+		return "", err
+	}
+	if app == nil {
+		if len(rules.Allow) == 0 {
+			return "", fmt.Errorf("%s is public - add --allow rules or expose it with --access first", hostname)
 		}
-		return "", fmt.Errorf("failed to get %s service status: %w\nOutput: %s", serviceName, err, string(output))
+		return c.CreateAccessPolicy(ctx, hostname, "private", owners, rules, AppSettings{})
+	}
+
+	policies, err := c.listAccessPolicies(ctx, app.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list access policies: %w", err)
 	}
-	return string(output), nil
-}
 
-// GetServiceLogs returns the logs of the cloudflared service, optionally filtered by hostname
-func (c *Client) GetServiceLogs(tunnelName string, lines int, hostname string) (string, error) {
-	serviceName := fmt.Sprintf("cloudflared-%s", tunnelName)
-	args := []string{"-u", serviceName, "--no-pager", "-n", fmt.Sprintf("%d", lines)}
+	dir := &directory{c: c}
 
-	if hostname != "" {
-		args = append(args, "--grep", hostname)
+	// require and exclude apply to the group policy as well
+	for _, policy := range policies {
+		if policy.Name != policyName(hostname, policyGroup) {
+			continue
+		}
+		require, err := dir.toAPI(ctx, rules.Require)
+		if err != nil {
+			return "", err
+		}
+		exclude, err := dir.toAPI(ctx, rules.Exclude)
+		if err != nil {
+			return "", err
+		}
+		_, err = c.api.UpdateAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.UpdateAccessPolicyParams{
+			ApplicationID: app.ID,
+			PolicyID:      policy.ID,
+			Name:          policy.Name,
+			Decision:      policy.Decision,
+			Precedence:    policy.Precedence,
+			Include:       policy.Include,
+			Require:       require,
+			Exclude:       exclude,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to update group access policy: %w", err)
+		}
 	}
 
-	cmd := exec.Command("journalctl", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("failed to get %s service logs: %w\nOutput: %s", serviceName, err, string(output))
+	if err := c.writeRulePolicies(ctx, dir, app.ID, hostname, rules, policies); err != nil {
+		return "", err
 	}
-	return string(output), nil
+	return app.ID, nil
 }
 
-// FollowServiceLogs follows the logs of the cloudflared service in real-time
-func (c *Client) FollowServiceLogs(tunnelName string, hostname string) error {
-	serviceName := fmt.Sprintf("cloudflared-%s", tunnelName)
-	args := []string{"-u", serviceName, "-f"}
+// ApplyAccess makes the Access application of a hostname match desired, creating,
+// updating or deleting only the policies that differ and creating missing public
+// paths; a nil desired deletes the application and any public paths. A missing
+// application is recreated with desired's settings, so restoring a snapshot from
+// GetAccess undoes a failed change, even one that deleted the application.
+// Returns the ID of the application (empty if it was deleted).
+func (c *Client) ApplyAccess(ctx context.Context, hostname string, desired *Access) (string, error) {
+	if desired == nil {
+		// public paths have nothing left to bypass once the application is gone
+		_, err := c.RemoveAccessPolicy(ctx, hostname)
+		return "", err
+	}
 
-	if hostname != "" {
-		args = append(args, "--grep", hostname)
+	app, paths, err := c.findAccessApplications(ctx, hostname)
+	if err != nil {
+		return "", err
 	}
 
-	cmd := exec.Command("journalctl", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	dir := &directory{c: c}
 
-	return cmd.Run()
-}
+	var existing []cloudflare.AccessPolicy
+	if app == nil {
+		params := cloudflare.CreateAccessApplicationParams{
+			Name:   fmt.Sprintf("orb-%s", hostname),
+			Domain: hostname,
+			Type:   "self_hosted",
+		}
+		if desired.Settings != nil {
+			fields, err := dir.settingsToAPI(ctx, *desired.Settings)
+			if err != nil {
+				return "", err
+			}
+			params.SessionDuration = fields.SessionDuration
+			params.AllowedIdps = fields.AllowedIdps
+			params.AutoRedirectToIdentity = fields.AutoRedirectToIdentity
+			params.CorsHeaders = fields.CorsHeaders
+			params.AppLauncherVisible = fields.AppLauncherVisible
+			params.CustomDenyMessage = fields.CustomDenyMessage
+			params.CustomDenyURL = fields.CustomDenyURL
+		}
+		created, err := c.api.CreateAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), params)
+		if err != nil {
+			return "", fmt.Errorf("failed to create access application: %w", err)
+		}
+		app = &created
+	} else {
+		existing, err = c.listAccessPolicies(ctx, app.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to list access policies: %w", err)
+		}
+	}
 
-// CreateAccessPolicy creates a Cloudflare Access policy for a hostname
-// accessLevel can be "public", "private", or a group name
-func (c *Client) CreateAccessPolicy(hostname, accessLevel, userEmail string) error {
-	ctx := context.Background()
+	wanted := make(map[string]Policy)
+	for _, policy := range desired.Policies {
+		wanted[policy.Name] = policy
+	}
 
-	// If access level is public, don't create a policy
-	if accessLevel == "public" {
-		return nil
+	// delete unwanted policies first so their precedence is free for new ones
+	current := make(map[string]Policy)
+	var names map[string]string
+	if len(existing) > 0 {
+		names = c.objectNames(ctx)
+	}
+	for _, policy := range existing {
+		if _, ok := wanted[policy.Name]; !ok {
+			err := c.api.DeleteAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.DeleteAccessPolicyParams{
+				ApplicationID: app.ID,
+				PolicyID:      policy.ID,
+			})
+			if err != nil {
+				return app.ID, fmt.Errorf("failed to delete policy %s: %w", policy.Name, err)
+			}
+			continue
+		}
+		current[policy.Name] = Policy{
+			ID:         policy.ID,
+			Name:       policy.Name,
+			Decision:   policy.Decision,
+			Precedence: policy.Precedence,
+			Include:    rulesFromAPI(policy.Include, names),
+			Require:    rulesFromAPI(policy.Require, names),
+			Exclude:    rulesFromAPI(policy.Exclude, names),
+		}
 	}
 
-	// Create the access application
+	for _, policy := range desired.Policies {
+		have, ok := current[policy.Name]
+		if !ok {
+			if err := c.createPolicy(ctx, dir, app.ID, policy.Name, policy.Decision, policy.Precedence, policy.Include, policy.Require, policy.Exclude); err != nil {
+				return app.ID, fmt.Errorf("failed to create policy %s: %w", policy.Name, err)
+			}
+			continue
+		}
+		if samePolicy(have, policy) {
+			continue
+		}
+
+		params := cloudflare.UpdateAccessPolicyParams{
+			ApplicationID: app.ID,
+			PolicyID:      have.ID,
+			Name:          policy.Name,
+			Decision:      policy.Decision,
+			Precedence:    policy.Precedence,
+		}
+		if params.Include, err = dir.toAPI(ctx, policy.Include); err != nil {
+			return app.ID, err
+		}
+		if params.Require, err = dir.toAPI(ctx, policy.Require); err != nil {
+			return app.ID, err
+		}
+		if params.Exclude, err = dir.toAPI(ctx, policy.Exclude); err != nil {
+			return app.ID, err
+		}
+		if _, err := c.api.UpdateAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), params); err != nil {
+			return app.ID, fmt.Errorf("failed to update policy %s: %w", policy.Name, err)
+		}
+	}
+
+	have := make(map[string]bool)
+	for _, path := range paths {
+		have[strings.TrimPrefix(path.Domain, hostname)] = true
+	}
+	for _, path := range desired.PublicPaths {
+		if have[path] {
+			continue
+		}
+		if _, err := c.CreatePublicPath(ctx, hostname, path); err != nil {
+			return app.ID, err
+		}
+	}
+
+	return app.ID, nil
+}
+
+// CreatePublicPath creates a separate Access application scoped to path of hostname
+// (e.g. /webhook/*) with a bypass policy, so the path is reachable without logging
+// in while the rest of the hostname stays protected
+// Returns the ID of the created application
+func (c *Client) CreatePublicPath(ctx context.Context, hostname, path string) (string, error) {
 	createdApp, err := c.api.CreateAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.CreateAccessApplicationParams{
-		Name:   fmt.Sprintf("orb-%s", hostname),
-		Domain: hostname,
+		Name:   pathAppName(hostname, path),
+		Domain: hostname + path,
 		Type:   "self_hosted",
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create access application: %w", err)
+		return "", fmt.Errorf("failed to create access application for %s: %w", path, err)
 	}
 
-	// Always create owner policy first (precedence 1 - highest priority, cannot be altered)
-	_, err = c.api.CreateAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.CreateAccessPolicyParams{
-		ApplicationID: createdApp.ID,
-		Name:          fmt.Sprintf("orb-%s-owner", hostname),
-		Decision:      "allow",
-		Include: []any{
-			cloudflare.AccessGroupEmail{Email: struct {
-				Email string `json:"email"`
-			}{Email: userEmail}},
-		},
-		Precedence: 1,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create owner access policy: %w", err)
+	everyone := []Rule{{Type: RuleEveryone}}
+	if err := c.createPolicy(ctx, &directory{c: c}, createdApp.ID, policyName(hostname, policyBypass), "bypass", 1, everyone, nil, nil); err != nil {
+		return createdApp.ID, fmt.Errorf("failed to create bypass policy for %s: %w", path, err)
 	}
+	return createdApp.ID, nil
+}
 
-	// If not private, also add group access (precedence 2)
-	if accessLevel != "private" {
-		// Look up the group by name
-		groups, _, err := c.api.ListAccessGroups(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessGroupsParams{})
-		if err != nil {
-			return fmt.Errorf("failed to list access groups: %w", err)
-		}
+// GetAccess returns the Access application protecting hostname with its policies in
+// precedence order, or nil if the hostname is public
+func (c *Client) GetAccess(ctx context.Context, hostname string) (*Access, error) {
+	app, paths, err := c.findAccessApplications(ctx, hostname)
+	if err != nil || app == nil {
+		return nil, err
+	}
+	return c.accessFromAPI(ctx, hostname, app, paths, func() map[string]string { return c.objectNames(ctx) })
+}
 
-		var groupID string
-		for _, group := range groups {
-			if group.Name == accessLevel {
-				groupID = group.ID
-				break
-			}
-		}
+// ListAccess is GetAccess for several hostnames at once: the applications, and the
+// names of groups and service tokens, are listed once rather than per hostname.
+// Public hostnames map to nil.
+func (c *Client) ListAccess(ctx context.Context, hostnames []string) (map[string]*Access, error) {
+	apps, err := c.listAccessApplications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access applications: %w", err)
+	}
 
-		if groupID == "" {
-			return fmt.Errorf("access group %q not found - create it with `orb access create %s <emails>` first", accessLevel, accessLevel)
+	var names map[string]string
+	lookupNames := func() map[string]string {
+		if names == nil {
+			names = c.objectNames(ctx)
 		}
+		return names
+	}
 
-		// Create group policy (precedence 2)
-		_, err = c.api.CreateAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.CreateAccessPolicyParams{
-			ApplicationID: createdApp.ID,
-			Name:          fmt.Sprintf("orb-%s-group", hostname),
-			Decision:      "allow",
-			Include: []any{
-				cloudflare.AccessGroupAccessGroup{Group: struct {
-					ID string `json:"id"`
-				}{ID: groupID}},
-			},
-			Precedence: 2,
-		})
+	accesses := make(map[string]*Access, len(hostnames))
+	for _, hostname := range hostnames {
+		app, paths := matchApplications(apps, hostname)
+		if app == nil {
+			accesses[hostname] = nil
+			continue
+		}
+		access, err := c.accessFromAPI(ctx, hostname, app, paths, lookupNames)
 		if err != nil {
-			return fmt.Errorf("failed to create group access policy: %w", err)
+			return nil, err
 		}
+		accesses[hostname] = access
 	}
-
-	return nil
+	return accesses, nil
 }
 
-// GetAccessInfo returns the access level for a hostname (e.g., "public", "private", or group name)
-func (c *Client) GetAccessInfo(hostname string) string {
-	ctx := context.Background()
-
-	// List all access applications
-	apps, _, err := c.api.ListAccessApplications(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessApplicationsParams{})
+// accessFromAPI reads the policies of a hostname's application into an Access.
+// lookupNames is only called when a policy refers to groups or service tokens,
+// which are stored by ID.
+func (c *Client) accessFromAPI(ctx context.Context, hostname string, app *cloudflare.AccessApplication, paths []cloudflare.AccessApplication, lookupNames func() map[string]string) (*Access, error) {
+	policies, err := c.listAccessPolicies(ctx, app.ID)
 	if err != nil {
-		return "public"
+		return nil, fmt.Errorf("failed to list access policies: %w", err)
 	}
 
-	// Find the application for this hostname
-	appName := fmt.Sprintf("orb-%s", hostname)
-	for _, app := range apps {
-		if app.Name == appName {
-			// Get the policies for this application
-			policies, _, err := c.api.ListAccessPolicies(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessPoliciesParams{
-				ApplicationID: app.ID,
+	convert := func(names map[string]string) *Access {
+		access := &Access{AppID: app.ID}
+		for _, path := range paths {
+			access.PublicPaths = append(access.PublicPaths, strings.TrimPrefix(path.Domain, hostname))
+		}
+		sort.Strings(access.PublicPaths)
+		for _, policy := range policies {
+			access.Policies = append(access.Policies, Policy{
+				ID:         policy.ID,
+				Name:       policy.Name,
+				Decision:   policy.Decision,
+				Precedence: policy.Precedence,
+				Include:    rulesFromAPI(policy.Include, names),
+				Require:    rulesFromAPI(policy.Require, names),
+				Exclude:    rulesFromAPI(policy.Exclude, names),
 			})
-			if err != nil || len(policies) == 0 {
-				return "protected"
-			}
+		}
+		sortPolicies(access.Policies)
+		access.Settings = c.settingsFromAPI(ctx, app)
+		return access
+	}
 
-			// Check the first policy's include rules to determine type
-			policy := policies[0]
-			if len(policy.Include) > 0 {
-				// Try to extract group information
-				for _, include := range policy.Include {
-					// Check if it's an email-based rule (private)
-					if emailRule, ok := include.(cloudflare.AccessGroupEmail); ok && emailRule.Email.Email != "" {
-						return "private"
-					}
-					// Check if it's a group-based rule
-					if groupRule, ok := include.(cloudflare.AccessGroupAccessGroup); ok && groupRule.Group.ID != "" {
-						// Look up the group name by ID
-						groups, _, err := c.api.ListAccessGroups(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessGroupsParams{})
-						if err == nil {
-							for _, group := range groups {
-								if group.ID == groupRule.Group.ID {
-									return group.Name
-								}
-							}
-						}
-						return "group"
-					}
-				}
+	// groups and service tokens are stored by ID; only look up names when needed
+	access := convert(nil)
+	for _, policy := range access.Policies {
+		for _, rule := range append(append(policy.Include, policy.Require...), policy.Exclude...) {
+			if rule.Type == RuleGroup || rule.Type == RuleServiceToken {
+				return convert(lookupNames()), nil
 			}
-			return "protected"
 		}
 	}
-
-	return "public"
+	return access, nil
 }
 
-// RemoveAccessPolicy removes the Cloudflare Access policy for a hostname
-func (c *Client) RemoveAccessPolicy(hostname string) error {
-	ctx := context.Background()
+// GetAccessInfo summarises who can reach a hostname (e.g., "public", "private",
+// a group name, or the full rule set such as "friends, country:CA")
+func (c *Client) GetAccessInfo(ctx context.Context, hostname string) string {
+	access, err := c.GetAccess(ctx, hostname)
+	if err != nil {
+		return "unknown"
+	}
+	return access.String()
+}
 
-	// List all access applications
-	apps, _, err := c.api.ListAccessApplications(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessApplicationsParams{})
+// RemoveAccessPolicy removes the Cloudflare Access policy for a hostname, along
+// with the applications of its public paths
+// Returns the ID of the deleted application (empty if there was none)
+func (c *Client) RemoveAccessPolicy(ctx context.Context, hostname string) (string, error) {
+	app, paths, err := c.findAccessApplications(ctx, hostname)
 	if err != nil {
-		return fmt.Errorf("failed to list access applications: %w", err)
+		return "", err
 	}
 
-	// Find the application for this hostname
-	appName := fmt.Sprintf("orb-%s", hostname)
-	for _, app := range apps {
-		if app.Name == appName {
-			// Delete the application (this also deletes associated policies)
-			err := c.api.DeleteAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), app.ID)
-			if err != nil {
-				return fmt.Errorf("failed to delete access application: %w", err)
-			}
-			return nil
+	var appID string
+	if app != nil {
+		// Delete the application (this also deletes associated policies)
+		if err := c.api.DeleteAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), app.ID); err != nil {
+			return "", fmt.Errorf("failed to delete access application: %w", err)
 		}
+		appID = app.ID
 	}
 
 	// Not found is not an error
-	return nil
+	_, err = c.deleteApplications(ctx, paths)
+	return appID, err
+}
+
+// deleteApplications deletes path applications, returning the IDs of those deleted
+func (c *Client) deleteApplications(ctx context.Context, apps []cloudflare.AccessApplication) ([]string, error) {
+	var ids []string
+	for _, app := range apps {
+		if err := c.api.DeleteAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), app.ID); err != nil {
+			return ids, fmt.Errorf("failed to delete access application %s: %w", app.Name, err)
+		}
+		ids = append(ids, app.ID)
+	}
+	return ids, nil
 }
 
-// RevokeGroupAccess removes only the group policy, keeping the owner policy intact
+// RevokeGroupAccess removes every policy but the owner's (group access, --allow rules
+// and shares) and the applications of public paths
 // This is used when temporary access expires - reverts to private (owner-only)
-func (c *Client) RevokeGroupAccess(hostname string) error {
-	ctx := context.Background()
+// Returns the IDs of the deleted policies and path applications (empty if there were none)
+func (c *Client) RevokeGroupAccess(ctx context.Context, hostname string) ([]string, error) {
+	app, paths, err := c.findAccessApplications(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := c.deleteApplications(ctx, paths)
+	if err != nil || app == nil {
+		// No application found
+		return ids, err
+	}
+
+	// List policies for this application
+	policies, err := c.listAccessPolicies(ctx, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access policies: %w", err)
+	}
+
+	// Delete all but the owner policy
+	for _, policy := range policies {
+		if isOwnerPolicy(policy.Name) {
+			continue
+		}
+		err := c.api.DeleteAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.DeleteAccessPolicyParams{
+			ApplicationID: app.ID,
+			PolicyID:      policy.ID,
+		})
+		if err != nil {
+			return ids, fmt.Errorf("failed to delete policy %s: %w", policy.Name, err)
+		}
+		ids = append(ids, policy.ID)
+	}
+
+	return ids, nil
+}
+
+// findAccessApplication returns orb's Access application for a hostname (nil if there is none)
+func (c *Client) findAccessApplication(ctx context.Context, hostname string) (*cloudflare.AccessApplication, error) {
+	app, _, err := c.findAccessApplications(ctx, hostname)
+	return app, err
+}
 
-	// List all access applications
-	apps, _, err := c.api.ListAccessApplications(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessApplicationsParams{})
+// findAccessApplications returns orb's Access application for a hostname (nil if there
+// is none) and the applications of its public paths
+func (c *Client) findAccessApplications(ctx context.Context, hostname string) (*cloudflare.AccessApplication, []cloudflare.AccessApplication, error) {
+	apps, err := c.listAccessApplications(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list access applications: %w", err)
+		return nil, nil, fmt.Errorf("failed to list access applications: %w", err)
 	}
+	main, paths := matchApplications(apps, hostname)
+	return main, paths, nil
+}
 
-	// Find the application for this hostname
+// matchApplications picks orb's application for a hostname (nil if there is none)
+// and the applications of its public paths out of apps
+func matchApplications(apps []cloudflare.AccessApplication, hostname string) (*cloudflare.AccessApplication, []cloudflare.AccessApplication) {
 	appName := fmt.Sprintf("orb-%s", hostname)
-	for _, app := range apps {
-		if app.Name == appName {
-			// List policies for this application
-			policies, _, err := c.api.ListAccessPolicies(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessPoliciesParams{
-				ApplicationID: app.ID,
+	var main *cloudflare.AccessApplication
+	var paths []cloudflare.AccessApplication
+	for i := range apps {
+		switch {
+		case apps[i].Name == appName:
+			main = &apps[i]
+		case strings.HasPrefix(apps[i].Name, appName+"/"):
+			paths = append(paths, apps[i])
+		}
+	}
+	return main, paths
+}
+
+// createPolicy attaches a policy to an application
+func (c *Client) createPolicy(ctx context.Context, dir *directory, appID, name, decision string, precedence int, include, require, exclude []Rule) error {
+	params := cloudflare.CreateAccessPolicyParams{
+		ApplicationID: appID,
+		Name:          name,
+		Decision:      decision,
+		Precedence:    precedence,
+	}
+
+	var err error
+	if params.Include, err = dir.toAPI(ctx, include); err != nil {
+		return err
+	}
+	if params.Require, err = dir.toAPI(ctx, require); err != nil {
+		return err
+	}
+	if params.Exclude, err = dir.toAPI(ctx, exclude); err != nil {
+		return err
+	}
+
+	_, err = c.api.CreateAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), params)
+	return err
+}
+
+// writeRulePolicies creates, updates or deletes the policies holding --allow rules:
+// one for rules that need a login and a non_identity one for service tokens
+func (c *Client) writeRulePolicies(ctx context.Context, dir *directory, appID, hostname string, rules AccessRules, existing []cloudflare.AccessPolicy) error {
+	specs := []struct {
+		suffix     string
+		decision   string
+		precedence int
+		include    []Rule
+	}{
+		{policyRules, "allow", 3, rules.identityRules()},
+		{policyTokens, "non_identity", 4, rules.tokenRules()},
+	}
+
+	for _, spec := range specs {
+		name := policyName(hostname, spec.suffix)
+
+		var current *cloudflare.AccessPolicy
+		for i := range existing {
+			if existing[i].Name == name {
+				current = &existing[i]
+				break
+			}
+		}
+
+		switch {
+		case len(spec.include) == 0 && current == nil:
+			continue
+		case len(spec.include) == 0:
+			err := c.api.DeleteAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.DeleteAccessPolicyParams{
+				ApplicationID: appID,
+				PolicyID:      current.ID,
 			})
 			if err != nil {
-				return fmt.Errorf("failed to list access policies: %w", err)
+				return fmt.Errorf("failed to delete policy %s: %w", name, err)
 			}
-
-			// Find and delete only the group policy (not the owner policy)
-			groupPolicyName := fmt.Sprintf("orb-%s-group", hostname)
-			for _, policy := range policies {
-				if policy.Name == groupPolicyName {
-					err := c.api.DeleteAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.DeleteAccessPolicyParams{
-						ApplicationID: app.ID,
-						PolicyID:      policy.ID,
-					})
-					if err != nil {
-						return fmt.Errorf("failed to delete group policy: %w", err)
-					}
-					return nil
-				}
+		case current == nil:
+			if err := c.createPolicy(ctx, dir, appID, name, spec.decision, spec.precedence, spec.include, rules.Require, rules.Exclude); err != nil {
+				return fmt.Errorf("failed to create policy %s: %w", name, err)
+			}
+		default:
+			params := cloudflare.UpdateAccessPolicyParams{
+				ApplicationID: appID,
+				PolicyID:      current.ID,
+				Name:          name,
+				Decision:      spec.decision,
+				Precedence:    current.Precedence,
+			}
+			var err error
+			if params.Include, err = dir.toAPI(ctx, spec.include); err != nil {
+				return err
+			}
+			if params.Require, err = dir.toAPI(ctx, rules.Require); err != nil {
+				return err
 			}
+			if params.Exclude, err = dir.toAPI(ctx, rules.Exclude); err != nil {
+				return err
+			}
+			if _, err := c.api.UpdateAccessPolicy(ctx, cloudflare.AccountIdentifier(c.accountID), params); err != nil {
+				return fmt.Errorf("failed to update policy %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
 
-			// No group policy found - already private
-			return nil
+// objectNames maps group and service token IDs to names, for rendering rules.
+// Lookups are best effort: an unresolved ID is shown as is.
+func (c *Client) objectNames(ctx context.Context) map[string]string {
+	names := make(map[string]string)
+	if groups, err := c.listAccessGroups(ctx); err == nil {
+		for _, group := range groups {
+			names[group.ID] = group.Name
+		}
+	}
+	if tokens, err := c.listAccessServiceTokens(ctx); err == nil {
+		for _, token := range tokens {
+			names[token.ID] = token.Name
 		}
 	}
+	return names
+}
 
-	// No application found
-	return nil
+// rulesFromAPI converts a policy's include, require or exclude list
+func rulesFromAPI(values []any, names map[string]string) []Rule {
+	var rules []Rule
+	for _, v := range values {
+		rules = append(rules, ruleFromAPI(v, names))
+	}
+	return rules
 }
 
-// CreateAccessGroup creates a new Access group with email addresses
-func (c *Client) CreateAccessGroup(groupName, emails string) error {
-	ctx := context.Background()
+// directory resolves group and service token names to IDs, listing each at most once
+type directory struct {
+	c      *Client
+	groups map[string]string // name -> ID
+	tokens map[string]string // name -> ID
+	idps   map[string]string // identity provider name -> ID
+}
 
-	// Parse comma-separated emails
-	emailList := []string{}
-	for _, email := range strings.Split(emails, ",") {
-		emailList = append(emailList, strings.TrimSpace(email))
+// groupID returns the ID of the named Access group
+func (d *directory) groupID(ctx context.Context, name string) (string, error) {
+	if d.groups == nil {
+		groups, err := d.c.listAccessGroups(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list access groups: %w", err)
+		}
+		d.groups = make(map[string]string)
+		for _, group := range groups {
+			d.groups[group.Name] = group.ID
+		}
 	}
 
-	// Build include rules with email addresses
-	var include []any
-	for _, email := range emailList {
-		include = append(include, cloudflare.AccessGroupEmail{Email: struct {
-			Email string `json:"email"`
-		}{Email: email}})
+	id, ok := d.groups[name]
+	if !ok {
+		return "", fmt.Errorf("access group %q not found - create it with `orb access create %s <emails>` first", name, name)
 	}
+	return id, nil
+}
 
-	// Create the access group
-	_, err := c.api.CreateAccessGroup(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.CreateAccessGroupParams{
-		Name:    groupName,
-		Include: include,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create access group: %w", err)
+// tokenID returns the ID of the named service token
+func (d *directory) tokenID(ctx context.Context, name string) (string, error) {
+	if d.tokens == nil {
+		tokens, err := d.c.listAccessServiceTokens(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list service tokens: %w", err)
+		}
+		d.tokens = make(map[string]string)
+		for _, token := range tokens {
+			d.tokens[token.Name] = token.ID
+		}
 	}
 
-	fmt.Printf("✔ Created Access group %q with %d email(s)\n", groupName, len(emailList))
-	return nil
+	id, ok := d.tokens[name]
+	if !ok {
+		return "", fmt.Errorf("service token %q %w", name, ErrServiceTokenNotFound)
+	}
+	return id, nil
 }
 
-// ListAccessGroupsFormatted lists all Access groups in a formatted table
-func (c *Client) ListAccessGroupsFormatted() error {
-	ctx := context.Background()
+// toAPI converts rules to the typed include/require/exclude entries the API expects
+func (d *directory) toAPI(ctx context.Context, rules []Rule) ([]any, error) {
+	var values []any
+	for _, r := range rules {
+		switch r.Type {
+		case RuleEmail:
+			var rule cloudflare.AccessGroupEmail
+			rule.Email.Email = r.Value
+			values = append(values, rule)
+		case RuleEmailDomain:
+			var rule cloudflare.AccessGroupEmailDomain
+			rule.EmailDomain.Domain = r.Value
+			values = append(values, rule)
+		case RuleIP:
+			var rule cloudflare.AccessGroupIP
+			rule.IP.IP = r.Value
+			values = append(values, rule)
+		case RuleCountry:
+			var rule cloudflare.AccessGroupGeo
+			rule.Geo.CountryCode = r.Value
+			values = append(values, rule)
+		case RuleEveryone:
+			values = append(values, cloudflare.AccessGroupEveryone{})
+		case RuleGroup:
+			id, err := d.groupID(ctx, r.Value)
+			if err != nil {
+				return nil, err
+			}
+			var rule cloudflare.AccessGroupAccessGroup
+			rule.Group.ID = id
+			values = append(values, rule)
+		case RuleServiceToken:
+			id, err := d.tokenID(ctx, r.Value)
+			if err != nil {
+				return nil, err
+			}
+			var rule cloudflare.AccessGroupServiceToken
+			rule.ServiceToken.ID = id
+			values = append(values, rule)
+		default:
+			if r.Raw == "" {
+				return nil, fmt.Errorf("unsupported rule type %q", r.Type)
+			}
+			// a rule read from the API that orb does not manage goes back as it came
+			values = append(values, json.RawMessage(r.Raw))
+		}
+	}
+	return values, nil
+}
 
-	groups, _, err := c.api.ListAccessGroups(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessGroupsParams{})
-	if err != nil {
-		return fmt.Errorf("failed to list access groups: %w", err)
+// CreateAccessGroup creates a new Access group from comma-separated members and
+// returns its ID. Members are email addresses or rules such as email-domain:example.com
+// and group:contractors.
+func (c *Client) CreateAccessGroup(ctx context.Context, groupName, emails string) (string, error) {
+	var members []Rule
+	for _, member := range strings.Split(emails, ",") {
+		if strings.TrimSpace(member) == "" {
+			continue
+		}
+		rule, err := ParseMember(member)
+		if err != nil {
+			return "", err
+		}
+		members = append(members, rule)
 	}
 
-	if len(groups) == 0 {
-		fmt.Println("No Access groups found")
-		return nil
+	// Build include rules (other groups are looked up by name)
+	include, err := (&directory{c: c}).toAPI(ctx, members)
+	if err != nil {
+		return "", err
 	}
 
-	fmt.Printf("\nAccess Groups (%d):\n", len(groups))
-	for _, group := range groups {
-		fmt.Printf("  • %s (ID: %s)\n", group.Name, group.ID)
+	// Create the access group
+	group, err := c.api.CreateAccessGroup(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.CreateAccessGroupParams{
+		Name:    groupName,
+		Include: include,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create access group: %w", err)
 	}
 
-	return nil
+	fmt.Printf("✔ Created Access group %q with %d member(s)\n", groupName, len(members))
+	return group.ID, nil
 }
 
-// UpdateAccessGroupMembers adds or removes members from an Access group
-func (c *Client) UpdateAccessGroupMembers(groupName string, addEmails, removeEmails []string) error {
-	ctx := context.Background()
-
-	// Find the group by name
-	groups, _, err := c.api.ListAccessGroups(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessGroupsParams{})
+// ListAccessGroups returns all Access groups in the account
+func (c *Client) ListAccessGroups(ctx context.Context) ([]Group, error) {
+	groups, err := c.listAccessGroups(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list access groups: %w", err)
+		return nil, fmt.Errorf("failed to list access groups: %w", err)
 	}
 
-	var group cloudflare.AccessGroup
-	var found bool
-	for _, g := range groups {
-		if g.Name == groupName {
-			group = g
-			found = true
-			break
-		}
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, Group{ID: group.ID, Name: group.Name})
 	}
+	return result, nil
+}
 
-	if !found {
-		return fmt.Errorf("access group %q not found", groupName)
+// UpdateAccessGroupMembers adds or removes email addresses from an Access group,
+// keeping its other members (email domains, groups...)
+func (c *Client) UpdateAccessGroupMembers(ctx context.Context, groupName string, addEmails, removeEmails []string) error {
+	group, err := c.findAccessGroup(ctx, groupName)
+	if err != nil {
+		return err
 	}
 
-	// Extract current emails from the group's include rules
-	currentEmails := make(map[string]bool)
-	for _, include := range group.Include {
-		if emailRule, ok := include.(map[string]interface{}); ok {
-			if emailObj, ok := emailRule["email"].(map[string]interface{}); ok {
-				if email, ok := emailObj["email"].(string); ok {
-					currentEmails[email] = true
-				}
-			}
-		}
-	}
+	// Current members, with other groups by name so they survive the update
+	members := rulesFromAPI(group.Include, c.objectNames(ctx))
 
 	// Add new emails
 	for _, email := range addEmails {
-		email = strings.TrimSpace(email)
+		email = strings.ToLower(strings.TrimSpace(email))
 		if email != "" {
-			currentEmails[email] = true
+			members = appendUnique(members, Rule{Type: RuleEmail, Value: email})
 		}
 	}
 
 	// Remove emails
+	removed := make(map[string]bool)
 	for _, email := range removeEmails {
-		email = strings.TrimSpace(email)
-		delete(currentEmails, email)
+		removed[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+	kept := members[:0]
+	for _, member := range members {
+		if member.Type == RuleEmail && removed[strings.ToLower(member.Value)] {
+			continue
+		}
+		kept = append(kept, member)
 	}
 
-	if len(currentEmails) == 0 {
+	if len(kept) == 0 {
 		return fmt.Errorf("cannot remove all members from group - delete the group instead")
 	}
 
-	// Build new include rules
-	var include []any
-	for email := range currentEmails {
-		include = append(include, cloudflare.AccessGroupEmail{Email: struct {
-			Email string `json:"email"`
-		}{Email: email}})
-	}
-
-	// Update the group
-	_, err = c.api.UpdateAccessGroup(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.UpdateAccessGroupParams{
-		ID:      group.ID,
-		Name:    groupName,
-		Include: include,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to update access group: %w", err)
+	if err := c.updateAccessGroup(ctx, group, kept); err != nil {
+		return err
 	}
 
 	if len(addEmails) > 0 {
@@ -502,47 +883,113 @@ func (c *Client) UpdateAccessGroupMembers(groupName string, addEmails, removeEma
 	if len(removeEmails) > 0 {
 		fmt.Printf("✔ Removed %d member(s) from %q\n", len(removeEmails), groupName)
 	}
-	fmt.Printf("  Group now has %d member(s)\n", len(currentEmails))
+	fmt.Printf("  Group now has %d member(s)\n", len(kept))
 
 	return nil
 }
 
 // GetAccessGroupMembers returns the list of email addresses in an Access group
-func (c *Client) GetAccessGroupMembers(groupName string) ([]string, error) {
-	ctx := context.Background()
+func (c *Client) GetAccessGroupMembers(ctx context.Context, groupName string) ([]string, error) {
+	group, err := c.findAccessGroup(ctx, groupName)
+	if err != nil {
+		return nil, err
+	}
 
-	groups, _, err := c.api.ListAccessGroups(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessGroupsParams{})
+	var emails []string
+	for _, include := range group.Include {
+		// typed values (cloudflare.AccessGroupEmail) and decoded JSON maps alike
+		if rule := ruleFromAPI(include, nil); rule.Type == RuleEmail {
+			emails = append(emails, rule.Value)
+		}
+	}
+	return emails, nil
+}
+
+// GetAccessGroupRules returns every member rule of an Access group: emails, email
+// domains, other groups (by name) and so on
+func (c *Client) GetAccessGroupRules(ctx context.Context, groupName string) ([]Rule, error) {
+	group, err := c.findAccessGroup(ctx, groupName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list access groups: %w", err)
+		return nil, err
 	}
+	return rulesFromAPI(group.Include, c.objectNames(ctx)), nil
+}
 
-	for _, group := range groups {
-		if group.Name == groupName {
-			var emails []string
-			for _, include := range group.Include {
-				if emailRule, ok := include.(map[string]interface{}); ok {
-					if emailObj, ok := emailRule["email"].(map[string]interface{}); ok {
-						if email, ok := emailObj["email"].(string); ok {
-							emails = append(emails, email)
-						}
-					}
-				}
+// SetAccessGroupRules replaces the member rules of an Access group, creating the
+// group if it does not exist. Returns the group's ID.
+func (c *Client) SetAccessGroupRules(ctx context.Context, groupName string, members []Rule) (string, error) {
+	if len(members) == 0 {
+		return "", fmt.Errorf("access group %q needs at least one member", groupName)
+	}
+
+	groups, err := c.listAccessGroups(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list access groups: %w", err)
+	}
+	for i := range groups {
+		if groups[i].Name == groupName {
+			if err := c.updateAccessGroup(ctx, &groups[i], members); err != nil {
+				return "", err
 			}
-			return emails, nil
+			return groups[i].ID, nil
 		}
 	}
 
-	return nil, fmt.Errorf("access group %q not found", groupName)
+	// not found: create it
+	include, err := (&directory{c: c}).toAPI(ctx, members)
+	if err != nil {
+		return "", err
+	}
+	created, err := c.api.CreateAccessGroup(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.CreateAccessGroupParams{
+		Name:    groupName,
+		Include: include,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create access group: %w", err)
+	}
+	return created.ID, nil
+}
+
+// updateAccessGroup replaces the include rules of an Access group
+func (c *Client) updateAccessGroup(ctx context.Context, group *cloudflare.AccessGroup, members []Rule) error {
+	include, err := (&directory{c: c}).toAPI(ctx, members)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.api.UpdateAccessGroup(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.UpdateAccessGroupParams{
+		ID:      group.ID,
+		Name:    group.Name,
+		Include: include,
+		Require: group.Require,
+		Exclude: group.Exclude,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update access group: %w", err)
+	}
+	return nil
 }
 
-// DeleteAccessGroup deletes an Access group by name
-func (c *Client) DeleteAccessGroup(groupName string) error {
-	ctx := context.Background()
+// findAccessGroup returns the Access group with the given name
+func (c *Client) findAccessGroup(ctx context.Context, groupName string) (*cloudflare.AccessGroup, error) {
+	groups, err := c.listAccessGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access groups: %w", err)
+	}
+	for i := range groups {
+		if groups[i].Name == groupName {
+			return &groups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("access group %q not found", groupName)
+}
 
+// DeleteAccessGroup deletes an Access group by name and returns its ID
+func (c *Client) DeleteAccessGroup(ctx context.Context, groupName string) (string, error) {
 	// Find the group by name
-	groups, _, err := c.api.ListAccessGroups(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessGroupsParams{})
+	groups, err := c.listAccessGroups(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list access groups: %w", err)
+		return "", fmt.Errorf("failed to list access groups: %w", err)
 	}
 
 	var groupID string
@@ -554,15 +1001,15 @@ func (c *Client) DeleteAccessGroup(groupName string) error {
 	}
 
 	if groupID == "" {
-		return fmt.Errorf("access group %q not found", groupName)
+		return "", fmt.Errorf("access group %q not found", groupName)
 	}
 
 	// Delete the group
 	err = c.api.DeleteAccessGroup(ctx, cloudflare.AccountIdentifier(c.accountID), groupID)
 	if err != nil {
-		return fmt.Errorf("failed to delete access group: %w", err)
+		return "", fmt.Errorf("failed to delete access group: %w", err)
 	}
 
 	fmt.Printf("✔ Deleted Access group %q\n", groupName)
-	return nil
+	return groupID, nil
 }
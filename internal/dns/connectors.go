@@ -0,0 +1,71 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// Connector is a cloudflared process (replica) running a tunnel
+type Connector struct {
+	ID          string
+	Version     string // cloudflared version, e.g. 2024.8.2
+	Arch        string // e.g. linux_amd64
+	RunAt       time.Time
+	Connections []EdgeConnection
+}
+
+// EdgeConnection is one connection from a connector to a Cloudflare data center
+type EdgeConnection struct {
+	ID               string
+	Colo             string // data center, e.g. yyz01
+	OriginIP         string // public IP the connector connects from
+	OpenedAt         time.Time
+	PendingReconnect bool
+}
+
+// Active returns the connections of a connector that are up
+func (c Connector) Active() []EdgeConnection {
+	var active []EdgeConnection
+	for _, conn := range c.Connections {
+		if !conn.PendingReconnect {
+			active = append(active, conn)
+		}
+	}
+	return active
+}
+
+// ListTunnelConnectors returns the connectors of a tunnel and their edge
+// connections, oldest connector first
+func (c *Client) ListTunnelConnectors(ctx context.Context, tunnelID string) ([]Connector, error) {
+	clients, err := c.api.ListTunnelConnections(ctx, cloudflare.AccountIdentifier(c.accountID), tunnelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tunnel connections: %w", err)
+	}
+
+	connectors := make([]Connector, 0, len(clients))
+	for _, client := range clients {
+		connector := Connector{
+			ID:      client.ID,
+			Version: client.Version,
+			Arch:    client.Arch,
+			RunAt:   timeOrZero(client.RunAt),
+		}
+		for _, conn := range client.Connections {
+			opened, _ := time.Parse(time.RFC3339, conn.OpenedAt) // zero if missing
+			connector.Connections = append(connector.Connections, EdgeConnection{
+				ID:               conn.ID,
+				Colo:             conn.ColoName,
+				OriginIP:         conn.OriginIP,
+				OpenedAt:         opened,
+				PendingReconnect: conn.IsPendingReconnect,
+			})
+		}
+		connectors = append(connectors, connector)
+	}
+	sort.SliceStable(connectors, func(i, j int) bool { return connectors[i].RunAt.Before(connectors[j].RunAt) })
+	return connectors, nil
+}
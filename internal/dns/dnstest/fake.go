@@ -0,0 +1,944 @@
+// Package dnstest provides an in-memory implementation of dns.API for hermetic tests
+package dnstest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"orb/internal/dns"
+)
+
+// App is a fake Cloudflare Access application
+type App struct {
+	ID       string
+	Name     string
+	Domain   string
+	Policies []Policy
+	Settings dns.AppSettings
+}
+
+// Policy is a fake Access policy; groups and service tokens are referred to by name
+type Policy struct {
+	ID         string
+	Name       string
+	Decision   string
+	Precedence int
+	Include    []dns.Rule
+	Require    []dns.Rule
+	Exclude    []dns.Rule
+}
+
+// Group is a fake Access group
+type Group struct {
+	ID     string
+	Name   string
+	Emails []string
+	Rules  []dns.Rule // members other than email addresses (email domains, groups...)
+}
+
+// Fake is an in-memory stand-in for the Cloudflare API. The exported maps hold
+// its state and may be seeded or inspected directly; FailOn injects errors.
+type Fake struct {
+	mu sync.Mutex
+
+	Tunnels       map[string]string            // tunnel ID -> tunnel name
+	Records       map[string]string            // hostname -> CNAME target
+	Routes        map[string]*dns.Route        // network -> private network route
+	Connectors    map[string][]dns.Connector   // tunnel ID -> connectors running it
+	Apps          map[string]*App              // hostname (or hostname+path) -> Access application
+	Groups        map[string]*Group            // group name -> Access group
+	ServiceTokens map[string]*dns.ServiceToken // service token name -> token
+	// IdentityProviders are the identity provider names settings may refer to
+	IdentityProviders map[string]bool
+	// AccessLogs are the authentication decisions GetAccessLogs reads from
+	AccessLogs []dns.AccessLogEntry
+
+	recordIDs map[string]string // hostname -> DNS record ID
+	failures  map[string]error
+	nextID    int
+}
+
+var _ dns.API = (*Fake)(nil)
+
+// New creates an empty fake with no tunnels, records, apps or groups
+func New() *Fake {
+	return &Fake{
+		Tunnels:           make(map[string]string),
+		Records:           make(map[string]string),
+		Routes:            make(map[string]*dns.Route),
+		Connectors:        make(map[string][]dns.Connector),
+		Apps:              make(map[string]*App),
+		Groups:            make(map[string]*Group),
+		ServiceTokens:     make(map[string]*dns.ServiceToken),
+		IdentityProviders: make(map[string]bool),
+		recordIDs:         make(map[string]string),
+		failures:          make(map[string]error),
+	}
+}
+
+// FailOn makes every call to the named method (e.g. "CreateDNSRoute") return err.
+// Passing a nil err clears the failure.
+func (f *Fake) FailOn(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// fail returns the injected error for method, if any. Callers must hold f.mu.
+func (f *Fake) fail(method string) error {
+	return f.failures[method]
+}
+
+// id returns a fresh object ID. Callers must hold f.mu.
+func (f *Fake) id(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%d", prefix, f.nextID)
+}
+
+// GetTunnelName returns the name of a seeded tunnel
+func (f *Fake) GetTunnelName(ctx context.Context, tunnelID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetTunnelName"); err != nil {
+		return "", err
+	}
+
+	name, ok := f.Tunnels[tunnelID]
+	if !ok {
+		return "", fmt.Errorf("failed to get tunnel: tunnel %s not found", tunnelID)
+	}
+	return name, nil
+}
+
+// ListTunnelConnectors returns the seeded connectors of a tunnel
+func (f *Fake) ListTunnelConnectors(ctx context.Context, tunnelID string) ([]dns.Connector, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ListTunnelConnectors"); err != nil {
+		return nil, err
+	}
+
+	if _, ok := f.Tunnels[tunnelID]; !ok {
+		return nil, fmt.Errorf("failed to list tunnel connections: tunnel %s not found", tunnelID)
+	}
+	return append([]dns.Connector(nil), f.Connectors[tunnelID]...), nil
+}
+
+// ListTunnelRoutes returns the routes of a tunnel (or of all tunnels), ordered by network
+func (f *Fake) ListTunnelRoutes(ctx context.Context, tunnelID string) ([]dns.Route, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ListTunnelRoutes"); err != nil {
+		return nil, err
+	}
+
+	var routes []dns.Route
+	for _, route := range f.Routes {
+		if tunnelID == "" || route.TunnelID == tunnelID {
+			routes = append(routes, *route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Network < routes[j].Network })
+	return routes, nil
+}
+
+// CreateTunnelRoute routes a network to a seeded tunnel
+func (f *Fake) CreateTunnelRoute(ctx context.Context, tunnelID, network, comment string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreateTunnelRoute"); err != nil {
+		return err
+	}
+
+	name, ok := f.Tunnels[tunnelID]
+	if !ok {
+		return fmt.Errorf("failed to create route for %s: tunnel %s not found", network, tunnelID)
+	}
+	if _, exists := f.Routes[network]; exists {
+		return fmt.Errorf("failed to create route for %s: route already exists", network)
+	}
+	f.Routes[network] = &dns.Route{Network: network, TunnelID: tunnelID, TunnelName: name, Comment: comment, CreatedAt: time.Now()}
+	return nil
+}
+
+// DeleteTunnelRoute removes the route of a network
+func (f *Fake) DeleteTunnelRoute(ctx context.Context, network string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("DeleteTunnelRoute"); err != nil {
+		return err
+	}
+
+	if _, ok := f.Routes[network]; !ok {
+		return fmt.Errorf("failed to delete route for %s: route not found", network)
+	}
+	delete(f.Routes, network)
+	return nil
+}
+
+// CreateDNSRoute records a CNAME pointing hostname at the tunnel
+func (f *Fake) CreateDNSRoute(ctx context.Context, tunnelID, hostname string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreateDNSRoute"); err != nil {
+		return "", err
+	}
+
+	if _, exists := f.Records[hostname]; exists {
+		return "", fmt.Errorf("failed to create DNS record: record for %s already exists", hostname)
+	}
+	f.Records[hostname] = fmt.Sprintf("%s.cfargotunnel.com", tunnelID)
+	id := f.id("record")
+	f.recordIDs[hostname] = id
+	return id, nil
+}
+
+// RemoveDNSRoute deletes the CNAME for hostname
+func (f *Fake) RemoveDNSRoute(ctx context.Context, tunnelID, hostname string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("RemoveDNSRoute"); err != nil {
+		return nil, err
+	}
+
+	if _, exists := f.Records[hostname]; !exists {
+		return nil, fmt.Errorf("no DNS record found for hostname: %s", hostname)
+	}
+	id := f.recordIDs[hostname]
+	delete(f.Records, hostname)
+	delete(f.recordIDs, hostname)
+	return []string{id}, nil
+}
+
+// CreateAccessPolicy creates an application with an owner policy, a group policy
+// for group access levels and policies for allow rules - mirroring dns.Client
+func (f *Fake) CreateAccessPolicy(ctx context.Context, hostname, accessLevel string, owners []dns.Rule, rules dns.AccessRules, settings dns.AppSettings) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreateAccessPolicy"); err != nil {
+		return "", err
+	}
+	if err := f.checkSettings(settings); err != nil {
+		return "", err
+	}
+	id, err := f.createAccessPolicy(hostname, accessLevel, owners, rules)
+	if app, ok := f.Apps[hostname]; ok && err == nil && id != "" {
+		app.Settings = settings
+	}
+	return id, err
+}
+
+// createAccessPolicy implements CreateAccessPolicy. Callers must hold f.mu.
+func (f *Fake) createAccessPolicy(hostname, accessLevel string, owners []dns.Rule, rules dns.AccessRules) (string, error) {
+	if accessLevel == "public" && len(rules.Allow) == 0 {
+		return "", nil
+	}
+
+	groups := dns.GroupRules(accessLevel)
+	for _, group := range groups {
+		if _, ok := f.Groups[group.Value]; !ok {
+			return "", fmt.Errorf("access group %q not found - create it with `orb access create %s <emails>` first", group.Value, group.Value)
+		}
+	}
+	if err := f.checkRules(rules); err != nil {
+		return "", err
+	}
+	if len(owners) == 0 {
+		return "", fmt.Errorf("failed to create owner access policy: no owners")
+	}
+	if err := f.checkRules(dns.AccessRules{Allow: owners}); err != nil {
+		return "", err
+	}
+
+	app := &App{ID: f.id("app"), Name: "orb-" + hostname, Domain: hostname}
+	f.Apps[hostname] = app
+	app.Policies = append(app.Policies, Policy{
+		ID:         f.id("policy"),
+		Name:       fmt.Sprintf("orb-%s-owner", hostname),
+		Decision:   "allow",
+		Precedence: 1,
+		Include:    owners,
+	})
+
+	if len(groups) > 0 {
+		app.Policies = append(app.Policies, Policy{
+			ID:         f.id("policy"),
+			Name:       fmt.Sprintf("orb-%s-group", hostname),
+			Decision:   "allow",
+			Precedence: 2,
+			Include:    groups,
+			Require:    rules.Require,
+			Exclude:    rules.Exclude,
+		})
+	}
+	f.writeRulePolicies(app, hostname, rules)
+	return app.ID, nil
+}
+
+// SetAccessRules replaces the allow, require and exclude rules of hostname, keeping
+// the owner and group policies - mirroring dns.Client
+func (f *Fake) SetAccessRules(ctx context.Context, hostname string, owners []dns.Rule, rules dns.AccessRules) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("SetAccessRules"); err != nil {
+		return "", err
+	}
+
+	app, ok := f.Apps[hostname]
+	if !ok {
+		if len(rules.Allow) == 0 {
+			return "", fmt.Errorf("%s is public - add --allow rules or expose it with --access first", hostname)
+		}
+		return f.createAccessPolicy(hostname, "private", owners, rules)
+	}
+	if err := f.checkRules(dns.AccessRules{Require: rules.Require, Exclude: rules.Exclude}); err != nil {
+		return "", err
+	}
+
+	// like dns.Client, the group policy is updated before the allow rules are
+	// resolved, so a bad allow rule leaves the change half applied
+	for i := range app.Policies {
+		if app.Policies[i].Name == fmt.Sprintf("orb-%s-group", hostname) {
+			app.Policies[i].Require = rules.Require
+			app.Policies[i].Exclude = rules.Exclude
+		}
+	}
+	if err := f.checkRules(dns.AccessRules{Allow: rules.Allow}); err != nil {
+		return "", err
+	}
+	f.writeRulePolicies(app, hostname, rules)
+	return app.ID, nil
+}
+
+// writeRulePolicies replaces the policies holding allow rules. Callers must hold f.mu.
+func (f *Fake) writeRulePolicies(app *App, hostname string, rules dns.AccessRules) {
+	var identity, tokens []dns.Rule
+	for _, rule := range rules.Allow {
+		if rule.Type == dns.RuleServiceToken {
+			tokens = append(tokens, rule)
+		} else {
+			identity = append(identity, rule)
+		}
+	}
+
+	specs := []struct {
+		name       string
+		decision   string
+		precedence int
+		include    []dns.Rule
+	}{
+		{fmt.Sprintf("orb-%s-rules", hostname), "allow", 3, identity},
+		{fmt.Sprintf("orb-%s-tokens", hostname), "non_identity", 4, tokens},
+	}
+
+	for _, spec := range specs {
+		idx := -1
+		for i, policy := range app.Policies {
+			if policy.Name == spec.name {
+				idx = i
+				break
+			}
+		}
+
+		switch {
+		case len(spec.include) == 0 && idx != -1:
+			app.Policies = append(app.Policies[:idx], app.Policies[idx+1:]...)
+		case len(spec.include) == 0:
+		case idx == -1:
+			app.Policies = append(app.Policies, Policy{
+				ID:         f.id("policy"),
+				Name:       spec.name,
+				Decision:   spec.decision,
+				Precedence: spec.precedence,
+				Include:    spec.include,
+				Require:    rules.Require,
+				Exclude:    rules.Exclude,
+			})
+		default:
+			app.Policies[idx].Include = spec.include
+			app.Policies[idx].Require = rules.Require
+			app.Policies[idx].Exclude = rules.Exclude
+		}
+	}
+}
+
+// checkRules fails on groups or service tokens that do not exist. Callers must hold f.mu.
+func (f *Fake) checkRules(rules dns.AccessRules) error {
+	for _, list := range [][]dns.Rule{rules.Allow, rules.Require, rules.Exclude} {
+		for _, rule := range list {
+			switch rule.Type {
+			case dns.RuleGroup:
+				if _, ok := f.Groups[rule.Value]; !ok {
+					return fmt.Errorf("access group %q not found - create it with `orb access create %s <emails>` first", rule.Value, rule.Value)
+				}
+			case dns.RuleServiceToken:
+				if _, ok := f.ServiceTokens[rule.Value]; !ok {
+					return fmt.Errorf("service token %q %w", rule.Value, dns.ErrServiceTokenNotFound)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// GetAccess returns a copy of the application protecting hostname (nil if public)
+func (f *Fake) GetAccess(ctx context.Context, hostname string) (*dns.Access, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetAccess"); err != nil {
+		return nil, err
+	}
+	return f.access(hostname), nil
+}
+
+// ListAccess returns GetAccess for each hostname (nil for public ones)
+func (f *Fake) ListAccess(ctx context.Context, hostnames []string) (map[string]*dns.Access, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ListAccess"); err != nil {
+		return nil, err
+	}
+
+	accesses := make(map[string]*dns.Access, len(hostnames))
+	for _, hostname := range hostnames {
+		accesses[hostname] = f.access(hostname)
+	}
+	return accesses, nil
+}
+
+// access returns a copy of the application protecting hostname (nil if public).
+// Callers must hold f.mu.
+func (f *Fake) access(hostname string) *dns.Access {
+	app, ok := f.Apps[hostname]
+	if !ok {
+		return nil
+	}
+
+	access := &dns.Access{AppID: app.ID, PublicPaths: f.publicPaths(hostname)}
+	for _, policy := range app.Policies {
+		access.Policies = append(access.Policies, dns.Policy{
+			ID:         policy.ID,
+			Name:       policy.Name,
+			Decision:   policy.Decision,
+			Precedence: policy.Precedence,
+			Include:    append([]dns.Rule(nil), policy.Include...),
+			Require:    append([]dns.Rule(nil), policy.Require...),
+			Exclude:    append([]dns.Rule(nil), policy.Exclude...),
+		})
+	}
+	sort.SliceStable(access.Policies, func(i, j int) bool {
+		return access.Policies[i].Precedence < access.Policies[j].Precedence
+	})
+	settings := app.Settings
+	access.Settings = &settings
+	return access
+}
+
+// ApplyAccess replaces the policies of hostname with desired (nil deletes the
+// application), keeping the IDs of policies that already exist, recreating a
+// missing application with desired's settings and creating missing public paths -
+// mirroring dns.Client
+func (f *Fake) ApplyAccess(ctx context.Context, hostname string, desired *dns.Access) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ApplyAccess"); err != nil {
+		return "", err
+	}
+
+	if desired == nil {
+		delete(f.Apps, hostname)
+		f.removePublicPaths(hostname)
+		return "", nil
+	}
+
+	for _, policy := range desired.Policies {
+		if err := f.checkRules(dns.AccessRules{Allow: policy.Include, Require: policy.Require, Exclude: policy.Exclude}); err != nil {
+			return "", err
+		}
+	}
+
+	app, ok := f.Apps[hostname]
+	if !ok {
+		app = &App{ID: f.id("app"), Name: "orb-" + hostname, Domain: hostname}
+		if desired.Settings != nil {
+			if err := f.checkSettings(*desired.Settings); err != nil {
+				return "", err
+			}
+			app.Settings = *desired.Settings
+		}
+		f.Apps[hostname] = app
+	}
+
+	ids := make(map[string]string)
+	for _, policy := range app.Policies {
+		ids[policy.Name] = policy.ID
+	}
+
+	app.Policies = nil
+	for _, policy := range desired.Policies {
+		id, ok := ids[policy.Name]
+		if !ok {
+			id = f.id("policy")
+		}
+		app.Policies = append(app.Policies, Policy{
+			ID:         id,
+			Name:       policy.Name,
+			Decision:   policy.Decision,
+			Precedence: policy.Precedence,
+			Include:    append([]dns.Rule(nil), policy.Include...),
+			Require:    append([]dns.Rule(nil), policy.Require...),
+			Exclude:    append([]dns.Rule(nil), policy.Exclude...),
+		})
+	}
+
+	for _, path := range desired.PublicPaths {
+		if _, ok := f.Apps[hostname+path]; !ok {
+			f.createPublicPath(hostname, path)
+		}
+	}
+	return app.ID, nil
+}
+
+// GetAccessInfo summarises the access of hostname the same way dns.Client does
+func (f *Fake) GetAccessInfo(ctx context.Context, hostname string) string {
+	f.mu.Lock()
+	failed := f.fail("GetAccessInfo") != nil
+	f.mu.Unlock()
+	if failed {
+		return "unknown"
+	}
+
+	access, err := f.GetAccess(ctx, hostname)
+	if err != nil {
+		return "unknown"
+	}
+	return access.String()
+}
+
+// RemoveAccessPolicy deletes the application for hostname, if any, and its public paths
+func (f *Fake) RemoveAccessPolicy(ctx context.Context, hostname string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("RemoveAccessPolicy"); err != nil {
+		return "", err
+	}
+
+	f.removePublicPaths(hostname)
+	app, ok := f.Apps[hostname]
+	if !ok {
+		return "", nil
+	}
+	delete(f.Apps, hostname)
+	return app.ID, nil
+}
+
+// CreatePublicPath creates a bypass application for path of hostname - mirroring dns.Client
+func (f *Fake) CreatePublicPath(ctx context.Context, hostname, path string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreatePublicPath"); err != nil {
+		return "", err
+	}
+	return f.createPublicPath(hostname, path), nil
+}
+
+// createPublicPath implements CreatePublicPath. Callers must hold f.mu.
+func (f *Fake) createPublicPath(hostname, path string) string {
+	app := &App{ID: f.id("app"), Name: "orb-" + hostname + path, Domain: hostname + path}
+	app.Policies = append(app.Policies, Policy{
+		ID:         f.id("policy"),
+		Name:       fmt.Sprintf("orb-%s-bypass", hostname),
+		Decision:   "bypass",
+		Precedence: 1,
+		Include:    []dns.Rule{{Type: dns.RuleEveryone}},
+	})
+	f.Apps[hostname+path] = app
+	return app.ID
+}
+
+// GetAppSettings returns the settings of the application for hostname (nil if public)
+func (f *Fake) GetAppSettings(ctx context.Context, hostname string) (*dns.AppSettings, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetAppSettings"); err != nil {
+		return nil, err
+	}
+	app, ok := f.Apps[hostname]
+	if !ok {
+		return nil, nil
+	}
+	settings := app.Settings
+	return &settings, nil
+}
+
+// SetAppSettings replaces the settings of the application for hostname
+func (f *Fake) SetAppSettings(ctx context.Context, hostname string, settings dns.AppSettings) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("SetAppSettings"); err != nil {
+		return err
+	}
+	app, ok := f.Apps[hostname]
+	if !ok {
+		return fmt.Errorf("no Access application for %s", hostname)
+	}
+	if err := f.checkSettings(settings); err != nil {
+		return err
+	}
+	app.Settings = settings
+	return nil
+}
+
+// checkSettings fails like dns.Client does for unknown identity providers. Callers must hold f.mu.
+func (f *Fake) checkSettings(settings dns.AppSettings) error {
+	for _, name := range settings.IdentityProviders {
+		if !f.IdentityProviders[name] {
+			return fmt.Errorf("identity provider %q not found - check Zero Trust → Settings → Authentication", name)
+		}
+	}
+	return nil
+}
+
+// publicPaths returns the paths of hostname with bypass applications. Callers must hold f.mu.
+func (f *Fake) publicPaths(hostname string) []string {
+	var paths []string
+	for domain := range f.Apps {
+		if strings.HasPrefix(domain, hostname+"/") {
+			paths = append(paths, strings.TrimPrefix(domain, hostname))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// removePublicPaths deletes the bypass applications of hostname, returning their IDs.
+// Callers must hold f.mu.
+func (f *Fake) removePublicPaths(hostname string) []string {
+	var ids []string
+	for _, path := range f.publicPaths(hostname) {
+		ids = append(ids, f.Apps[hostname+path].ID)
+		delete(f.Apps, hostname+path)
+	}
+	return ids
+}
+
+// RevokeGroupAccess deletes every policy but the owner's for hostname, and its public paths
+func (f *Fake) RevokeGroupAccess(ctx context.Context, hostname string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("RevokeGroupAccess"); err != nil {
+		return nil, err
+	}
+
+	ids := f.removePublicPaths(hostname)
+	app, ok := f.Apps[hostname]
+	if !ok {
+		return ids, nil
+	}
+
+	kept := app.Policies[:0]
+	for _, policy := range app.Policies {
+		if strings.HasSuffix(policy.Name, "-owner") {
+			kept = append(kept, policy)
+			continue
+		}
+		ids = append(ids, policy.ID)
+	}
+	app.Policies = kept
+	return ids, nil
+}
+
+// CreateAccessGroup creates a group from comma-separated members
+func (f *Fake) CreateAccessGroup(ctx context.Context, groupName, emails string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreateAccessGroup"); err != nil {
+		return "", err
+	}
+
+	if _, exists := f.Groups[groupName]; exists {
+		return "", fmt.Errorf("failed to create access group: %q already exists", groupName)
+	}
+
+	var members []dns.Rule
+	for _, member := range strings.Split(emails, ",") {
+		if strings.TrimSpace(member) == "" {
+			continue
+		}
+		rule, err := dns.ParseMember(member)
+		if err != nil {
+			return "", err
+		}
+		members = append(members, rule)
+	}
+
+	group := &Group{ID: f.id("group"), Name: groupName}
+	if err := f.setMembers(group, members); err != nil {
+		return "", err
+	}
+	f.Groups[groupName] = group
+	return group.ID, nil
+}
+
+// ListAccessGroups returns all groups sorted by name
+func (f *Fake) ListAccessGroups(ctx context.Context) ([]dns.Group, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ListAccessGroups"); err != nil {
+		return nil, err
+	}
+
+	var groups []dns.Group
+	for _, group := range f.Groups {
+		groups = append(groups, dns.Group{ID: group.ID, Name: group.Name})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+// UpdateAccessGroupMembers adds and removes group members
+func (f *Fake) UpdateAccessGroupMembers(ctx context.Context, groupName string, addEmails, removeEmails []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("UpdateAccessGroupMembers"); err != nil {
+		return err
+	}
+
+	group, ok := f.Groups[groupName]
+	if !ok {
+		return fmt.Errorf("access group %q not found", groupName)
+	}
+
+	members := make(map[string]bool)
+	for _, email := range group.Emails {
+		members[email] = true
+	}
+	for _, email := range addEmails {
+		if email = strings.TrimSpace(email); email != "" {
+			members[email] = true
+		}
+	}
+	for _, email := range removeEmails {
+		delete(members, strings.TrimSpace(email))
+	}
+	if len(members) == 0 && len(group.Rules) == 0 {
+		return fmt.Errorf("cannot remove all members from group - delete the group instead")
+	}
+
+	group.Emails = group.Emails[:0]
+	for email := range members {
+		group.Emails = append(group.Emails, email)
+	}
+	sort.Strings(group.Emails)
+	return nil
+}
+
+// GetAccessGroupMembers returns the emails in a group
+func (f *Fake) GetAccessGroupMembers(ctx context.Context, groupName string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetAccessGroupMembers"); err != nil {
+		return nil, err
+	}
+
+	group, ok := f.Groups[groupName]
+	if !ok {
+		return nil, fmt.Errorf("access group %q not found", groupName)
+	}
+	return append([]string(nil), group.Emails...), nil
+}
+
+// GetAccessGroupRules returns every member of a group as rules
+func (f *Fake) GetAccessGroupRules(ctx context.Context, groupName string) ([]dns.Rule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetAccessGroupRules"); err != nil {
+		return nil, err
+	}
+
+	group, ok := f.Groups[groupName]
+	if !ok {
+		return nil, fmt.Errorf("access group %q not found", groupName)
+	}
+	var rules []dns.Rule
+	for _, email := range group.Emails {
+		rules = append(rules, dns.Rule{Type: dns.RuleEmail, Value: email})
+	}
+	return append(rules, group.Rules...), nil
+}
+
+// SetAccessGroupRules replaces the members of a group, creating it if needed
+func (f *Fake) SetAccessGroupRules(ctx context.Context, groupName string, members []dns.Rule) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("SetAccessGroupRules"); err != nil {
+		return "", err
+	}
+
+	if len(members) == 0 {
+		return "", fmt.Errorf("access group %q needs at least one member", groupName)
+	}
+	group, ok := f.Groups[groupName]
+	if !ok {
+		group = &Group{ID: f.id("group"), Name: groupName}
+	}
+	if err := f.setMembers(group, members); err != nil {
+		return "", err
+	}
+	f.Groups[groupName] = group
+	return group.ID, nil
+}
+
+// setMembers splits members into the emails and other rules of group. Like
+// Cloudflare, it refuses references to groups that do not exist.
+func (f *Fake) setMembers(group *Group, members []dns.Rule) error {
+	var emails []string
+	var rules []dns.Rule
+	for _, member := range members {
+		switch member.Type {
+		case dns.RuleEmail:
+			emails = append(emails, member.Value)
+		case dns.RuleGroup:
+			if _, ok := f.Groups[member.Value]; !ok {
+				return fmt.Errorf("access group %q not found", member.Value)
+			}
+			rules = append(rules, member)
+		default:
+			rules = append(rules, member)
+		}
+	}
+	group.Emails, group.Rules = emails, rules
+	return nil
+}
+
+// DeleteAccessGroup deletes a group by name
+func (f *Fake) DeleteAccessGroup(ctx context.Context, groupName string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("DeleteAccessGroup"); err != nil {
+		return "", err
+	}
+
+	group, ok := f.Groups[groupName]
+	if !ok {
+		return "", fmt.Errorf("access group %q not found", groupName)
+	}
+	delete(f.Groups, groupName)
+	return group.ID, nil
+}
+
+// CreateServiceToken adds a service token with a fresh secret. Durations are
+// "forever" or Go durations in hours (e.g. "8760h").
+func (f *Fake) CreateServiceToken(ctx context.Context, name, duration string) (*dns.ServiceToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreateServiceToken"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.ServiceTokens[name]; ok {
+		return nil, fmt.Errorf("service token %q already exists - rotate it with `orb access token rotate %s`", name, name)
+	}
+
+	token := &dns.ServiceToken{ID: f.id("token"), Name: name, ClientID: f.id("client") + ".access", Duration: duration}
+	if err := renew(token); err != nil {
+		return nil, err
+	}
+	f.ServiceTokens[name] = token
+
+	created := *token
+	created.ClientSecret = f.id("secret")
+	return &created, nil
+}
+
+// ListServiceTokens returns the service tokens sorted by name, without secrets
+func (f *Fake) ListServiceTokens(ctx context.Context) ([]dns.ServiceToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ListServiceTokens"); err != nil {
+		return nil, err
+	}
+
+	tokens := make([]dns.ServiceToken, 0, len(f.ServiceTokens))
+	for _, token := range f.ServiceTokens {
+		tokens = append(tokens, *token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return tokens, nil
+}
+
+// RotateServiceToken gives a service token a fresh secret and renews its expiry
+func (f *Fake) RotateServiceToken(ctx context.Context, name string) (*dns.ServiceToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("RotateServiceToken"); err != nil {
+		return nil, err
+	}
+	token, ok := f.ServiceTokens[name]
+	if !ok {
+		return nil, fmt.Errorf("service token %q %w", name, dns.ErrServiceTokenNotFound)
+	}
+	if err := renew(token); err != nil {
+		return nil, err
+	}
+
+	rotated := *token
+	rotated.ClientSecret = f.id("secret")
+	return &rotated, nil
+}
+
+// DeleteServiceToken removes a service token, returning its ID
+func (f *Fake) DeleteServiceToken(ctx context.Context, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("DeleteServiceToken"); err != nil {
+		return "", err
+	}
+	token, ok := f.ServiceTokens[name]
+	if !ok {
+		return "", fmt.Errorf("service token %q %w", name, dns.ErrServiceTokenNotFound)
+	}
+	delete(f.ServiceTokens, name)
+	return token.ID, nil
+}
+
+// GetAccessLogs returns the entries of AccessLogs for hostname (or any exposed
+// hostname if empty) made after since, oldest first
+func (f *Fake) GetAccessLogs(ctx context.Context, hostname string, since time.Time) ([]dns.AccessLogEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetAccessLogs"); err != nil {
+		return nil, err
+	}
+
+	var entries []dns.AccessLogEntry
+	for _, entry := range f.AccessLogs {
+		if _, ok := f.Apps[entry.Hostname]; !ok || (hostname != "" && entry.Hostname != hostname) || entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// renew sets the expiry of token to its duration from now
+func renew(token *dns.ServiceToken) error {
+	if token.Duration == "forever" {
+		token.ExpiresAt = time.Time{}
+		return nil
+	}
+	d, err := time.ParseDuration(token.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid service token duration %q", token.Duration)
+	}
+	token.ExpiresAt = time.Now().Add(d)
+	return nil
+}
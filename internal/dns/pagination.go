@@ -0,0 +1,107 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// pageSize is the number of results requested per page from list endpoints
+const pageSize = 50
+
+// paginate walks every page of a Cloudflare list endpoint and returns all results
+func paginate[T any](list func(page cloudflare.ResultInfo) ([]T, *cloudflare.ResultInfo, error)) ([]T, error) {
+	var all []T
+	page := cloudflare.ResultInfo{Page: 1, PerPage: pageSize}
+
+	for {
+		items, info, err := list(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+
+		if len(items) == 0 || info == nil || !info.HasMorePages() {
+			return all, nil
+		}
+		page.Page = info.Page + 1
+	}
+}
+
+// listDNSRecords returns all zone DNS records matching params
+func (c *Client) listDNSRecords(ctx context.Context, params cloudflare.ListDNSRecordsParams) ([]cloudflare.DNSRecord, error) {
+	return paginate(func(page cloudflare.ResultInfo) ([]cloudflare.DNSRecord, *cloudflare.ResultInfo, error) {
+		params.ResultInfo = page
+		return c.api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(c.zoneID), params)
+	})
+}
+
+// listAccessApplications returns all Access applications in the account
+func (c *Client) listAccessApplications(ctx context.Context) ([]cloudflare.AccessApplication, error) {
+	return paginate(func(page cloudflare.ResultInfo) ([]cloudflare.AccessApplication, *cloudflare.ResultInfo, error) {
+		return c.api.ListAccessApplications(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessApplicationsParams{ResultInfo: page})
+	})
+}
+
+// listAccessPolicies returns all policies attached to an Access application
+func (c *Client) listAccessPolicies(ctx context.Context, appID string) ([]cloudflare.AccessPolicy, error) {
+	return paginate(func(page cloudflare.ResultInfo) ([]cloudflare.AccessPolicy, *cloudflare.ResultInfo, error) {
+		return c.api.ListAccessPolicies(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessPoliciesParams{
+			ApplicationID: appID,
+			ResultInfo:    page,
+		})
+	})
+}
+
+// listAccessGroups returns all Access groups in the account
+func (c *Client) listAccessGroups(ctx context.Context) ([]cloudflare.AccessGroup, error) {
+	return paginate(func(page cloudflare.ResultInfo) ([]cloudflare.AccessGroup, *cloudflare.ResultInfo, error) {
+		return c.api.ListAccessGroups(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessGroupsParams{ResultInfo: page})
+	})
+}
+
+// listAccessIdentityProviders returns all identity providers configured for Access
+func (c *Client) listAccessIdentityProviders(ctx context.Context) ([]cloudflare.AccessIdentityProvider, error) {
+	return paginate(func(page cloudflare.ResultInfo) ([]cloudflare.AccessIdentityProvider, *cloudflare.ResultInfo, error) {
+		return c.api.ListAccessIdentityProviders(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.ListAccessIdentityProvidersParams{ResultInfo: page})
+	})
+}
+
+// listAccessServiceTokens returns all service tokens in the account. cloudflare-go
+// does not pass paging parameters for this endpoint, so the pages are requested directly.
+func (c *Client) listAccessServiceTokens(ctx context.Context) ([]cloudflare.AccessServiceToken, error) {
+	return paginate(func(page cloudflare.ResultInfo) ([]cloudflare.AccessServiceToken, *cloudflare.ResultInfo, error) {
+		res, err := c.api.Raw(ctx, http.MethodGet, fmt.Sprintf("/accounts/%s/access/service_tokens?page=%d&per_page=%d", c.accountID, page.Page, page.PerPage), nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var tokens []cloudflare.AccessServiceToken
+		if err := json.Unmarshal(res.Result, &tokens); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode service tokens: %w", err)
+		}
+		return tokens, pageInfo(page, len(tokens)), nil
+	})
+}
+
+// listTunnelRoutes returns the private network routes matching params
+func (c *Client) listTunnelRoutes(ctx context.Context, params cloudflare.TunnelRoutesListParams) ([]cloudflare.TunnelRoute, error) {
+	return paginate(func(page cloudflare.ResultInfo) ([]cloudflare.TunnelRoute, *cloudflare.ResultInfo, error) {
+		params.PaginationOptions = cloudflare.PaginationOptions{Page: page.Page, PerPage: page.PerPage}
+		routes, err := c.api.ListTunnelRoutes(ctx, cloudflare.AccountIdentifier(c.accountID), params)
+		return routes, pageInfo(page, len(routes)), err
+	})
+}
+
+// pageInfo stands in for the result info of endpoints whose responses cloudflare-go
+// does not return it for: a full page means there may be another
+func pageInfo(page cloudflare.ResultInfo, count int) *cloudflare.ResultInfo {
+	info := page
+	info.TotalPages = page.Page
+	if count >= page.PerPage {
+		info.TotalPages = page.Page + 1
+	}
+	return &info
+}
@@ -0,0 +1,154 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// testClient returns a client talking to a test server run by handler
+func testClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	api, err := cloudflare.NewWithAPIToken("token", cloudflare.BaseURL(srv.URL), cloudflare.UsingRetryPolicy(0, 0, 0), cloudflare.UsingRateLimit(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{api: api, zoneID: "zone", accountID: "account"}
+}
+
+// writePage writes page of total numbered items as a Cloudflare list response,
+// with result_info built by info (nil leaves it out)
+func writePage(w http.ResponseWriter, r *http.Request, total int, info func(page, perPage int) map[string]int) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+
+	items := []map[string]string{}
+	for i := (page - 1) * perPage; i < min(page*perPage, total); i++ {
+		items = append(items, map[string]string{"id": fmt.Sprintf("id-%d", i), "name": fmt.Sprintf("item-%d", i)})
+	}
+
+	body := map[string]any{"success": true, "errors": []any{}, "messages": []any{}, "result": items}
+	if info != nil {
+		body["result_info"] = info(page, perPage)
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestPaginateResultInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		info         func(page, perPage int) map[string]int
+		wantItems    int
+		wantRequests int32
+	}{
+		{
+			name:  "total pages",
+			total: 120,
+			info: func(page, perPage int) map[string]int {
+				return map[string]int{"page": page, "per_page": perPage, "total_count": 120, "total_pages": 3}
+			},
+			wantItems:    120,
+			wantRequests: 3,
+		},
+		{
+			name:  "total count only",
+			total: 120,
+			info: func(page, perPage int) map[string]int {
+				return map[string]int{"page": page, "per_page": perPage, "total_count": 120}
+			},
+			wantItems:    120,
+			wantRequests: 3,
+		},
+		{
+			name:         "no result info is a single page",
+			total:        120,
+			wantItems:    pageSize,
+			wantRequests: 1,
+		},
+		{
+			name:  "empty page ends the listing",
+			total: 60,
+			info: func(page, perPage int) map[string]int {
+				return map[string]int{"page": page, "per_page": perPage, "total_pages": 5}
+			},
+			wantItems:    60,
+			wantRequests: 3,
+		},
+		{
+			name:  "nothing to list",
+			total: 0,
+			info: func(page, perPage int) map[string]int {
+				return map[string]int{"page": page, "per_page": perPage}
+			},
+			wantItems:    0,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				writePage(w, r, tt.total, tt.info)
+			})
+
+			groups, err := c.listAccessGroups(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(groups) != tt.wantItems {
+				t.Errorf("got %d groups, want %d", len(groups), tt.wantItems)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestPaginatePageInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		wantRequests int32
+	}{
+		{"empty", 0, 1},
+		{"short page", 3, 1},
+		{"exactly one full page", pageSize, 2},
+		{"two full pages", 2 * pageSize, 3},
+		{"partial last page", 2*pageSize + 20, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				writePage(w, r, tt.total, nil)
+			})
+
+			tokens, err := c.listAccessServiceTokens(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tokens) != tt.total {
+				t.Errorf("got %d tokens, want %d", len(tokens), tt.total)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry policy for Cloudflare API requests
+const (
+	maxRetries    = 4
+	minRetryDelay = 500 * time.Millisecond
+	maxRetryDelay = 30 * time.Second
+	// maxRetryAfter caps how long we are willing to wait when Cloudflare asks us to back off
+	maxRetryAfter = 2 * time.Minute
+)
+
+// retryTransport retries failed Cloudflare API requests with exponential backoff.
+// Rate-limited requests (429) are always retried since Cloudflare rejected them before
+// doing any work; server and network errors are only retried for idempotent methods.
+type retryTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration // deadline for each request, retries and waits included; 0 disables it
+}
+
+// newRetryTransport wraps the default transport with retries and a per-request timeout
+func newRetryTransport(timeout time.Duration) *retryTransport {
+	return &retryTransport{
+		next:    http.DefaultTransport,
+		timeout: timeout,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+	}
+	req = req.WithContext(ctx)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+
+		if attempt >= maxRetries || ctx.Err() != nil || !shouldRetry(req, resp, err) {
+			return release(resp, err, cancel)
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if retryAfter > maxRetryAfter {
+					return release(resp, err, cancel)
+				}
+				delay = retryAfter
+			}
+		}
+
+		// waiting past the deadline would only turn this response into a timeout
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return release(resp, err, cancel)
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			cancel()
+			return nil, ctx.Err()
+		}
+
+		// rewind the body for the next attempt
+		if req.Body != nil {
+			if req.GetBody == nil {
+				cancel()
+				return nil, errors.New("cannot retry request with non-rewindable body")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// release hands the outcome of the last attempt to the caller, keeping the
+// request deadline alive until the response body is closed
+func release(resp *http.Response, err error, cancel context.CancelFunc) (*http.Response, error) {
+	if err != nil || resp == nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// shouldRetry reports whether a failed attempt is worth repeating
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !isIdempotent(req.Method) {
+		return false
+	}
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// isIdempotent reports whether repeating a request with this method is safe
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the exponential delay before the given retry attempt
+func backoff(attempt int) time.Duration {
+	delay := minRetryDelay << attempt
+	if delay > maxRetryDelay || delay <= 0 {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// cancelOnClose releases a request context once the response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
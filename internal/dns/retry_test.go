@@ -0,0 +1,256 @@
+package dns
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 500 * time.Millisecond},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, maxRetryDelay},
+		{100, maxRetryDelay}, // the shift overflows
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"missing", "", 0, false},
+		{"zero seconds", "0", 0, true},
+		{"seconds", "7", 7 * time.Second, true},
+		{"negative seconds", "-3", 0, false},
+		{"garbage", "soon", 0, false},
+		{"date in the past", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+		{"date in the future", time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat), 90 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parseRetryAfter(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			// HTTP dates have whole-second precision
+			if got > tt.want || got < tt.want-2*time.Second {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// reply is one canned response of a test server
+type reply struct {
+	status     int
+	retryAfter string
+}
+
+// replayServer answers the n-th request with replies[n], repeating the last one,
+// and records the bodies it received
+type replayServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	replies []reply
+	bodies  []string
+}
+
+func newReplayServer(t *testing.T, replies ...reply) *replayServer {
+	t.Helper()
+	s := &replayServer{replies: replies}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		n := len(s.bodies)
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+
+		rep := s.replies[min(n, len(s.replies)-1)]
+		if rep.retryAfter != "" {
+			w.Header().Set("Retry-After", rep.retryAfter)
+		}
+		w.WriteHeader(rep.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// attempts returns how many requests the server received
+func (s *replayServer) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func TestRetryTransport(t *testing.T) {
+	pastDate := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		method       string
+		replies      []reply
+		wantStatus   int
+		wantAttempts int
+		minElapsed   time.Duration
+	}{
+		{
+			name:         "success is not retried",
+			method:       http.MethodGet,
+			replies:      []reply{{status: http.StatusOK}},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 1,
+		},
+		{
+			name:         "server error on GET is retried",
+			method:       http.MethodGet,
+			replies:      []reply{{status: http.StatusServiceUnavailable, retryAfter: "0"}, {status: http.StatusOK}},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+		{
+			name:         "server error waits for the backoff",
+			method:       http.MethodGet,
+			replies:      []reply{{status: http.StatusBadGateway}, {status: http.StatusBadGateway}, {status: http.StatusOK}},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 3,
+			minElapsed:   backoff(0) + backoff(1),
+		},
+		{
+			name:         "gives up after the last retry",
+			method:       http.MethodDelete,
+			replies:      []reply{{status: http.StatusInternalServerError, retryAfter: "0"}},
+			wantStatus:   http.StatusInternalServerError,
+			wantAttempts: maxRetries + 1,
+		},
+		{
+			name:         "client error is not retried",
+			method:       http.MethodGet,
+			replies:      []reply{{status: http.StatusNotFound}},
+			wantStatus:   http.StatusNotFound,
+			wantAttempts: 1,
+		},
+		{
+			name:         "server error on POST is not retried",
+			method:       http.MethodPost,
+			replies:      []reply{{status: http.StatusServiceUnavailable, retryAfter: "0"}, {status: http.StatusOK}},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+		{
+			name:         "rate limited POST is retried",
+			method:       http.MethodPost,
+			replies:      []reply{{status: http.StatusTooManyRequests, retryAfter: "0"}, {status: http.StatusCreated}},
+			wantStatus:   http.StatusCreated,
+			wantAttempts: 2,
+		},
+		{
+			name:         "Retry-After date in the past retries at once",
+			method:       http.MethodGet,
+			replies:      []reply{{status: http.StatusTooManyRequests, retryAfter: pastDate}, {status: http.StatusOK}},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+		{
+			name:         "Retry-After beyond the cap is not waited for",
+			method:       http.MethodGet,
+			replies:      []reply{{status: http.StatusTooManyRequests, retryAfter: "600"}, {status: http.StatusOK}},
+			wantStatus:   http.StatusTooManyRequests,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newReplayServer(t, tt.replies...)
+			client := &http.Client{Transport: newRetryTransport(0)}
+
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader(`{"name":"orb"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := srv.attempts(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("retried after %v, want at least %v", elapsed, tt.minElapsed)
+			}
+			for i, body := range srv.bodies {
+				if body != `{"name":"orb"}` {
+					t.Errorf("attempt %d sent body %q", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportTimeout(t *testing.T) {
+	t.Run("waits past the deadline are skipped", func(t *testing.T) {
+		srv := newReplayServer(t, reply{status: http.StatusServiceUnavailable, retryAfter: "5"})
+		client := &http.Client{Transport: newRetryTransport(time.Second)}
+
+		start := time.Now()
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable || srv.attempts() != 1 {
+			t.Errorf("got %d after %d attempts, want 503 after 1", resp.StatusCode, srv.attempts())
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("waited %v for a retry that could not finish in time", elapsed)
+		}
+	})
+
+	t.Run("the deadline covers all attempts", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		t.Cleanup(srv.Close)
+		client := &http.Client{Transport: newRetryTransport(200 * time.Millisecond)}
+
+		start := time.Now()
+		if resp, err := client.Get(srv.URL); err == nil {
+			resp.Body.Close()
+			t.Fatal("request to a hanging server succeeded")
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("request gave up after %v, want about 200ms", elapsed)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// Route is a private network (CIDR) that WARP clients reach through a tunnel
+type Route struct {
+	Network    string // e.g. 10.0.0.0/24
+	TunnelID   string
+	TunnelName string
+	Comment    string
+	CreatedAt  time.Time
+}
+
+// ListTunnelRoutes returns the private network routes of a tunnel, or of every
+// tunnel in the account if tunnelID is empty, ordered by network
+func (c *Client) ListTunnelRoutes(ctx context.Context, tunnelID string) ([]Route, error) {
+	routes, err := c.listTunnelRoutes(ctx, cloudflare.TunnelRoutesListParams{
+		TunnelID:  tunnelID,
+		IsDeleted: cloudflare.BoolPtr(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tunnel routes: %w", err)
+	}
+
+	result := make([]Route, 0, len(routes))
+	for _, route := range routes {
+		result = append(result, Route{
+			Network:    route.Network,
+			TunnelID:   route.TunnelID,
+			TunnelName: route.TunnelName,
+			Comment:    route.Comment,
+			CreatedAt:  timeOrZero(route.CreatedAt),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Network < result[j].Network })
+	return result, nil
+}
+
+// CreateTunnelRoute routes a private network to a tunnel. Routes are identified
+// by their network, so nothing else is returned.
+func (c *Client) CreateTunnelRoute(ctx context.Context, tunnelID, network, comment string) error {
+	_, err := c.api.CreateTunnelRoute(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.TunnelRoutesCreateParams{
+		Network:  network,
+		TunnelID: tunnelID,
+		Comment:  comment,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create route for %s: %w", network, err)
+	}
+	return nil
+}
+
+// DeleteTunnelRoute removes the route of a private network
+func (c *Client) DeleteTunnelRoute(ctx context.Context, network string) error {
+	if err := c.api.DeleteTunnelRoute(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.TunnelRoutesDeleteParams{Network: network}); err != nil {
+		return fmt.Errorf("failed to delete route for %s: %w", network, err)
+	}
+	return nil
+}
@@ -0,0 +1,643 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Rule types accepted by ParseRule
+const (
+	RuleEmail        = "email"
+	RuleEmailDomain  = "email-domain"
+	RuleIP           = "ip"
+	RuleCountry      = "country"
+	RuleServiceToken = "service-token"
+	RuleGroup        = "group"
+	RuleEveryone     = "everyone"
+)
+
+// ruleTypes lists the rule types in the order they are documented
+var ruleTypes = []string{RuleEmail, RuleEmailDomain, RuleIP, RuleCountry, RuleServiceToken, RuleGroup, RuleEveryone}
+
+var (
+	// domainRe validates an email domain (e.g. example.com)
+	domainRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+$`)
+	// countryRe validates an ISO 3166-1 alpha-2 country code
+	countryRe = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// Rule is a single Access rule such as "email-domain:example.com" or "everyone".
+// Groups and service tokens are referred to by name.
+type Rule struct {
+	Type  string
+	Value string
+
+	// Raw is the API JSON of a rule type orb does not manage (e.g. an identity
+	// provider group), so it is written back unchanged rather than dropped
+	Raw string
+}
+
+// AccessRules are the extra conditions on a hostname's non-owner policies:
+// Allow rules grant access (any one suffices), Require rules must all match
+// and Exclude rules deny access even when an allow rule matches
+type AccessRules struct {
+	Allow   []Rule
+	Require []Rule
+	Exclude []Rule
+}
+
+// Access describes the Access application protecting a hostname.
+// A nil *Access means the hostname is public.
+type Access struct {
+	AppID    string
+	Policies []Policy // in precedence order
+
+	// PublicPaths are paths with their own bypass application (e.g. /webhook/*).
+	// ApplyAccess creates any that are missing and removes them with the application.
+	PublicPaths []string
+
+	// Settings are the application's settings, as reported by GetAccess. ApplyAccess
+	// uses them when it has to recreate the application; nil leaves the defaults.
+	Settings *AppSettings
+}
+
+// Policy is an Access policy attached to an orb application
+type Policy struct {
+	ID         string
+	Name       string
+	Decision   string // "allow" or "non_identity" (service tokens)
+	Precedence int
+	Include    []Rule
+	Require    []Rule
+	Exclude    []Rule
+}
+
+// ParseRule parses a rule written as type:value (or just "everyone")
+func ParseRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, RuleEveryone) {
+		return Rule{Type: RuleEveryone}, nil
+	}
+
+	ruleType, value, ok := strings.Cut(s, ":")
+	ruleType = strings.ToLower(strings.TrimSpace(ruleType))
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		return Rule{}, fmt.Errorf("invalid rule %q: use type:value with type one of %s", s, strings.Join(ruleTypes, ", "))
+	}
+
+	switch ruleType {
+	case RuleEmail:
+		if !strings.Contains(value, "@") {
+			return Rule{}, fmt.Errorf("invalid rule %q: not an email address", s)
+		}
+		return Rule{Type: RuleEmail, Value: strings.ToLower(value)}, nil
+	case RuleEmailDomain:
+		domain := strings.ToLower(strings.TrimPrefix(value, "@"))
+		if !domainRe.MatchString(domain) {
+			return Rule{}, fmt.Errorf("invalid rule %q: not a domain (e.g. email-domain:example.com)", s)
+		}
+		return Rule{Type: RuleEmailDomain, Value: domain}, nil
+	case RuleIP:
+		cidr, err := normalizeCIDR(value)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid rule %q: %w", s, err)
+		}
+		return Rule{Type: RuleIP, Value: cidr}, nil
+	case RuleCountry:
+		code := strings.ToUpper(value)
+		if !countryRe.MatchString(code) {
+			return Rule{}, fmt.Errorf("invalid rule %q: use a two-letter country code (e.g. country:CA)", s)
+		}
+		return Rule{Type: RuleCountry, Value: code}, nil
+	case RuleServiceToken, RuleGroup:
+		return Rule{Type: ruleType, Value: value}, nil
+	default:
+		return Rule{}, fmt.Errorf("unknown rule type %q: must be one of %s", ruleType, strings.Join(ruleTypes, ", "))
+	}
+}
+
+// ParseMember parses an Access group member: a bare email address, or any rule
+// ParseRule accepts (e.g. email-domain:example.com, group:contractors)
+func ParseMember(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "@") && !strings.Contains(s, ":") {
+		return ParseRule(RuleEmail + ":" + s)
+	}
+	return ParseRule(s)
+}
+
+// FormatMember is the inverse of ParseMember: email addresses bare, other rules as type:value
+func FormatMember(r Rule) string {
+	if r.Type == RuleEmail {
+		return r.Value
+	}
+	return r.String()
+}
+
+// ParseRules parses lists of rules, e.g. from repeated command-line flags
+func ParseRules(values []string) ([]Rule, error) {
+	var rules []Rule
+	for _, value := range values {
+		rule, err := ParseRule(value)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// normalizeCIDR accepts an IP address or CIDR range and returns it in CIDR form
+func normalizeCIDR(value string) (string, error) {
+	if ip := net.ParseIP(value); ip != nil {
+		if ip.To4() != nil {
+			return ip.String() + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return "", fmt.Errorf("not an IP address or CIDR range (e.g. ip:203.0.113.0/24)")
+	}
+	return network.String(), nil
+}
+
+// String renders the rule in the form ParseRule accepts
+func (r Rule) String() string {
+	if r.Value == "" {
+		return r.Type
+	}
+	return r.Type + ":" + r.Value
+}
+
+// IsZero reports whether no rules are set
+func (r AccessRules) IsZero() bool {
+	return len(r.Allow) == 0 && len(r.Require) == 0 && len(r.Exclude) == 0
+}
+
+// String renders the rules as clauses, e.g. "allow email-domain:example.com; require country:CA"
+func (r AccessRules) String() string {
+	var clauses []string
+	if len(r.Allow) > 0 {
+		clauses = append(clauses, "allow "+JoinRules(r.Allow))
+	}
+	if len(r.Require) > 0 {
+		clauses = append(clauses, "require "+JoinRules(r.Require))
+	}
+	if len(r.Exclude) > 0 {
+		clauses = append(clauses, "exclude "+JoinRules(r.Exclude))
+	}
+	return strings.Join(clauses, "; ")
+}
+
+// identityRules returns the allow rules that need a login (everything but service tokens)
+func (r AccessRules) identityRules() []Rule {
+	var rules []Rule
+	for _, rule := range r.Allow {
+		if rule.Type != RuleServiceToken {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// tokenRules returns the service token allow rules, which need a non_identity policy
+func (r AccessRules) tokenRules() []Rule {
+	var rules []Rule
+	for _, rule := range r.Allow {
+		if rule.Type == RuleServiceToken {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Policy name suffixes of the policies orb manages on an application
+const (
+	policyOwner  = "owner"  // precedence 1: the owner's email
+	policyGroup  = "group"  // precedence 2: an Access group
+	policyRules  = "rules"  // precedence 3: --allow rules that need a login
+	policyTokens = "tokens" // precedence 4: --allow service-token rules
+	policyShare  = "share"  // precedence 5 and up: one per email shared with `orb tunnel share`
+)
+
+// firstSharePrecedence is the precedence of the first share policy on an application
+const firstSharePrecedence = 5
+
+// policyBypass names the policy of a --public-path application
+const policyBypass = "bypass"
+
+// pathAppName returns the name of the application that makes path of hostname public
+func pathAppName(hostname, path string) string {
+	return fmt.Sprintf("orb-%s%s", hostname, path)
+}
+
+// policyName returns the name of one of orb's policies for hostname
+func policyName(hostname, suffix string) string {
+	return fmt.Sprintf("orb-%s-%s", hostname, suffix)
+}
+
+// isOwnerPolicy reports whether a policy is the owner policy orb creates
+func isOwnerPolicy(name string) bool {
+	return strings.HasSuffix(name, "-"+policyOwner)
+}
+
+// isGroupPolicy reports whether a policy is the group policy orb creates for --access
+func isGroupPolicy(name string) bool {
+	return strings.HasSuffix(name, "-"+policyGroup)
+}
+
+// GroupRules returns the group rules of an access level: none for "public" and
+// "private", otherwise one per comma-separated group name (e.g. "team,contractors")
+func GroupRules(accessLevel string) []Rule {
+	if accessLevel == "public" || accessLevel == "private" {
+		return nil
+	}
+	var rules []Rule
+	for _, name := range strings.Split(accessLevel, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			rules = appendUnique(rules, Rule{Type: RuleGroup, Value: name})
+		}
+	}
+	return rules
+}
+
+// SharePolicy returns the policy that shares hostname with a single email address
+func SharePolicy(hostname, email string, precedence int) Policy {
+	return Policy{
+		Name:       policyName(hostname, policyShare+"-"+email),
+		Decision:   "allow",
+		Precedence: precedence,
+		Include:    []Rule{{Type: RuleEmail, Value: email}},
+	}
+}
+
+// Kind returns which of orb's policies p is: "owner", "group", "rules", "tokens",
+// "share" or "bypass" ("" for a policy orb did not create)
+func (p Policy) Kind() string {
+	if _, ok := shareEmail(p); ok {
+		return policyShare
+	}
+	for _, suffix := range []string{policyOwner, policyGroup, policyRules, policyTokens, policyBypass} {
+		if strings.HasSuffix(p.Name, "-"+suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// shareEmail returns the email a share policy grants, if policy is one
+func shareEmail(policy Policy) (string, bool) {
+	if len(policy.Include) != 1 || policy.Include[0].Type != RuleEmail {
+		return "", false
+	}
+	email := policy.Include[0].Value
+	return email, strings.HasSuffix(policy.Name, "-"+policyShare+"-"+email)
+}
+
+// Shares returns the email addresses the hostname is shared with, by share policy name
+func (a *Access) Shares() map[string]string {
+	shares := make(map[string]string)
+	if a == nil {
+		return shares
+	}
+	for _, policy := range a.Policies {
+		if email, ok := shareEmail(policy); ok {
+			shares[email] = policy.Name
+		}
+	}
+	return shares
+}
+
+// NextSharePrecedence returns the precedence for a new share policy, after every existing policy
+func (a *Access) NextSharePrecedence() int {
+	next := firstSharePrecedence
+	if a == nil {
+		return next
+	}
+	for _, policy := range a.Policies {
+		next = max(next, policy.Precedence+1)
+	}
+	return next
+}
+
+// Groups returns the names of the Access groups the group policy grants
+func (a *Access) Groups() []string {
+	if a == nil {
+		return nil
+	}
+	var groups []string
+	for _, policy := range a.Policies {
+		if !isGroupPolicy(policy.Name) {
+			continue
+		}
+		for _, rule := range policy.Include {
+			if rule.Type == RuleGroup {
+				groups = append(groups, rule.Value)
+			}
+		}
+	}
+	return groups
+}
+
+// Tokens returns the names of the service tokens the tokens policy lets in
+func (a *Access) Tokens() []string {
+	if a == nil {
+		return nil
+	}
+	var tokens []string
+	for _, policy := range a.Policies {
+		if !strings.HasSuffix(policy.Name, "-"+policyTokens) {
+			continue
+		}
+		for _, rule := range policy.Include {
+			if rule.Type == RuleServiceToken {
+				tokens = append(tokens, rule.Value)
+			}
+		}
+	}
+	return tokens
+}
+
+// WithTokens returns a copy of the access whose non_identity tokens policy lets
+// exactly tokens in, dropping the policy if tokens is empty. A new policy takes
+// the require and exclude rules of the others, like --allow service-token: does.
+func (a *Access) WithTokens(hostname string, tokens []string) *Access {
+	name := policyName(hostname, policyTokens)
+	next := &Access{AppID: a.AppID, PublicPaths: a.PublicPaths, Settings: a.Settings}
+	var current *Policy
+	for i, policy := range a.Policies {
+		if policy.Name == name {
+			current = &a.Policies[i]
+			continue
+		}
+		next.Policies = append(next.Policies, policy)
+	}
+	if len(tokens) == 0 {
+		return next
+	}
+
+	policy := Policy{Name: name, Decision: "non_identity", Precedence: 4}
+	if current != nil {
+		policy.ID, policy.Require, policy.Exclude = current.ID, current.Require, current.Exclude
+	} else {
+		rules := a.Rules()
+		policy.Require, policy.Exclude = rules.Require, rules.Exclude
+	}
+	for _, token := range tokens {
+		policy.Include = append(policy.Include, Rule{Type: RuleServiceToken, Value: token})
+	}
+	next.Policies = append(next.Policies, policy)
+	sortPolicies(next.Policies)
+	return next
+}
+
+// Owners returns who the owner policy lets in (nil if it has none)
+func (a *Access) Owners() []Rule {
+	if a == nil {
+		return nil
+	}
+	for _, policy := range a.Policies {
+		if policy.Kind() == policyOwner {
+			return policy.Include
+		}
+	}
+	return nil
+}
+
+// WithOwners returns a copy of the access whose owner policy lets exactly owners in,
+// creating the policy at precedence 1 if it is missing
+func (a *Access) WithOwners(hostname string, owners []Rule) *Access {
+	name := policyName(hostname, policyOwner)
+	next := &Access{AppID: a.AppID, PublicPaths: a.PublicPaths, Settings: a.Settings}
+	found := false
+	for _, policy := range a.Policies {
+		if policy.Name == name {
+			policy.Include = owners
+			found = true
+		}
+		next.Policies = append(next.Policies, policy)
+	}
+	if !found {
+		next.Policies = append(next.Policies, Policy{Name: name, Decision: "allow", Precedence: 1, Include: owners})
+		sortPolicies(next.Policies)
+	}
+	return next
+}
+
+// SameRules reports whether a and b hold the same rules, in any order
+func SameRules(a, b []Rule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, rule := range a {
+		if !slices.Contains(b, rule) {
+			return false
+		}
+	}
+	return true
+}
+
+// Shared reports whether anyone besides the owner has access
+func (a *Access) Shared() bool {
+	if a == nil {
+		return false
+	}
+	for _, policy := range a.Policies {
+		if !isOwnerPolicy(policy.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the allow, require and exclude rules of the non-owner policies
+func (a *Access) Rules() AccessRules {
+	var rules AccessRules
+	if a == nil {
+		return rules
+	}
+	for _, policy := range a.Policies {
+		if isOwnerPolicy(policy.Name) {
+			continue
+		}
+		rules.Allow = appendUnique(rules.Allow, policy.Include...)
+		rules.Require = appendUnique(rules.Require, policy.Require...)
+		rules.Exclude = appendUnique(rules.Exclude, policy.Exclude...)
+	}
+	return rules
+}
+
+// String summarises who can reach the hostname, e.g. "public", "private",
+// "friends, email-domain:example.com" or "friends (require country:CA)"
+func (a *Access) String() string {
+	if a == nil {
+		return "public"
+	}
+	if len(a.Policies) == 0 {
+		return "protected"
+	}
+
+	rules := a.Rules()
+	if len(rules.Allow) == 0 {
+		return "private" + a.publicPathsSuffix()
+	}
+
+	var grants []string
+	for _, rule := range rules.Allow {
+		if rule.Type == RuleGroup {
+			grants = append(grants, rule.Value) // groups read as they do in --access
+			continue
+		}
+		grants = append(grants, rule.String())
+	}
+	summary := strings.Join(grants, ", ")
+
+	var conditions []string
+	if len(rules.Require) > 0 {
+		conditions = append(conditions, "require "+JoinRules(rules.Require))
+	}
+	if len(rules.Exclude) > 0 {
+		conditions = append(conditions, "exclude "+JoinRules(rules.Exclude))
+	}
+	if len(conditions) > 0 {
+		summary += " (" + strings.Join(conditions, "; ") + ")"
+	}
+	return summary + a.publicPathsSuffix()
+}
+
+// publicPathsSuffix renders the public paths for String, e.g. " + public /webhook/*"
+func (a *Access) publicPathsSuffix() string {
+	if len(a.PublicPaths) == 0 {
+		return ""
+	}
+	return " + public " + strings.Join(a.PublicPaths, ", ")
+}
+
+// DescribePolicy renders a policy's rules on one line, e.g.
+// "allow email-domain:example.com; require country:CA"
+func DescribePolicy(p Policy) string {
+	parts := []string{p.Decision + " " + JoinRules(p.Include)}
+	if len(p.Require) > 0 {
+		parts = append(parts, "require "+JoinRules(p.Require))
+	}
+	if len(p.Exclude) > 0 {
+		parts = append(parts, "exclude "+JoinRules(p.Exclude))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// samePolicy reports whether two policies grant the same access
+func samePolicy(a, b Policy) bool {
+	return a.Decision == b.Decision && a.Precedence == b.Precedence && DescribePolicy(a) == DescribePolicy(b)
+}
+
+// JoinRules renders rules as a comma-separated list
+func JoinRules(rules []Rule) string {
+	parts := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		parts = append(parts, rule.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// appendUnique appends the rules not already present
+func appendUnique(rules []Rule, add ...Rule) []Rule {
+	for _, rule := range add {
+		seen := false
+		for _, existing := range rules {
+			if existing == rule {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// sortPolicies orders policies by precedence
+func sortPolicies(policies []Policy) {
+	sort.SliceStable(policies, func(i, j int) bool { return policies[i].Precedence < policies[j].Precedence })
+}
+
+// apiRule is the JSON shape of an Access rule as the API returns it
+type apiRule struct {
+	Email *struct {
+		Email string `json:"email"`
+	} `json:"email"`
+	EmailDomain *struct {
+		Domain string `json:"domain"`
+	} `json:"email_domain"`
+	IP *struct {
+		IP string `json:"ip"`
+	} `json:"ip"`
+	Geo *struct {
+		CountryCode string `json:"country_code"`
+	} `json:"geo"`
+	ServiceToken *struct {
+		TokenID string `json:"token_id"`
+	} `json:"service_token"`
+	Group *struct {
+		ID string `json:"id"`
+	} `json:"group"`
+	Everyone *struct{} `json:"everyone"`
+}
+
+// ruleFromAPI converts an include/require/exclude entry into a Rule. Entries arrive
+// as decoded JSON maps from the API but as typed cloudflare structs when built locally,
+// so both are handled by round-tripping through JSON. Group and service token IDs
+// are translated with names (ID -> name), falling back to the ID.
+func ruleFromAPI(v any, names map[string]string) Rule {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Rule{Type: "unknown"}
+	}
+
+	var raw apiRule
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Rule{Type: "unknown", Raw: string(data)}
+	}
+
+	name := func(id string) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return id
+	}
+
+	switch {
+	case raw.Email != nil:
+		return Rule{Type: RuleEmail, Value: raw.Email.Email}
+	case raw.EmailDomain != nil:
+		return Rule{Type: RuleEmailDomain, Value: raw.EmailDomain.Domain}
+	case raw.IP != nil:
+		return Rule{Type: RuleIP, Value: raw.IP.IP}
+	case raw.Geo != nil:
+		return Rule{Type: RuleCountry, Value: raw.Geo.CountryCode}
+	case raw.ServiceToken != nil:
+		return Rule{Type: RuleServiceToken, Value: name(raw.ServiceToken.TokenID)}
+	case raw.Group != nil:
+		return Rule{Type: RuleGroup, Value: name(raw.Group.ID)}
+	case raw.Everyone != nil:
+		return Rule{Type: RuleEveryone}
+	}
+
+	// a rule orb does not manage (e.g. an identity provider group): show its kind
+	// and keep the rule itself
+	var kinds map[string]json.RawMessage
+	if err := json.Unmarshal(data, &kinds); err == nil {
+		for kind := range kinds {
+			return Rule{Type: kind, Raw: string(data)}
+		}
+	}
+	return Rule{Type: "unknown", Raw: string(data)}
+}
@@ -0,0 +1,190 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rule
+		wantErr bool
+	}{
+		{in: "email:Bob@Example.com", want: Rule{Type: RuleEmail, Value: "bob@example.com"}},
+		{in: " EMAIL : bob@example.com ", want: Rule{Type: RuleEmail, Value: "bob@example.com"}},
+		{in: "email:bob", wantErr: true},
+		{in: "email-domain:Example.COM", want: Rule{Type: RuleEmailDomain, Value: "example.com"}},
+		{in: "email-domain:@example.com", want: Rule{Type: RuleEmailDomain, Value: "example.com"}},
+		{in: "email-domain:example", wantErr: true},
+		{in: "email-domain:-example.com", wantErr: true},
+		{in: "ip:203.0.113.7", want: Rule{Type: RuleIP, Value: "203.0.113.7/32"}},
+		{in: "ip:203.0.113.9/24", want: Rule{Type: RuleIP, Value: "203.0.113.0/24"}},
+		{in: "ip:2001:db8::1", want: Rule{Type: RuleIP, Value: "2001:db8::1/128"}},
+		{in: "ip:2001:db8::/32", want: Rule{Type: RuleIP, Value: "2001:db8::/32"}},
+		{in: "ip:203.0.113.300", wantErr: true},
+		{in: "country:ca", want: Rule{Type: RuleCountry, Value: "CA"}},
+		{in: "country:CAN", wantErr: true},
+		{in: "country:C1", wantErr: true},
+		{in: "service-token:ci-deploy", want: Rule{Type: RuleServiceToken, Value: "ci-deploy"}},
+		{in: "group:friends", want: Rule{Type: RuleGroup, Value: "friends"}},
+		{in: "everyone", want: Rule{Type: RuleEveryone}},
+		{in: "Everyone", want: Rule{Type: RuleEveryone}},
+		{in: "everyone:yes", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "email", wantErr: true},
+		{in: "email:", wantErr: true},
+		{in: "saml:dept=eng", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRule(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRule(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule(%q) failed: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRule(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+
+			// String renders the rule so that it parses back the same
+			again, err := ParseRule(got.String())
+			if err != nil || again != got {
+				t.Errorf("ParseRule(%q) = %#v, %v after rendering %#v", got.String(), again, err, got)
+			}
+		})
+	}
+}
+
+func TestParseMember(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rule
+		format  string
+		wantErr bool
+	}{
+		{in: "Bob@Example.com", want: Rule{Type: RuleEmail, Value: "bob@example.com"}, format: "bob@example.com"},
+		{in: "email:bob@example.com", want: Rule{Type: RuleEmail, Value: "bob@example.com"}, format: "bob@example.com"},
+		{in: "email-domain:example.com", want: Rule{Type: RuleEmailDomain, Value: "example.com"}, format: "email-domain:example.com"},
+		{in: "group:contractors", want: Rule{Type: RuleGroup, Value: "contractors"}, format: "group:contractors"},
+		{in: "everyone", want: Rule{Type: RuleEveryone}, format: "everyone"},
+		{in: "bob", wantErr: true},
+		{in: "mailto:bob@example.com", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMember(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMember(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMember(%q) failed: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMember(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+			if format := FormatMember(got); format != tt.format {
+				t.Errorf("FormatMember(%#v) = %q, want %q", got, format, tt.format)
+			}
+		})
+	}
+}
+
+// decoded returns the JSON the way cloudflare-go hands it back from a list call
+func decoded(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestRuleFromAPI(t *testing.T) {
+	names := map[string]string{"grp-1": "friends", "tok-1": "ci-deploy"}
+
+	var email cloudflare.AccessGroupEmail
+	email.Email.Email = "bob@example.com"
+	var domain cloudflare.AccessGroupEmailDomain
+	domain.EmailDomain.Domain = "example.com"
+	var ip cloudflare.AccessGroupIP
+	ip.IP.IP = "203.0.113.0/24"
+	var geo cloudflare.AccessGroupGeo
+	geo.Geo.CountryCode = "CA"
+	var token cloudflare.AccessGroupServiceToken
+	token.ServiceToken.ID = "tok-1"
+	var group cloudflare.AccessGroupAccessGroup
+	group.Group.ID = "grp-1"
+
+	tests := []struct {
+		name string
+		in   any
+		want Rule
+	}{
+		{"typed email", email, Rule{Type: RuleEmail, Value: "bob@example.com"}},
+		{"typed email domain", domain, Rule{Type: RuleEmailDomain, Value: "example.com"}},
+		{"typed ip", ip, Rule{Type: RuleIP, Value: "203.0.113.0/24"}},
+		{"typed country", geo, Rule{Type: RuleCountry, Value: "CA"}},
+		{"typed service token", token, Rule{Type: RuleServiceToken, Value: "ci-deploy"}},
+		{"typed group", group, Rule{Type: RuleGroup, Value: "friends"}},
+		{"typed everyone", cloudflare.AccessGroupEveryone{}, Rule{Type: RuleEveryone}},
+		{"decoded email", decoded(t, `{"email":{"email":"bob@example.com"}}`), Rule{Type: RuleEmail, Value: "bob@example.com"}},
+		{"decoded email domain", decoded(t, `{"email_domain":{"domain":"example.com"}}`), Rule{Type: RuleEmailDomain, Value: "example.com"}},
+		{"decoded ip", decoded(t, `{"ip":{"ip":"203.0.113.0/24"}}`), Rule{Type: RuleIP, Value: "203.0.113.0/24"}},
+		{"decoded country", decoded(t, `{"geo":{"country_code":"CA"}}`), Rule{Type: RuleCountry, Value: "CA"}},
+		{"decoded service token", decoded(t, `{"service_token":{"token_id":"tok-1"}}`), Rule{Type: RuleServiceToken, Value: "ci-deploy"}},
+		{"decoded group", decoded(t, `{"group":{"id":"grp-1"}}`), Rule{Type: RuleGroup, Value: "friends"}},
+		{"decoded everyone", decoded(t, `{"everyone":{}}`), Rule{Type: RuleEveryone}},
+		{"unnamed group", decoded(t, `{"group":{"id":"grp-9"}}`), Rule{Type: RuleGroup, Value: "grp-9"}},
+		{
+			"unmanaged rule",
+			decoded(t, `{"saml":{"attribute_name":"dept","attribute_value":"eng"}}`),
+			Rule{Type: "saml", Raw: `{"saml":{"attribute_name":"dept","attribute_value":"eng"}}`},
+		},
+		{"not an object", "everyone", Rule{Type: "unknown", Raw: `"everyone"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleFromAPI(tt.in, names); got != tt.want {
+				t.Errorf("ruleFromAPI() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmanagedRuleRoundTrip(t *testing.T) {
+	in := `{"saml":{"attribute_name":"dept","attribute_value":"eng"}}`
+	rule := ruleFromAPI(decoded(t, in), nil)
+
+	// rules without a name to look up need no API calls
+	values, err := (&directory{}).toAPI(context.Background(), []Rule{rule, {Type: RuleEmail, Value: "bob@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[` + in + `,{"email":{"email":"bob@example.com"}}]`; string(out) != want {
+		t.Errorf("written back as %s, want %s", out, want)
+	}
+
+	if _, err := (&directory{}).toAPI(context.Background(), []Rule{{Type: "saml"}}); err == nil {
+		t.Error("a rule of an unmanaged type without its API JSON was accepted")
+	}
+}
@@ -0,0 +1,242 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// AppSettings are the settings of an Access application besides its policies.
+// Zero values leave Cloudflare's defaults in place.
+type AppSettings struct {
+	SessionDuration   string   // how long a login lasts, e.g. "24h"
+	IdentityProviders []string // names of the identity providers offered at login (all when empty)
+	AutoRedirect      bool     // skip the login page and go straight to the only identity provider
+	CORS              *CORS    // CORS headers for API clients (nil: none)
+	HideFromLauncher  bool     // keep the application out of the App Launcher
+	DenyMessage       string   // shown instead of the default page when access is denied
+	DenyURL           string   // redirect here instead when access is denied
+}
+
+// CORS are the CORS settings of an Access application. A "*" entry allows any
+// origin, method or header.
+type CORS struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // seconds browsers may cache a preflight response
+}
+
+// String renders the CORS settings on one line, e.g. "origins https://app.example.com; methods GET, POST"
+func (c *CORS) String() string {
+	if c == nil {
+		return "off"
+	}
+	var parts []string
+	if len(c.AllowedOrigins) > 0 {
+		parts = append(parts, "origins "+strings.Join(c.AllowedOrigins, ", "))
+	}
+	if len(c.AllowedMethods) > 0 {
+		parts = append(parts, "methods "+strings.Join(c.AllowedMethods, ", "))
+	}
+	if len(c.AllowedHeaders) > 0 {
+		parts = append(parts, "headers "+strings.Join(c.AllowedHeaders, ", "))
+	}
+	if c.AllowCredentials {
+		parts = append(parts, "credentials")
+	}
+	if c.MaxAge > 0 {
+		parts = append(parts, fmt.Sprintf("max-age %ds", c.MaxAge))
+	}
+	if len(parts) == 0 {
+		return "on"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// GetAppSettings returns the settings of the Access application for a hostname (nil if public)
+func (c *Client) GetAppSettings(ctx context.Context, hostname string) (*AppSettings, error) {
+	app, err := c.findAccessApplication(ctx, hostname)
+	if err != nil || app == nil {
+		return nil, err
+	}
+	return c.settingsFromAPI(ctx, app), nil
+}
+
+// settingsFromAPI returns the settings of an Access application, naming its identity providers
+func (c *Client) settingsFromAPI(ctx context.Context, app *cloudflare.AccessApplication) *AppSettings {
+	settings := &AppSettings{
+		SessionDuration: app.SessionDuration,
+		DenyMessage:     app.CustomDenyMessage,
+		DenyURL:         app.CustomDenyURL,
+		CORS:            corsFromAPI(app.CorsHeaders),
+	}
+	if app.AutoRedirectToIdentity != nil {
+		settings.AutoRedirect = *app.AutoRedirectToIdentity
+	}
+	if app.AppLauncherVisible != nil {
+		settings.HideFromLauncher = !*app.AppLauncherVisible
+	}
+	if len(app.AllowedIdps) > 0 {
+		names := c.identityProviderNames(ctx)
+		for _, id := range app.AllowedIdps {
+			if name, ok := names[id]; ok {
+				id = name
+			}
+			settings.IdentityProviders = append(settings.IdentityProviders, id)
+		}
+	}
+	return settings
+}
+
+// SetAppSettings replaces the settings of the Access application for a hostname,
+// keeping its policies
+func (c *Client) SetAppSettings(ctx context.Context, hostname string, settings AppSettings) error {
+	app, err := c.findAccessApplication(ctx, hostname)
+	if err != nil {
+		return err
+	}
+	if app == nil {
+		return fmt.Errorf("no Access application for %s", hostname)
+	}
+
+	dir := &directory{c: c}
+	fields, err := dir.settingsToAPI(ctx, settings)
+	if err != nil {
+		return err
+	}
+
+	// the update replaces the whole application, so unset fields fall back to defaults
+	_, err = c.api.UpdateAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.UpdateAccessApplicationParams{
+		ID:                     app.ID,
+		Name:                   app.Name,
+		Domain:                 app.Domain,
+		Type:                   app.Type,
+		SessionDuration:        fields.SessionDuration,
+		AllowedIdps:            fields.AllowedIdps,
+		AutoRedirectToIdentity: fields.AutoRedirectToIdentity,
+		CorsHeaders:            fields.CorsHeaders,
+		AppLauncherVisible:     fields.AppLauncherVisible,
+		CustomDenyMessage:      fields.CustomDenyMessage,
+		CustomDenyURL:          fields.CustomDenyURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update access application: %w", err)
+	}
+	return nil
+}
+
+// settingsToAPI returns an application with the fields for settings filled in,
+// resolving identity provider names to IDs
+func (d *directory) settingsToAPI(ctx context.Context, settings AppSettings) (cloudflare.AccessApplication, error) {
+	app := cloudflare.AccessApplication{
+		SessionDuration:   settings.SessionDuration,
+		CorsHeaders:       corsToAPI(settings.CORS),
+		CustomDenyMessage: settings.DenyMessage,
+		CustomDenyURL:     settings.DenyURL,
+	}
+	for _, name := range settings.IdentityProviders {
+		id, err := d.identityProviderID(ctx, name)
+		if err != nil {
+			return app, err
+		}
+		app.AllowedIdps = append(app.AllowedIdps, id)
+	}
+	if settings.AutoRedirect {
+		app.AutoRedirectToIdentity = cloudflare.BoolPtr(true)
+	}
+	if settings.HideFromLauncher {
+		app.AppLauncherVisible = cloudflare.BoolPtr(false)
+	}
+	return app, nil
+}
+
+// identityProviderID returns the ID of the named identity provider
+func (d *directory) identityProviderID(ctx context.Context, name string) (string, error) {
+	if d.idps == nil {
+		idps, err := d.c.listAccessIdentityProviders(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list identity providers: %w", err)
+		}
+		d.idps = make(map[string]string)
+		for _, idp := range idps {
+			d.idps[idp.Name] = idp.ID
+		}
+	}
+
+	id, ok := d.idps[name]
+	if !ok {
+		return "", fmt.Errorf("identity provider %q not found - check Zero Trust → Settings → Authentication", name)
+	}
+	return id, nil
+}
+
+// identityProviderNames maps identity provider IDs to names; on error IDs are shown as is
+func (c *Client) identityProviderNames(ctx context.Context) map[string]string {
+	names := make(map[string]string)
+	idps, err := c.listAccessIdentityProviders(ctx)
+	if err != nil {
+		return names
+	}
+	for _, idp := range idps {
+		names[idp.ID] = idp.Name
+	}
+	return names
+}
+
+// corsToAPI converts CORS settings, turning "*" entries into the allow-all flags
+func corsToAPI(c *CORS) *cloudflare.AccessApplicationCorsHeaders {
+	if c == nil {
+		return nil
+	}
+	headers := &cloudflare.AccessApplicationCorsHeaders{
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           c.MaxAge,
+	}
+	headers.AllowedOrigins, headers.AllowAllOrigins = splitWildcard(c.AllowedOrigins)
+	headers.AllowedMethods, headers.AllowAllMethods = splitWildcard(c.AllowedMethods)
+	headers.AllowedHeaders, headers.AllowAllHeaders = splitWildcard(c.AllowedHeaders)
+	return headers
+}
+
+// corsFromAPI converts CORS headers back, showing the allow-all flags as "*"
+func corsFromAPI(h *cloudflare.AccessApplicationCorsHeaders) *CORS {
+	if h == nil {
+		return nil
+	}
+	return &CORS{
+		AllowedOrigins:   joinWildcard(h.AllowedOrigins, h.AllowAllOrigins),
+		AllowedMethods:   joinWildcard(h.AllowedMethods, h.AllowAllMethods),
+		AllowedHeaders:   joinWildcard(h.AllowedHeaders, h.AllowAllHeaders),
+		AllowCredentials: h.AllowCredentials,
+		MaxAge:           h.MaxAge,
+	}
+}
+
+// splitWildcard separates a "*" entry from the explicit values
+func splitWildcard(values []string) ([]string, bool) {
+	var explicit []string
+	all := false
+	for _, value := range values {
+		if value == "*" {
+			all = true
+			continue
+		}
+		explicit = append(explicit, value)
+	}
+	if all {
+		return nil, true
+	}
+	return explicit, false
+}
+
+// joinWildcard is the inverse of splitWildcard
+func joinWildcard(values []string, all bool) []string {
+	if all {
+		return []string{"*"}
+	}
+	return values
+}
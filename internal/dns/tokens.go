@@ -0,0 +1,138 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// ErrServiceTokenNotFound is returned when no service token has the given name
+var ErrServiceTokenNotFound = errors.New("not found")
+
+// ServiceToken is a Cloudflare Access service token. ClientSecret is only set
+// right after the token is created or rotated - Cloudflare never shows it again.
+type ServiceToken struct {
+	ID           string
+	Name         string
+	ClientID     string
+	ClientSecret string
+	Duration     string    // lifetime Cloudflare renews it by, e.g. "8760h" or "forever"
+	ExpiresAt    time.Time // zero if it never expires
+}
+
+// CreateServiceToken creates a service token valid for duration (e.g. "8760h", or "forever")
+func (c *Client) CreateServiceToken(ctx context.Context, name, duration string) (*ServiceToken, error) {
+	_, err := c.findServiceToken(ctx, name)
+	if err == nil {
+		return nil, fmt.Errorf("service token %q already exists - rotate it with `orb access token rotate %s`", name, name)
+	}
+	if !errors.Is(err, ErrServiceTokenNotFound) {
+		return nil, err
+	}
+
+	created, err := c.api.CreateAccessServiceToken(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.CreateAccessServiceTokenParams{
+		Name:     name,
+		Duration: duration,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service token: %w", err)
+	}
+
+	return &ServiceToken{
+		ID:           created.ID,
+		Name:         created.Name,
+		ClientID:     created.ClientID,
+		ClientSecret: created.ClientSecret,
+		Duration:     created.Duration,
+		ExpiresAt:    timeOrZero(created.ExpiresAt),
+	}, nil
+}
+
+// ListServiceTokens returns all service tokens in the account, without secrets
+func (c *Client) ListServiceTokens(ctx context.Context) ([]ServiceToken, error) {
+	tokens, err := c.listAccessServiceTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service tokens: %w", err)
+	}
+
+	result := make([]ServiceToken, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, ServiceToken{
+			ID:        token.ID,
+			Name:      token.Name,
+			ClientID:  token.ClientID,
+			Duration:  token.Duration,
+			ExpiresAt: timeOrZero(token.ExpiresAt),
+		})
+	}
+	return result, nil
+}
+
+// RotateServiceToken generates a new client secret for a service token and renews
+// its expiry by its duration. The old secret stops working immediately.
+func (c *Client) RotateServiceToken(ctx context.Context, name string) (*ServiceToken, error) {
+	token, err := c.findServiceToken(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := c.api.RotateAccessServiceToken(ctx, cloudflare.AccountIdentifier(c.accountID), token.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate service token: %w", err)
+	}
+
+	result := &ServiceToken{
+		ID:           rotated.ID,
+		Name:         rotated.Name,
+		ClientID:     rotated.ClientID,
+		ClientSecret: rotated.ClientSecret,
+		Duration:     rotated.Duration,
+		ExpiresAt:    timeOrZero(rotated.ExpiresAt),
+	}
+
+	// the secret is already rotated, so a failed renewal only leaves the old expiry
+	refreshed, err := c.api.RefreshAccessServiceToken(ctx, cloudflare.AccountIdentifier(c.accountID), token.ID)
+	if err != nil {
+		return result, fmt.Errorf("rotated the secret but failed to renew the expiry: %w", err)
+	}
+	result.ExpiresAt = timeOrZero(refreshed.ExpiresAt)
+	return result, nil
+}
+
+// DeleteServiceToken deletes a service token by name, returning its ID
+func (c *Client) DeleteServiceToken(ctx context.Context, name string) (string, error) {
+	token, err := c.findServiceToken(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := c.api.DeleteAccessServiceToken(ctx, cloudflare.AccountIdentifier(c.accountID), token.ID); err != nil {
+		return "", fmt.Errorf("failed to delete service token: %w", err)
+	}
+	return token.ID, nil
+}
+
+// findServiceToken returns the service token with the given name
+func (c *Client) findServiceToken(ctx context.Context, name string) (*cloudflare.AccessServiceToken, error) {
+	tokens, err := c.listAccessServiceTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service tokens: %w", err)
+	}
+	for i := range tokens {
+		if tokens[i].Name == name {
+			return &tokens[i], nil
+		}
+	}
+	return nil, fmt.Errorf("service token %q %w", name, ErrServiceTokenNotFound)
+}
+
+// timeOrZero dereferences an optional API timestamp
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
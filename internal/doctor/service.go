@@ -77,8 +77,8 @@ func (s *Service) checkEnvVariables() {
 	}
 
 	// Check optional but recommended
-	if os.Getenv("USER_EMAIL") == "" {
-		s.addCheck("USER_EMAIL (optional)", "warn", "Not set - required for private access level")
+	if os.Getenv("OWNER_EMAIL") == "" && os.Getenv("USER_EMAIL") == "" && os.Getenv("OWNER_GROUP") == "" {
+		s.addCheck("OWNER_EMAIL (optional)", "warn", "Not set (nor USER_EMAIL or OWNER_GROUP) - required for private access level")
 	}
 }
 
@@ -0,0 +1,146 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"orb/internal/scheduler"
+	"orb/internal/tunnel"
+)
+
+// collectServices reports the exposed services by access kind and the result of probing each
+func (e *Exporter) collectServices(ctx context.Context, m *metrics) error {
+	states, err := e.tunnel.ServiceStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	// every kind is reported, so alerts like "a public service appeared" see a 0 → 1 change
+	counts := make(map[string]int)
+	for _, state := range states {
+		counts[state.Access]++
+	}
+	m.family("orb_services", "gauge", "Number of exposed services by access kind.")
+	for _, kind := range tunnel.AccessKinds {
+		m.sample("orb_services", float64(counts[kind]), "access", kind)
+	}
+
+	m.family("orb_service_info", "gauge", "Exposed service with its target and access kind (always 1).")
+	for _, state := range states {
+		m.sample("orb_service_info", 1, "hostname", state.Hostname, "target", state.Target, "access", state.Access)
+	}
+	m.family("orb_service_up", "gauge", "Whether the last HTTPS probe of the service returned 2xx or 3xx.")
+	for _, state := range states {
+		m.sample("orb_service_up", boolValue(state.Healthy), "hostname", state.Hostname)
+	}
+	m.family("orb_service_probe_status_code", "gauge", "HTTP status code of the last probe (0 if the request failed).")
+	for _, state := range states {
+		m.sample("orb_service_probe_status_code", float64(state.StatusCode), "hostname", state.Hostname)
+	}
+	m.family("orb_service_probe_duration_seconds", "gauge", "How long the last probe of the service took.")
+	for _, state := range states {
+		m.sample("orb_service_probe_duration_seconds", state.Latency.Seconds(), "hostname", state.Hostname)
+	}
+	return nil
+}
+
+// collectExpiries reports the pending expiries and how long until each falls due
+func (e *Exporter) collectExpiries(ctx context.Context, m *metrics) error {
+	expiries, err := e.tunnel.PendingExpiries()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	m.family("orb_expiries", "gauge", "Number of pending expiries.")
+	m.sample("orb_expiries", float64(len(expiries)))
+	m.family("orb_expiry_seconds", "gauge", "Seconds until a pending expiry falls due (negative if overdue).")
+	for _, expiry := range expiries {
+		m.sample("orb_expiry_seconds", expiry.At.Sub(now).Seconds(), expiryLabels(expiry)...)
+	}
+	m.family("orb_expiry_timestamp_seconds", "gauge", "When a pending expiry falls due.")
+	for _, expiry := range expiries {
+		m.sample("orb_expiry_timestamp_seconds", unixSeconds(expiry.At), expiryLabels(expiry)...)
+	}
+	return nil
+}
+
+// expiryLabels identifies an expiry: its subdomain, kind and the group or email it lapses for
+func expiryLabels(expiry tunnel.Expiry) []string {
+	return []string{"subdomain", expiry.Subdomain, "kind", expiry.Kind, "target", expiry.Target}
+}
+
+// collectDatabases reports the managed databases and whether their containers are running
+func (e *Exporter) collectDatabases(ctx context.Context, m *metrics) error {
+	if e.databases == nil {
+		return nil
+	}
+	statuses, err := e.databases.Statuses()
+	if err != nil {
+		return err
+	}
+
+	m.family("orb_database_running", "gauge", "Whether the container of a managed database is running.")
+	for _, db := range statuses {
+		m.sample("orb_database_running", boolValue(db.Status == "running"), "name", db.Name, "type", db.Type, "port", db.Port)
+	}
+	m.family("orb_database_info", "gauge", "Managed database with its container state (always 1).")
+	for _, db := range statuses {
+		m.sample("orb_database_info", 1, "name", db.Name, "type", db.Type, "port", db.Port, "status", db.Status)
+	}
+	return nil
+}
+
+// collectSchedules reports the scheduled tasks and the result of their last recorded run
+func (e *Exporter) collectSchedules(ctx context.Context, m *metrics) error {
+	if e.schedules == nil {
+		return nil
+	}
+	schedules, err := e.schedules.Schedules()
+	if err != nil {
+		return err
+	}
+
+	m.family("orb_schedule_info", "gauge", "Scheduled task with its cron expression (always 1).")
+	for _, sched := range schedules {
+		m.sample("orb_schedule_info", 1, "name", sched.Name, "cron", sched.Cron)
+	}
+
+	type lastRun struct {
+		name string
+		run  *scheduler.Run
+	}
+	var runs []lastRun
+	for _, sched := range schedules {
+		run, err := e.schedules.LastRun(sched.Name)
+		if err != nil {
+			return err
+		}
+		if run != nil {
+			runs = append(runs, lastRun{sched.Name, run})
+		}
+	}
+
+	// schedules that have not run through `orb schedule run` yet have no samples below
+	m.family("orb_schedule_last_run_timestamp_seconds", "gauge", "When the last run of a scheduled task started.")
+	for _, r := range runs {
+		m.sample("orb_schedule_last_run_timestamp_seconds", unixSeconds(r.run.StartedAt), "name", r.name)
+	}
+	m.family("orb_schedule_last_run_duration_seconds", "gauge", "How long the last run of a scheduled task took.")
+	for _, r := range runs {
+		m.sample("orb_schedule_last_run_duration_seconds", r.run.Duration.Seconds(), "name", r.name)
+	}
+	m.family("orb_schedule_last_exit_code", "gauge", "Exit code of the last run of a scheduled task.")
+	for _, r := range runs {
+		m.sample("orb_schedule_last_exit_code", float64(r.run.ExitCode), "name", r.name)
+	}
+	m.family("orb_schedule_last_success_timestamp_seconds", "gauge", "When the last successful run of a scheduled task started (0 if none).")
+	for _, r := range runs {
+		var success float64
+		if !r.run.LastSuccess.IsZero() {
+			success = unixSeconds(r.run.LastSuccess)
+		}
+		m.sample("orb_schedule_last_success_timestamp_seconds", success, "name", r.name)
+	}
+	return nil
+}
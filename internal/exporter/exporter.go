@@ -0,0 +1,171 @@
+// Package exporter serves the state orb manages as Prometheus metrics: exposed
+// services and their access, health probes, pending expiries, managed databases
+// and the last runs of scheduled tasks.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"orb/internal/database"
+	"orb/internal/scheduler"
+	"orb/internal/tunnel"
+)
+
+// DefaultListenAddress is where `orb exporter` listens unless --listen is set
+const DefaultListenAddress = ":9469"
+
+// contentType is the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Exporter collects metrics every interval and serves the latest collection,
+// so scrapes are cheap and do not probe services or call Cloudflare themselves
+type Exporter struct {
+	tunnel    *tunnel.Service
+	databases *database.Service
+	schedules *scheduler.Service
+	interval  time.Duration
+
+	mu   sync.RWMutex
+	body []byte
+}
+
+// New creates an exporter refreshing its metrics every interval.
+// The database and scheduler services are optional (nil skips their metrics).
+func New(tunnelSvc *tunnel.Service, databases *database.Service, schedules *scheduler.Service, interval time.Duration) (*Exporter, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("exporter interval must be positive")
+	}
+	return &Exporter{
+		tunnel:    tunnelSvc,
+		databases: databases,
+		schedules: schedules,
+		interval:  interval,
+	}, nil
+}
+
+// Run serves /metrics on address until ctx is cancelled, refreshing the metrics in the background
+func (e *Exporter) Run(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `<html><head><title>orb exporter</title></head><body><h1>orb exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	// collect once before serving so the first scrape has data
+	e.Refresh(ctx)
+	go e.refreshLoop(ctx)
+
+	fmt.Printf("✔ orb exporter listening on %s/metrics (refreshing every %s, Ctrl-C to stop)\n", listener.Addr(), e.interval)
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("exporter stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to stop exporter: %w", err)
+	}
+	fmt.Println("✔ orb exporter stopped")
+	return nil
+}
+
+// ServeHTTP serves the latest collection
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	body := e.body
+	e.mu.RUnlock()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// refreshLoop refreshes the metrics every interval until ctx is cancelled
+func (e *Exporter) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh runs every collector and replaces the served metrics. A failing
+// collector is reported by orb_collector_success instead of failing the rest.
+func (e *Exporter) Refresh(ctx context.Context) {
+	// a refresh should not outlive the next one
+	ctx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+
+	collectors := []struct {
+		name    string
+		collect func(context.Context, *metrics) error
+	}{
+		{"services", e.collectServices},
+		{"expiries", e.collectExpiries},
+		{"databases", e.collectDatabases},
+		{"schedules", e.collectSchedules},
+	}
+
+	m := &metrics{}
+	success := make(map[string]bool)
+	durations := make(map[string]time.Duration)
+	for _, c := range collectors {
+		// a failed collector's partial metrics are dropped
+		collected := &metrics{}
+		start := time.Now()
+		err := c.collect(ctx, collected)
+		durations[c.name] = time.Since(start)
+		success[c.name] = err == nil
+		if err != nil {
+			fmt.Printf("⚠ Warning: failed to collect %s: %v\n", c.name, err)
+			continue
+		}
+		m.b.WriteString(collected.b.String())
+	}
+
+	m.family("orb_collector_success", "gauge", "Whether the last collection of a collector succeeded.")
+	for _, c := range collectors {
+		m.sample("orb_collector_success", boolValue(success[c.name]), "collector", c.name)
+	}
+	m.family("orb_collector_duration_seconds", "gauge", "How long the last collection of a collector took.")
+	for _, c := range collectors {
+		m.sample("orb_collector_duration_seconds", durations[c.name].Seconds(), "collector", c.name)
+	}
+	m.family("orb_last_refresh_timestamp_seconds", "gauge", "When the exporter last refreshed its metrics.")
+	m.sample("orb_last_refresh_timestamp_seconds", unixSeconds(time.Now()))
+
+	e.mu.Lock()
+	e.body = m.bytes()
+	e.mu.Unlock()
+}
+
+// unixSeconds renders a time as a Unix timestamp in seconds
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
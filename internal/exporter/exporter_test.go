@@ -0,0 +1,202 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"orb/internal/dns/dnstest"
+	"orb/internal/runner/runnertest"
+	"orb/internal/tunnel"
+)
+
+// statusTransport answers every probe with the same status code
+type statusTransport int
+
+func (code statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: int(code), Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+// testExporter returns an exporter over a tunnel config exposing web.example.com,
+// and the path of its expiry store
+func testExporter(t *testing.T) (*Exporter, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yml")
+	config := "tunnel: tid\ningress:\n  - hostname: web.example.com\n    service: http://localhost:8080\n  - service: http_status:404\n"
+	if err := os.WriteFile(cfgPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expiriesPath := filepath.Join(dir, "expiries.json")
+	svc := tunnel.NewServiceWith(&tunnel.Environment{Domain: "example.com", ConfigPath: cfgPath}, tunnel.Deps{
+		Cloudflare: dnstest.New(),
+		Runner:     runnertest.New(),
+		HTTP:       &http.Client{Transport: statusTransport(http.StatusOK)},
+		Expiries:   tunnel.NewExpiryStore(expiriesPath),
+	}, tunnel.Options{})
+
+	e, err := New(svc, nil, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, expiriesPath
+}
+
+// scrape refreshes the exporter and returns the body served at /metrics
+func scrape(t *testing.T, e *Exporter) string {
+	t.Helper()
+	e.Refresh(context.Background())
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != contentType {
+		t.Errorf("Content-Type = %q, want %q", got, contentType)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// families checks that every sample follows the HELP and TYPE lines of its own
+// family, and that no family appears twice. It returns the families in order.
+func families(t *testing.T, body string) []string {
+	t.Helper()
+	var names []string
+	var current string
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if name, ok := strings.CutPrefix(line, "# HELP "); ok {
+			name, _, _ = strings.Cut(name, " ")
+			if i+1 == len(lines) || !strings.HasPrefix(lines[i+1], "# TYPE "+name+" ") {
+				t.Fatalf("HELP of %s is not followed by its TYPE", name)
+			}
+			if slices.Contains(names, name) {
+				t.Fatalf("family %s appears twice", name)
+			}
+			names = append(names, name)
+			current = name
+			i++
+			continue
+		}
+		name := strings.FieldsFunc(line, func(r rune) bool { return r == '{' || r == ' ' })[0]
+		if name != current {
+			t.Fatalf("sample %q is outside the %s family", line, name)
+		}
+	}
+	return names
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	e, expiriesPath := testExporter(t)
+	at := time.Now().Add(time.Hour)
+	if err := tunnel.NewExpiryStore(expiriesPath).Set(tunnel.Expiry{Kind: tunnel.ExpiryShare, Subdomain: "web", Target: "a\"b\\c\nd", At: at}); err != nil {
+		t.Fatal(err)
+	}
+
+	body := scrape(t, e)
+	want := []string{
+		"orb_services",
+		"orb_service_info",
+		"orb_service_up",
+		"orb_service_probe_status_code",
+		"orb_service_probe_duration_seconds",
+		"orb_expiries",
+		"orb_expiry_seconds",
+		"orb_expiry_timestamp_seconds",
+		"orb_collector_success",
+		"orb_collector_duration_seconds",
+		"orb_last_refresh_timestamp_seconds",
+	}
+	if got := families(t, body); !slices.Equal(got, want) {
+		t.Errorf("families =\n%v\nwant\n%v", got, want)
+	}
+
+	for _, line := range []string{
+		`orb_services{access="public"} 1`,
+		`orb_services{access="private"} 0`,
+		`orb_service_info{hostname="web.example.com",target="http://localhost:8080",access="public"} 1`,
+		`orb_service_up{hostname="web.example.com"} 1`,
+		`orb_service_probe_status_code{hostname="web.example.com"} 200`,
+		`orb_expiries 1`,
+		`orb_expiry_timestamp_seconds{subdomain="web",kind="share",target="a\"b\\c\nd"} ` + formatValue(unixSeconds(at)),
+		`orb_collector_success{collector="services"} 1`,
+		`orb_collector_success{collector="expiries"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %s in\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsEndpointCollectorFailure(t *testing.T) {
+	e, expiriesPath := testExporter(t)
+	if err := os.WriteFile(expiriesPath, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	body := scrape(t, e)
+	families(t, body)
+	if strings.Contains(body, "orb_expir") {
+		t.Errorf("failed collector's metrics were served:\n%s", body)
+	}
+	for _, line := range []string{
+		`orb_collector_success{collector="expiries"} 0`,
+		`orb_collector_success{collector="services"} 1`,
+		`orb_service_up{hostname="web.example.com"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %s in\n%s", line, body)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  float64
+		labels []string
+		want   string
+	}{
+		{"no labels", 3, nil, "m 3\n"},
+		{"fraction", 0.25, []string{"a", "b"}, "m{a=\"b\"} 0.25\n"},
+		{"large", 1.7e9, nil, "m 1700000000\n"},
+		{"escaped labels", 1, []string{"a", `q"b\s` + "\n", "c", "d"}, "m{a=\"q\\\"b\\\\s\\n\",c=\"d\"} 1\n"},
+		{"NaN", math.NaN(), nil, "m NaN\n"},
+		{"+Inf", math.Inf(1), nil, "m +Inf\n"},
+		{"-Inf", math.Inf(-1), nil, "m -Inf\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &metrics{}
+			m.sample("m", tt.value, tt.labels...)
+			if got := string(m.bytes()); got != tt.want {
+				t.Errorf("sample = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFamilyEscapesHelp(t *testing.T) {
+	m := &metrics{}
+	m.family("m", "gauge", "Back\\slash and\nnewline.")
+	if got, want := string(m.bytes()), "# HELP m Back\\\\slash and\\nnewline.\n# TYPE m gauge\n"; got != want {
+		t.Errorf("family = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// metrics writes samples in the Prometheus text exposition format (version 0.0.4)
+type metrics struct {
+	b strings.Builder
+}
+
+// family starts a metric family with its HELP and TYPE lines; its samples must follow
+func (m *metrics) family(name, typ, help string) {
+	fmt.Fprintf(&m.b, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(&m.b, "# TYPE %s %s\n", name, typ)
+}
+
+// sample writes one sample; labels are name/value pairs
+func (m *metrics) sample(name string, value float64, labels ...string) {
+	m.b.WriteString(name)
+	if len(labels) > 0 {
+		m.b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.b.WriteByte(',')
+			}
+			fmt.Fprintf(&m.b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		m.b.WriteByte('}')
+	}
+	m.b.WriteByte(' ')
+	m.b.WriteString(formatValue(value))
+	m.b.WriteByte('\n')
+}
+
+// bytes returns everything written so far
+func (m *metrics) bytes() []byte {
+	return []byte(m.b.String())
+}
+
+// escapeLabel escapes a label value: backslash, double quote and newline
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatValue renders a sample value the way Prometheus parses it
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// boolValue renders a condition as 1 or 0
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
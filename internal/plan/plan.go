@@ -0,0 +1,160 @@
+// Package plan renders the changes a mutating command would make when run with --dry-run
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Change markers used at the start of plan lines
+const (
+	Create = "+"
+	Delete = "-"
+	Modify = "~"
+)
+
+// section is a titled group of planned changes
+type section struct {
+	title string
+	lines []string
+}
+
+// Plan collects planned changes grouped by the system they touch
+type Plan struct {
+	sections []*section
+}
+
+// New creates an empty plan
+func New() *Plan {
+	return &Plan{}
+}
+
+// Add records a change under a section title (e.g. "DNS", "Access")
+func (p *Plan) Add(title, marker, format string, args ...any) {
+	p.section(title).lines = append(p.section(title).lines, marker+" "+fmt.Sprintf(format, args...))
+}
+
+// Note records an informational line under a section title
+func (p *Plan) Note(title, format string, args ...any) {
+	p.section(title).lines = append(p.section(title).lines, "  "+fmt.Sprintf(format, args...))
+}
+
+// Diff records a line diff between two versions of a file under a section title
+func (p *Plan) Diff(title, before, after string) {
+	lines := Diff(before, after)
+	if len(lines) == 0 {
+		p.section(title).lines = append(p.section(title).lines, "  (no changes)")
+		return
+	}
+	p.section(title).lines = append(p.section(title).lines, lines...)
+}
+
+// section returns the section with the given title, creating it if needed
+func (p *Plan) section(title string) *section {
+	for _, s := range p.sections {
+		if s.title == title {
+			return s
+		}
+	}
+	s := &section{title: title}
+	p.sections = append(p.sections, s)
+	return s
+}
+
+// Print writes the plan to stdout
+func (p *Plan) Print() {
+	fmt.Println("Dry run - no changes will be made")
+	for _, s := range p.sections {
+		fmt.Printf("\n%s:\n", s.title)
+		for _, line := range s.lines {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 2
+
+// Diff returns a line-based diff of before and after, with "-" for removed lines,
+// "+" for added lines and a few unchanged lines of context around each change
+func Diff(before, after string) []string {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		marker string
+		line   string
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{" ", a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			// removed lines come before the lines replacing them
+			ops = append(ops, op{"-", a[i]})
+			i++
+		default:
+			ops = append(ops, op{"+", b[j]})
+			j++
+		}
+	}
+
+	// keep changed lines plus surrounding context
+	keep := make([]bool, len(ops))
+	changed := false
+	for k, o := range ops {
+		if o.marker == " " {
+			continue
+		}
+		changed = true
+		for c := max(0, k-diffContext); c <= min(len(ops)-1, k+diffContext); c++ {
+			keep[c] = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	var out []string
+	skipped := false
+	for k, o := range ops {
+		if !keep[k] {
+			skipped = true
+			continue
+		}
+		if skipped && len(out) > 0 {
+			out = append(out, "  ...")
+		}
+		skipped = false
+		out = append(out, o.marker+" "+o.line)
+	}
+	return out
+}
+
+// splitLines splits text into lines, ignoring a trailing newline
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,98 @@
+package plan
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numbered returns the lines "1" to "n" as file content, with some lines replaced
+func numbered(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replace[i]
+		if !ok {
+			line = strconv.Itoa(i)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []string
+	}{
+		{
+			name: "both empty",
+		},
+		{
+			name:   "identical",
+			before: "a\nb\nc\n",
+			after:  "a\nb\nc\n",
+		},
+		{
+			name:   "trailing newline only",
+			before: "a\nb",
+			after:  "a\nb\n",
+		},
+		{
+			name:  "empty before",
+			after: "a\nb\n",
+			want:  []string{"+ a", "+ b"},
+		},
+		{
+			name:   "empty after",
+			before: "a\nb\n",
+			want:   []string{"- a", "- b"},
+		},
+		{
+			name:   "insert",
+			before: "a\nb\nc\n",
+			after:  "a\nb\nnew\nc\n",
+			want:   []string{"  a", "  b", "+ new", "  c"},
+		},
+		{
+			name:   "append",
+			before: "a\nb\n",
+			after:  "a\nb\nc\n",
+			want:   []string{"  a", "  b", "+ c"},
+		},
+		{
+			name:   "delete",
+			before: "a\nb\nc\n",
+			after:  "a\nc\n",
+			want:   []string{"  a", "- b", "  c"},
+		},
+		{
+			name:   "replace",
+			before: "a\nb\nc\n",
+			after:  "a\nB\nc\n",
+			want:   []string{"  a", "- b", "+ B", "  c"},
+		},
+		{
+			name:   "distant changes are separated",
+			before: numbered(10, nil),
+			after:  numbered(10, map[int]string{2: "two", 9: "nine"}),
+			want:   []string{"  1", "- 2", "+ two", "  3", "  4", "  ...", "  7", "  8", "- 9", "+ nine", "  10"},
+		},
+		{
+			name:   "context is trimmed at the start",
+			before: numbered(10, nil),
+			after:  numbered(10, map[int]string{10: "ten"}),
+			want:   []string{"  8", "  9", "- 10", "+ ten"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !slices.Equal(got, tt.want) {
+				t.Errorf("Diff() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Runner executes external commands (systemctl, journalctl, systemd-run, ...)
+// so the services that shell out can be exercised without touching the host
+type Runner interface {
+	// Output runs a command and returns its combined stdout and stderr
+	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+	// Attach runs a command connected to the terminal's stdin, stdout and stderr
+	Attach(ctx context.Context, name string, args ...string) error
+}
+
+// Exec runs commands on the local host
+type Exec struct{}
+
+// Output runs a command and returns its combined stdout and stderr
+func (Exec) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// Attach runs a command connected to the terminal's stdin, stdout and stderr
+func (Exec) Attach(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// ExitCode returns the exit code carried by err, or -1 if err is not an exit error
+func ExitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
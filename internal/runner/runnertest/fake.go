@@ -0,0 +1,113 @@
+// Package runnertest provides a fake runner.Runner that records commands instead of executing them
+package runnertest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"orb/internal/runner"
+)
+
+// Call is a single recorded command invocation
+type Call struct {
+	Name string
+	Args []string
+}
+
+// String renders the call as a shell-like command line
+func (c Call) String() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.Args, " "))
+}
+
+// Result is the canned outcome of a command
+type Result struct {
+	Output []byte
+	Err    error
+}
+
+// ExitError is a fake process exit status usable as a Result error
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string { return fmt.Sprintf("exit status %d", e.Code) }
+
+// ExitCode returns the fake exit code
+func (e *ExitError) ExitCode() int { return e.Code }
+
+// Fake records every command and answers with canned results.
+// Commands without a registered result succeed with no output.
+type Fake struct {
+	mu      sync.Mutex
+	calls   []Call
+	results map[string]Result
+}
+
+var _ runner.Runner = (*Fake)(nil)
+
+// New creates an empty fake runner
+func New() *Fake {
+	return &Fake{results: make(map[string]Result)}
+}
+
+// On registers the result returned when a command line starts with prefix (e.g. "sudo systemctl restart")
+func (f *Fake) On(prefix string, result Result) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[prefix] = result
+}
+
+// Calls returns all recorded invocations in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Ran reports whether any recorded command line starts with prefix
+func (f *Fake) Ran(prefix string) bool {
+	for _, call := range f.Calls() {
+		if strings.HasPrefix(call.String(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Output records the command and returns its canned result
+func (f *Fake) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res := f.record(name, args)
+	return res.Output, res.Err
+}
+
+// Attach records the command and returns its canned error
+func (f *Fake) Attach(ctx context.Context, name string, args ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.record(name, args).Err
+}
+
+// record stores the call and looks up the longest matching registered prefix
+func (f *Fake) record(name string, args []string) Result {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	call := Call{Name: name, Args: append([]string(nil), args...)}
+	f.calls = append(f.calls, call)
+
+	line := call.String()
+	var best string
+	var res Result
+	for prefix, r := range f.results {
+		if strings.HasPrefix(line, prefix) && len(prefix) >= len(best) {
+			best, res = prefix, r
+		}
+	}
+	return res
+}
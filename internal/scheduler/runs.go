@@ -0,0 +1,229 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/plan"
+)
+
+// Run is the result of the last run of a schedule, recorded by `orb schedule run`
+type Run struct {
+	StartedAt   time.Time     `json:"started_at"`
+	Duration    time.Duration `json:"duration"`
+	ExitCode    int           `json:"exit_code"`
+	LastSuccess time.Time     `json:"last_success,omitempty"` // zero if it never succeeded
+}
+
+// Succeeded reports whether the run exited with status 0
+func (r Run) Succeeded() bool {
+	return r.ExitCode == 0
+}
+
+// Schedules returns every schedule by name, as currently saved (cron may have
+// changed them since the service was created)
+func (s *Service) Schedules() ([]Schedule, error) {
+	s.schedules = make(map[string]Schedule)
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	schedules := make([]Schedule, 0, len(s.schedules))
+	for _, sched := range s.schedules {
+		schedules = append(schedules, sched)
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].Name < schedules[j].Name })
+	return schedules, nil
+}
+
+// LastRun returns the last recorded run of a schedule, or nil if it has not run
+// through `orb schedule run` yet
+func (s *Service) LastRun(name string) (*Run, error) {
+	data, err := os.ReadFile(s.runPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last run of %q: %w", name, err)
+	}
+
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse last run of %q: %w", name, err)
+	}
+	return &run, nil
+}
+
+// Run runs a schedule's command through sh, as cron would, and records its
+// result. It returns the command's exit status, which the caller exits with.
+func (s *Service) Run(name string) (int, error) {
+	sched, exists := s.schedules[name]
+	if !exists {
+		return 1, fmt.Errorf("schedule %q not found", name)
+	}
+
+	cmd := exec.Command("sh", "-c", sched.Command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	started := time.Now()
+	err := cmd.Run()
+	run := Run{StartedAt: started, Duration: time.Since(started)}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		run.ExitCode = exitErr.ExitCode()
+		if run.ExitCode < 0 {
+			run.ExitCode = 128 // killed by a signal
+		}
+	default:
+		return 1, fmt.Errorf("failed to run schedule %q: %w", name, err)
+	}
+
+	if run.Succeeded() {
+		run.LastSuccess = started
+	} else if previous, _ := s.LastRun(name); previous != nil {
+		run.LastSuccess = previous.LastSuccess
+	}
+	if err := s.saveRun(name, run); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: %v\n", err)
+	}
+	return run.ExitCode, nil
+}
+
+// lastRunOrNil returns the last run of a schedule, treating an unreadable record as none
+func (s *Service) lastRunOrNil(name string) *Run {
+	run, _ := s.LastRun(name)
+	return run
+}
+
+// saveRun records the last run of a schedule
+func (s *Service) saveRun(name string, run Run) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run of %q: %w", name, err)
+	}
+	if err := os.MkdirAll(s.runsDir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.runsDir, err)
+	}
+	if err := os.WriteFile(s.runPath(name), data, 0600); err != nil {
+		return fmt.Errorf("failed to record run of %q: %w", name, err)
+	}
+	return nil
+}
+
+// deleteRun forgets the last run of a removed schedule
+func (s *Service) deleteRun(name string) {
+	os.Remove(s.runPath(name))
+}
+
+// runPath is where the last run of a schedule is recorded
+func (s *Service) runPath(name string) string {
+	return filepath.Join(s.runsDir, name+".json")
+}
+
+// orbOnPath returns the orb that cron entries run: the one found on PATH as it
+// was installed (e.g. /usr/local/bin/orb), not the resolved path of this
+// executable, which may be a `go run` build or a versioned directory that a
+// reinstall replaces
+func orbOnPath() (string, error) {
+	path, err := exec.LookPath("orb")
+	if err != nil {
+		return "", fmt.Errorf("orb is not on PATH (cron entries run it by name): %w", err)
+	}
+	return path, nil
+}
+
+// runEntry returns the crontab command that runs a schedule through orb
+func runEntry(orb, name string) string {
+	return fmt.Sprintf("'%s' schedule run %s", strings.ReplaceAll(orb, "'", `'\''`), name)
+}
+
+// Migrate rewrites the crontab entries of schedules added before `orb schedule
+// run` existed, which run their command directly, so that their runs are
+// recorded too. Entries already running through orb are left alone.
+func (s *Service) Migrate() error {
+	current := readCrontab()
+	if names := legacyEntries(current, s.schedules); len(names) == 0 {
+		fmt.Println("✓ Every schedule already runs through `orb schedule run`")
+		return nil
+	}
+
+	orb, err := orbOnPath()
+	if err != nil {
+		return err
+	}
+	migrated, names := crontabWithRunEntries(current, s.schedules, orb)
+
+	if s.dryRun {
+		p := plan.New()
+		p.Diff("Crontab", current, migrated)
+		p.Print()
+		return nil
+	}
+
+	if err := writeCrontab(migrated); err != nil {
+		return fmt.Errorf("failed to update crontab: %w", err)
+	}
+	for _, name := range names {
+		s.record(audit.Entry{
+			Action:  "schedule.migrate",
+			Subject: name,
+			After:   audit.Fields("runner", "orb schedule run"),
+		})
+	}
+	fmt.Printf("✓ Schedules %s now run through `orb schedule run`, which records their runs\n", strings.Join(names, ", "))
+	return nil
+}
+
+// legacyEntries returns the schedules whose crontab entry runs their command
+// directly instead of through `orb schedule run`
+func legacyEntries(crontab string, schedules map[string]Schedule) []string {
+	_, names := crontabWithRunEntries(crontab, schedules, "orb")
+	return names
+}
+
+// crontabWithRunEntries returns crontab with every orb entry that runs its
+// command directly replaced by one running it through `orb schedule run` with
+// the given orb, and the names of the schedules it changed
+func crontabWithRunEntries(crontab string, schedules map[string]Schedule, orb string) (string, []string) {
+	lines := strings.Split(crontab, "\n")
+	var names []string
+	for i := 0; i+1 < len(lines); i++ {
+		name, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "# orb-schedule: ")
+		if !ok {
+			continue
+		}
+		sched, exists := schedules[name]
+		// names with characters cron or the run entry cannot take predate the checks in Add
+		if !exists || strings.ContainsAny(name, "/%") || strings.HasSuffix(lines[i+1], " schedule run "+name) {
+			continue
+		}
+		lines[i+1] = sched.Cron + " " + runEntry(orb, name)
+		names = append(names, name)
+	}
+	return strings.Join(lines, "\n"), names
+}
+
+// describeRun renders the last run of a schedule for `schedule list`
+func describeRun(run *Run) string {
+	if run == nil {
+		return "-"
+	}
+	when := run.StartedAt.Format("2006-01-02 15:04")
+	if run.Succeeded() {
+		return "✓ " + when
+	}
+	return fmt.Sprintf("✗ %s (exit %d)", when, run.ExitCode)
+}
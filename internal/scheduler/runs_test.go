@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCrontabWithRunEntries(t *testing.T) {
+	schedules := map[string]Schedule{
+		"backup": {Name: "backup", Cron: "0 2 * * *", Command: "./backup.sh"},
+		"sync":   {Name: "sync", Cron: "*/30 * * * *", Command: "sync.py"},
+	}
+	tests := []struct {
+		name    string
+		crontab string
+		want    string
+		changed []string
+	}{
+		{
+			name:    "direct entry",
+			crontab: "# orb-schedule: backup\n0 2 * * * ./backup.sh\n",
+			want:    "# orb-schedule: backup\n0 2 * * * '/usr/local/bin/orb' schedule run backup\n",
+			changed: []string{"backup"},
+		},
+		{
+			name:    "already migrated",
+			crontab: "# orb-schedule: sync\n*/30 * * * * '/opt/orb' schedule run sync\n",
+			want:    "# orb-schedule: sync\n*/30 * * * * '/opt/orb' schedule run sync\n",
+		},
+		{
+			name:    "unknown schedule and other entries",
+			crontab: "MAILTO=me\n# orb-schedule: gone\n0 * * * * old.sh\n5 4 * * * mine.sh\n",
+			want:    "MAILTO=me\n# orb-schedule: gone\n0 * * * * old.sh\n5 4 * * * mine.sh\n",
+		},
+		{
+			name:    "mixed",
+			crontab: "# orb-schedule: backup\n0 2 * * * ./backup.sh\n# orb-schedule: sync\n*/30 * * * * sync.py\n",
+			want:    "# orb-schedule: backup\n0 2 * * * '/usr/local/bin/orb' schedule run backup\n# orb-schedule: sync\n*/30 * * * * '/usr/local/bin/orb' schedule run sync\n",
+			changed: []string{"backup", "sync"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := crontabWithRunEntries(tt.crontab, schedules, "/usr/local/bin/orb")
+			if got != tt.want {
+				t.Errorf("crontab =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !slices.Equal(changed, tt.changed) {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			if legacy := legacyEntries(tt.crontab, schedules); !slices.Equal(legacy, tt.changed) {
+				t.Errorf("legacyEntries = %v, want %v", legacy, tt.changed)
+			}
+		})
+	}
+}
+
+func TestRunEntryQuotesPath(t *testing.T) {
+	if got, want := runEntry("/home/o'neil/bin/orb", "backup"), `'/home/o'\''neil/bin/orb' schedule run backup`; got != want {
+		t.Errorf("runEntry = %s, want %s", got, want)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"orb/internal/audit"
+	"orb/internal/plan"
+
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -23,11 +26,15 @@ type Schedule struct {
 // Service manages scheduled tasks
 type Service struct {
 	configPath string
+	runsDir    string // last run of each schedule, one file per schedule
 	schedules  map[string]Schedule
+	audit      *audit.Log
+	dryRun     bool
 }
 
 // NewService creates a new scheduler service
-func NewService() (*Service, error) {
+// With dryRun set, Add, Remove and Migrate print the planned changes instead of applying them
+func NewService(dryRun bool) (*Service, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config dir: %w", err)
@@ -38,9 +45,17 @@ func NewService() (*Service, error) {
 		return nil, fmt.Errorf("failed to create config dir: %w", err)
 	}
 
+	auditLog, err := audit.Open()
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Service{
 		configPath: filepath.Join(orbDir, "schedules.json"),
+		runsDir:    filepath.Join(orbDir, "schedule-runs"),
 		schedules:  make(map[string]Schedule),
+		audit:      auditLog,
+		dryRun:     dryRun,
 	}
 
 	if err := s.load(); err != nil {
@@ -91,7 +106,7 @@ func (s *Service) Add(name, cron, command string) error {
 	if name == "" {
 		return fmt.Errorf("schedule name cannot be empty")
 	}
-	if strings.ContainsAny(name, " \t\n\r#;|&<>$`\\\"'") {
+	if strings.ContainsAny(name, " \t\n\r#;|&<>$`\\\"'/%") {
 		return fmt.Errorf("schedule name contains invalid characters")
 	}
 
@@ -134,8 +149,24 @@ func (s *Service) Add(name, cron, command string) error {
 		CreatedAt: time.Now(),
 	}
 
+	// cron runs the command through `orb schedule run`, which records its result
+	orb, err := orbOnPath()
+	if err != nil {
+		return err
+	}
+	entry := runEntry(orb, name)
+
+	if s.dryRun {
+		current := readCrontab()
+		p := plan.New()
+		p.Diff("Crontab", current, crontabWithEntry(current, schedule, entry))
+		p.Add("Schedules", plan.Create, "%s in %s", name, s.configPath)
+		p.Print()
+		return nil
+	}
+
 	// Add to crontab
-	if err := s.addToCrontab(schedule); err != nil {
+	if err := s.addToCrontab(schedule, entry); err != nil {
 		return fmt.Errorf("failed to add to crontab: %w", err)
 	}
 
@@ -147,6 +178,12 @@ func (s *Service) Add(name, cron, command string) error {
 		return err
 	}
 
+	s.record(audit.Entry{
+		Action:  "schedule.add",
+		Subject: name,
+		After:   audit.Fields("cron", cron, "command", command),
+	})
+
 	fmt.Printf("✓ Schedule %q added\n", name)
 	fmt.Printf("  Cron: %s\n", cron)
 	fmt.Printf("  Command: %s\n", command)
@@ -157,10 +194,20 @@ func (s *Service) Add(name, cron, command string) error {
 
 // Remove deletes a scheduled task
 func (s *Service) Remove(name string) error {
-	if _, exists := s.schedules[name]; !exists {
+	sched, exists := s.schedules[name]
+	if !exists {
 		return fmt.Errorf("schedule %q not found", name)
 	}
 
+	if s.dryRun {
+		current := readCrontab()
+		p := plan.New()
+		p.Diff("Crontab", current, crontabWithoutEntry(current, name))
+		p.Add("Schedules", plan.Delete, "%s in %s", name, s.configPath)
+		p.Print()
+		return nil
+	}
+
 	// Remove from crontab
 	if err := s.removeFromCrontab(name); err != nil {
 		return fmt.Errorf("failed to remove from crontab: %w", err)
@@ -171,6 +218,13 @@ func (s *Service) Remove(name string) error {
 	if err := s.save(); err != nil {
 		return err
 	}
+	s.deleteRun(name)
+
+	s.record(audit.Entry{
+		Action:  "schedule.remove",
+		Subject: name,
+		Before:  audit.Fields("cron", sched.Cron, "command", sched.Command),
+	})
 
 	fmt.Printf("✓ Schedule %q removed\n", name)
 	return nil
@@ -185,7 +239,7 @@ func (s *Service) List() error {
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.Header("Name", "Cron", "Command", "Created")
+	table.Header("Name", "Cron", "Command", "Created", "Last Run")
 
 	for _, sched := range s.schedules {
 		if err := table.Append(
@@ -193,6 +247,7 @@ func (s *Service) List() error {
 			sched.Cron,
 			truncate(sched.Command, 40),
 			sched.CreatedAt.Format("2006-01-02"),
+			describeRun(s.lastRunOrNil(sched.Name)),
 		); err != nil {
 			return fmt.Errorf("failed to add table row: %w", err)
 		}
@@ -203,42 +258,60 @@ func (s *Service) List() error {
 		return fmt.Errorf("failed to render table: %w", err)
 	}
 
+	if names := legacyEntries(readCrontab(), s.schedules); len(names) > 0 {
+		fmt.Printf("\n⚠ %s run their command directly and record no runs - switch them over with `orb schedule migrate`\n", strings.Join(names, ", "))
+	}
+
 	return nil
 }
 
-// addToCrontab adds a schedule to the user's crontab
-func (s *Service) addToCrontab(sched Schedule) error {
-	// Get current crontab
-	current, _ := exec.Command("crontab", "-l").Output()
-
-	// Build new entry with marker comment
-	marker := fmt.Sprintf("# orb-schedule: %s", sched.Name)
-	entry := fmt.Sprintf("%s\n%s %s\n", marker, sched.Cron, sched.Command)
-
-	// Append to crontab
-	newCrontab := string(current) + entry
-
-	// Write new crontab
-	cmd := exec.Command("crontab", "-")
-	cmd.Stdin = strings.NewReader(newCrontab)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("%s: %s", err, output)
+// record appends a completed change to the audit log without failing the change
+func (s *Service) record(entry audit.Entry) {
+	if err := s.audit.Record(entry); err != nil {
+		fmt.Printf("⚠ Warning: failed to record change in audit log: %v\n", err)
 	}
+}
 
-	return nil
+// addToCrontab adds a schedule to the user's crontab, running entry
+func (s *Service) addToCrontab(sched Schedule, entry string) error {
+	return writeCrontab(crontabWithEntry(readCrontab(), sched, entry))
 }
 
 // removeFromCrontab removes a schedule from the user's crontab
 func (s *Service) removeFromCrontab(name string) error {
-	// Get current crontab
 	current, err := exec.Command("crontab", "-l").Output()
 	if err != nil {
 		return nil // No crontab exists
 	}
+	return writeCrontab(crontabWithoutEntry(string(current), name))
+}
+
+// readCrontab returns the user's current crontab, or "" if there is none
+func readCrontab() string {
+	current, _ := exec.Command("crontab", "-l").Output()
+	return string(current)
+}
+
+// writeCrontab replaces the user's crontab
+func writeCrontab(crontab string) error {
+	cmd := exec.Command("crontab", "-")
+	cmd.Stdin = strings.NewReader(crontab)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", err, output)
+	}
+	return nil
+}
+
+// crontabWithEntry appends a schedule entry running command, with its marker comment, to a crontab
+func crontabWithEntry(crontab string, sched Schedule, command string) string {
+	marker := fmt.Sprintf("# orb-schedule: %s", sched.Name)
+	return crontab + fmt.Sprintf("%s\n%s %s\n", marker, sched.Cron, command)
+}
 
-	// Remove lines with our marker
+// crontabWithoutEntry removes a schedule's marker comment and the entry after it from a crontab
+func crontabWithoutEntry(crontab, name string) string {
 	marker := fmt.Sprintf("# orb-schedule: %s", name)
-	lines := strings.Split(string(current), "\n")
+	lines := strings.Split(crontab, "\n")
 	var newLines []string
 	skipNext := false
 
@@ -254,15 +327,7 @@ func (s *Service) removeFromCrontab(name string) error {
 		newLines = append(newLines, line)
 	}
 
-	// Write new crontab
-	newCrontab := strings.Join(newLines, "\n")
-	cmd := exec.Command("crontab", "-")
-	cmd.Stdin = strings.NewReader(newCrontab)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("%s: %s", err, output)
-	}
-
-	return nil
+	return strings.Join(newLines, "\n")
 }
 
 // validateCron performs basic cron expression validation
@@ -0,0 +1,572 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/dns"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// ShowAccess prints who can reach a subdomain and the Access policies behind it
+func (s *Service) ShowAccess(ctx context.Context, subdomain string) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	access, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	fmt.Printf("Access for %s: %s\n", host, access)
+	if access == nil {
+		fmt.Println("  No Access application - anyone with the URL can reach it")
+		return nil
+	}
+	for _, path := range access.PublicPaths {
+		fmt.Printf("  Public path: https://%s%s (bypass - no login)\n", host, path)
+	}
+	expiries, _ := s.expiries.ForSubdomain(subdomain)
+	for _, expiry := range expiries {
+		switch expiry.Kind {
+		case ExpiryAccess:
+			fmt.Printf("  Reverts to private: %s (in %s)\n", expiry.At.Format("2006-01-02 15:04:05"), FormatRemaining(time.Until(expiry.At)))
+		case ExpiryGroup:
+			fmt.Printf("  Group %s lapses: %s (in %s)\n", expiry.Target, expiry.At.Format("2006-01-02 15:04:05"), FormatRemaining(time.Until(expiry.At)))
+		case ExpiryShare:
+			fmt.Printf("  Shared with %s until: %s (in %s)\n", expiry.Target, expiry.At.Format("2006-01-02 15:04:05"), FormatRemaining(time.Until(expiry.At)))
+		}
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("#", "Policy", "Decision", "Include", "Require", "Exclude")
+	for _, policy := range access.Policies {
+		if err := table.Append(
+			strconv.Itoa(policy.Precedence),
+			policy.Name,
+			policy.Decision,
+			dns.JoinRules(policy.Include),
+			dns.JoinRules(policy.Require),
+			dns.JoinRules(policy.Exclude),
+		); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nPolicies (application %s):\n", access.AppID)
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	return nil
+}
+
+// SetAccess replaces the --allow, --require and --exclude rules of an exposed
+// subdomain, keeping its owner and group policies. Empty rules remove them.
+func (s *Service) SetAccess(ctx context.Context, subdomain string, rules dns.AccessRules) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	if err := s.requireExposed(host); err != nil {
+		return err
+	}
+
+	current, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	// require and exclude only narrow access someone besides the owner already has
+	if len(rules.Allow) == 0 && (len(rules.Require) > 0 || len(rules.Exclude) > 0) && !hasGroupPolicy(current) {
+		return fmt.Errorf("--require and --exclude narrow group or --allow access - %s has neither", host)
+	}
+
+	owners, err := OwnersFromEnv()
+	if err != nil {
+		return err
+	}
+	if current == nil && len(owners) == 0 {
+		return errNoOwners("to protect a public service")
+	}
+
+	if s.dryRun {
+		return s.planSetAccess(host, current, owners, rules)
+	}
+
+	fmt.Printf("Updating Zero Trust access rules for %s...\n", host)
+	appID, err := s.cloudflare.SetAccessRules(ctx, host, owners, rules)
+	if err != nil {
+		// the group policy may already carry the new require and exclude rules
+		s.restoreAccess(ctx, host, current)
+		return fmt.Errorf("failed to update access rules: %w", err)
+	}
+
+	after := s.cloudflare.GetAccessInfo(ctx, host)
+	s.record(audit.Entry{
+		Action:  "tunnel.access",
+		Subject: host,
+		Before:  audit.Fields("access", current.String()),
+		After:   audit.Fields("access", after),
+		IDs:     audit.Fields("access_app", appID),
+	})
+
+	fmt.Printf("✔ Access for %s: %s\n", host, after)
+	return nil
+}
+
+// hasGroupPolicy reports whether an application has a group policy to narrow
+func hasGroupPolicy(access *dns.Access) bool {
+	if access == nil {
+		return false
+	}
+	for _, policy := range access.Policies {
+		if strings.HasSuffix(policy.Name, "-group") {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeAccess switches an exposed subdomain to public, private or group access in
+// place: only its Access application and policies change, ingress and DNS are left
+// alone. A failed change restores the previous policies. A group level may name
+// several groups, each with its own expiry (e.g. team,contractors:7d).
+func (s *Service) ChangeAccess(ctx context.Context, subdomain, level, expires string) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+	if err := ValidateAccessLevel(level); err != nil {
+		return err
+	}
+	grants, _ := ParseGroupGrants(level) // already validated
+	if len(grants) > 0 {
+		level = GroupNames(grants)
+	}
+	if expires != "" {
+		if err := ValidateExpiresDuration(expires); err != nil {
+			return err
+		}
+		if len(grants) == 0 {
+			return fmt.Errorf("--expires can only be used with group access (e.g., orb tunnel access %s friends --expires 24h)", subdomain)
+		}
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	if err := s.requireExposed(host); err != nil {
+		return err
+	}
+
+	before, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	owners, err := OwnersFromEnv()
+	if err != nil {
+		return err
+	}
+	if level != AccessLevelPublic && before == nil && len(owners) == 0 {
+		return errNoOwners(fmt.Sprintf("for %s access", level))
+	}
+
+	// check the groups up front rather than failing halfway through the policy changes
+	for _, grant := range grants {
+		if err := s.requireGroup(ctx, grant.Name); err != nil {
+			return err
+		}
+	}
+
+	desired := desiredAccess(before, host, level, owners)
+
+	if s.dryRun {
+		return s.planChangeAccess(subdomain, host, before, desired, grants, expires)
+	}
+
+	fmt.Printf("Changing access for %s to %s...\n", host, level)
+	appID, err := s.applyAccessChange(ctx, host, before, desired)
+	if err != nil {
+		return fmt.Errorf("failed to change access: %w", err)
+	}
+
+	after := s.cloudflare.GetAccessInfo(ctx, host)
+	s.record(audit.Entry{
+		Action:  "tunnel.access",
+		Subject: host,
+		Before:  audit.Fields("access", before.String()),
+		After:   audit.Fields("access", after, "groups", FormatGrants(grants, ""), "expires", expires),
+		IDs:     audit.Fields("access_app", appID),
+	})
+
+	// a pending expiry belonged to the previous access level
+	if expires != "" {
+		duration, _ := ParseExpiresDuration(expires) // already validated
+		expiryTime := time.Now().Add(duration)
+		if err := s.scheduleExpiry(ctx, Expiry{Kind: ExpiryAccess, Subdomain: subdomain, At: expiryTime}); err != nil {
+			fmt.Printf("⚠ Warning: failed to schedule access expiry: %v\n", err)
+		} else {
+			fmt.Printf("  Access reverts to private: %s (in %s)\n", expiryTime.Format("2006-01-02 15:04:05"), expires)
+		}
+	} else if err := s.cancelExpiry(ctx, ExpiryAccess, subdomain, ""); err != nil {
+		fmt.Printf("⚠ Warning: failed to cancel access expiry for %s: %v\n", host, err)
+	}
+	s.cancelExpiries(ctx, subdomain, ExpiryGroup)
+	s.scheduleGroupExpiries(ctx, subdomain, grants)
+	// public and private drop the share policies along with everything else
+	if len(grants) == 0 {
+		s.cancelExpiries(ctx, subdomain, ExpiryShare)
+	}
+
+	fmt.Printf("✔ Access for %s: %s\n", host, after)
+	return nil
+}
+
+// AddGroup grants one more Access group access to an exposed subdomain, keeping
+// its other groups and rules. A non-empty expires removes just this group later.
+func (s *Service) AddGroup(ctx context.Context, subdomain, group, expires string) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+	grants, err := ParseGroupGrants(group)
+	if err != nil {
+		return err
+	}
+	if len(grants) != 1 {
+		return fmt.Errorf("--add-group takes a single group name, got %q", group)
+	}
+	grant := grants[0]
+	if expires != "" {
+		if grant.Expires != "" {
+			return fmt.Errorf("give the expiry of group %s either as %s or with --expires, not both", grant.Name, grant)
+		}
+		if err := ValidateExpiresDuration(expires); err != nil {
+			return err
+		}
+		grant.Expires = expires
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	if err := s.requireExposed(host); err != nil {
+		return err
+	}
+
+	before, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	groups := before.Groups()
+	for _, existing := range groups {
+		if existing == grant.Name && grant.Expires == "" {
+			fmt.Printf("ℹ️  %s already has access to %s (no changes needed)\n", grant.Name, host)
+			return nil
+		}
+	}
+
+	owners, err := OwnersFromEnv()
+	if err != nil {
+		return err
+	}
+	if before == nil && len(owners) == 0 {
+		return errNoOwners("to protect a public service")
+	}
+	if err := s.requireGroup(ctx, grant.Name); err != nil {
+		return err
+	}
+
+	desired := withGroups(before, host, appendGroup(groups, grant.Name), owners)
+
+	if s.dryRun {
+		return s.planGroupChange(subdomain, host, before, desired, grant, false)
+	}
+
+	fmt.Printf("Granting %s access to %s...\n", grant.Name, host)
+	appID, err := s.applyAccessChange(ctx, host, before, desired)
+	if err != nil {
+		return fmt.Errorf("failed to add group: %w", err)
+	}
+
+	after := s.cloudflare.GetAccessInfo(ctx, host)
+	s.record(audit.Entry{
+		Action:  "tunnel.access",
+		Subject: host,
+		Before:  audit.Fields("access", before.String()),
+		After:   audit.Fields("access", after, "added_group", grant.String()),
+		IDs:     audit.Fields("access_app", appID),
+	})
+
+	s.scheduleGroupExpiries(ctx, subdomain, []GroupGrant{grant})
+
+	fmt.Printf("✔ Access for %s: %s\n", host, after)
+	return nil
+}
+
+// RemoveGroup takes one Access group's access away from an exposed subdomain,
+// keeping its other groups and rules. Removing the last group drops the group policy.
+func (s *Service) RemoveGroup(ctx context.Context, subdomain, group string) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	if err := s.requireExposed(host); err != nil {
+		return err
+	}
+
+	before, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	var remaining []string
+	found := false
+	for _, existing := range before.Groups() {
+		if existing == group {
+			found = true
+			continue
+		}
+		remaining = append(remaining, existing)
+	}
+	if !found {
+		// nothing to take away, but a stale expiry for the group has nothing left to do
+		if err := s.cancelExpiry(ctx, ExpiryGroup, subdomain, group); err != nil {
+			fmt.Printf("⚠ Warning: failed to cancel group expiry for %s: %v\n", host, err)
+		}
+		return fmt.Errorf("✖ group %s has no access to %s (currently %s)", group, host, before)
+	}
+
+	owners, err := OwnersFromEnv()
+	if err != nil {
+		return err
+	}
+	desired := withGroups(before, host, remaining, owners)
+
+	if s.dryRun {
+		return s.planGroupChange(subdomain, host, before, desired, GroupGrant{Name: group}, true)
+	}
+
+	fmt.Printf("Removing %s access to %s...\n", group, host)
+	appID, err := s.applyAccessChange(ctx, host, before, desired)
+	if err != nil {
+		return fmt.Errorf("failed to remove group: %w", err)
+	}
+
+	after := s.cloudflare.GetAccessInfo(ctx, host)
+	s.record(audit.Entry{
+		Action:  "tunnel.access",
+		Subject: host,
+		Before:  audit.Fields("access", before.String()),
+		After:   audit.Fields("access", after, "removed_group", group),
+		IDs:     audit.Fields("access_app", appID),
+	})
+
+	if err := s.cancelExpiry(ctx, ExpiryGroup, subdomain, group); err != nil {
+		fmt.Printf("⚠ Warning: failed to cancel group expiry for %s: %v\n", host, err)
+	}
+	// with no one left besides the owner, an access expiry has nothing to revoke
+	if !desired.Shared() {
+		if err := s.cancelExpiry(ctx, ExpiryAccess, subdomain, ""); err != nil {
+			fmt.Printf("⚠ Warning: failed to cancel access expiry for %s: %v\n", host, err)
+		}
+	}
+
+	fmt.Printf("✔ Access for %s: %s\n", host, after)
+	return nil
+}
+
+// requireExposed fails unless host has an ingress rule
+func (s *Service) requireExposed(host string) error {
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+	if s.config.FindIngressIndex(cfg, host) == -1 {
+		return fmt.Errorf("✖ %s is not currently exposed", host)
+	}
+	return nil
+}
+
+// ruleUsage maps the values of rules of one type (e.g. group names) to the exposed
+// hostnames whose Access policies include, require or exclude them
+func (s *Service) ruleUsage(ctx context.Context, ruleType string) (map[string][]string, error) {
+	cfg, err := s.config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string][]string)
+	for _, ingress := range cfg.Ingress {
+		if ingress.Hostname == "" {
+			continue
+		}
+		access, err := s.cloudflare.GetAccess(ctx, ingress.Hostname)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get access policies for %s: %w", ingress.Hostname, err)
+		}
+		if access == nil {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, policy := range access.Policies {
+			for _, rules := range [][]dns.Rule{policy.Include, policy.Require, policy.Exclude} {
+				for _, rule := range rules {
+					if rule.Type == ruleType && !seen[rule.Value] {
+						seen[rule.Value] = true
+						usage[rule.Value] = append(usage[rule.Value], ingress.Hostname)
+					}
+				}
+			}
+		}
+	}
+	return usage, nil
+}
+
+// applyAccessChange turns the Access application of host from before into desired,
+// restoring before if the change fails. It returns the application's ID.
+func (s *Service) applyAccessChange(ctx context.Context, host string, before, desired *dns.Access) (string, error) {
+	appID, err := s.cloudflare.ApplyAccess(ctx, host, desired)
+	if err != nil {
+		s.restoreAccess(ctx, host, before)
+		return "", err
+	}
+	if appID == "" && before != nil {
+		appID = before.AppID // deleted application
+	}
+	return appID, nil
+}
+
+// restoreAccess puts the Access application of host back the way a GetAccess
+// snapshot taken before a failed change found it
+func (s *Service) restoreAccess(ctx context.Context, host string, before *dns.Access) {
+	ctx, cancel := rollbackContext(ctx)
+	defer cancel()
+
+	fmt.Println("Rolling back: Restoring previous Access policies...")
+	if _, err := s.cloudflare.ApplyAccess(ctx, host, before); err != nil {
+		fmt.Printf("Failed to restore Access policies for %s: %v\n", host, err)
+	}
+}
+
+// scheduleGroupExpiries starts the timers that remove groups granted for a limited time
+func (s *Service) scheduleGroupExpiries(ctx context.Context, subdomain string, grants []GroupGrant) {
+	for _, grant := range grants {
+		if grant.Expires == "" {
+			continue
+		}
+		duration, _ := ParseExpiresDuration(grant.Expires) // already validated
+		expiryTime := time.Now().Add(duration)
+		if err := s.scheduleExpiry(ctx, Expiry{Kind: ExpiryGroup, Subdomain: subdomain, Target: grant.Name, At: expiryTime}); err != nil {
+			fmt.Printf("⚠ Warning: failed to schedule expiry of group %s: %v\n", grant.Name, err)
+		} else {
+			fmt.Printf("  Group %s lapses: %s (in %s)\n", grant.Name, expiryTime.Format("2006-01-02 15:04:05"), grant.Expires)
+		}
+	}
+}
+
+// requireGroup fails if the named Access group does not exist
+func (s *Service) requireGroup(ctx context.Context, name string) error {
+	groups, err := s.cloudflare.ListAccessGroups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if group.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("access group %q not found - create it with `orb access create %s <emails>` first", name, name)
+}
+
+// appendGroup adds a group name to a list unless it is already there
+func appendGroup(groups []string, name string) []string {
+	for _, group := range groups {
+		if group == name {
+			return groups
+		}
+	}
+	return append(groups, name)
+}
+
+// desiredAccess returns the Access application a host should have at level. The
+// owner policy lets owners in (or is kept as is without them); group access carries
+// over --allow rules and the require/exclude conditions and shares, while private
+// drops everything but the owner.
+func desiredAccess(current *dns.Access, host, level string, owners []dns.Rule) *dns.Access {
+	if level == AccessLevelPublic {
+		return nil
+	}
+
+	var groups []string
+	for _, rule := range dns.GroupRules(level) {
+		groups = append(groups, rule.Value)
+	}
+	desired := withGroups(current, host, groups, owners)
+	if level == AccessLevelPrivate {
+		desired.Policies = desired.Policies[:1] // the owner
+	}
+	return desired
+}
+
+// withGroups returns the Access application of a host with its group policy granting
+// exactly groups (none drops the policy). The owner policy - migrated to owners, or
+// created for them if missing - and any --allow rule policies are kept.
+func withGroups(current *dns.Access, host string, groups []string, owners []dns.Rule) *dns.Access {
+	owner := dns.Policy{
+		Name:       fmt.Sprintf("orb-%s-owner", host),
+		Decision:   "allow",
+		Precedence: 1,
+		Include:    owners,
+	}
+	var group *dns.Policy
+	var kept []dns.Policy
+	if current != nil {
+		for _, policy := range current.Policies {
+			switch {
+			case strings.HasSuffix(policy.Name, "-owner"):
+				if len(owners) > 0 {
+					policy.Include = owners
+				}
+				owner = policy
+			case strings.HasSuffix(policy.Name, "-group"):
+				group = &policy
+			default:
+				kept = append(kept, policy)
+			}
+		}
+	}
+
+	desired := &dns.Access{Policies: []dns.Policy{owner}}
+	if current != nil {
+		desired.AppID = current.AppID
+	}
+
+	if len(groups) > 0 {
+		// conditions stay with the group policy; a new one takes those of the rules
+		policy := dns.Policy{
+			Name:       fmt.Sprintf("orb-%s-group", host),
+			Decision:   "allow",
+			Precedence: 2,
+		}
+		if group != nil {
+			policy.Require, policy.Exclude = group.Require, group.Exclude
+		} else {
+			rules := current.Rules()
+			policy.Require, policy.Exclude = rules.Require, rules.Exclude
+		}
+		for _, name := range groups {
+			policy.Include = append(policy.Include, dns.Rule{Type: dns.RuleGroup, Value: name})
+		}
+		desired.Policies = append(desired.Policies, policy)
+	}
+	desired.Policies = append(desired.Policies, kept...)
+	return desired
+}
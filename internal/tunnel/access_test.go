@@ -0,0 +1,66 @@
+package tunnel
+
+import (
+	"context"
+	"testing"
+
+	"orb/internal/dns"
+)
+
+func TestSetAccessRollsBackOnFailure(t *testing.T) {
+	svc, cf, _ := testService(t)
+	ctx := context.Background()
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: "friends"}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := cf.GetAccess(ctx, "api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the require rule lands on the group policy before the unknown token fails
+	rules := dns.AccessRules{
+		Allow:   []dns.Rule{{Type: dns.RuleServiceToken, Value: "missing"}},
+		Require: []dns.Rule{{Type: dns.RuleCountry, Value: "CA"}},
+	}
+	if err := svc.SetAccess(ctx, "api", rules); err == nil {
+		t.Fatal("SetAccess succeeded with an unknown service token")
+	}
+
+	after, err := cf.GetAccess(ctx, "api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.String() != before.String() {
+		t.Errorf("access not restored: got %q, want %q", after, before)
+	}
+	for _, policy := range after.Policies {
+		if len(policy.Require) != 0 {
+			t.Errorf("policy %s kept require rules %v", policy.Name, policy.Require)
+		}
+	}
+}
+
+func TestSetAccess(t *testing.T) {
+	svc, cf, _ := testService(t)
+	ctx := context.Background()
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: "friends"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := dns.AccessRules{
+		Allow:   []dns.Rule{{Type: dns.RuleEmailDomain, Value: "example.org"}},
+		Require: []dns.Rule{{Type: dns.RuleCountry, Value: "CA"}},
+	}
+	if err := svc.SetAccess(ctx, "api", rules); err != nil {
+		t.Fatal(err)
+	}
+
+	access, err := cf.GetAccess(ctx, "api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := access.String(), "friends, email-domain:example.org (require country:CA)"; got != want {
+		t.Errorf("access = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"orb/internal/dns"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// accessLogPoll is how often `access logs -f` asks Cloudflare for new decisions
+var accessLogPoll = 15 * time.Second
+
+// AccessLogOptions filters the Access authentication logs
+type AccessLogOptions struct {
+	Since  string // how far back to look, e.g. 30m, 24h, 7d
+	Denied bool   // only denied logins
+	User   string // only logins by this email address
+	Follow bool   // keep polling for new logins
+}
+
+// AccessLogs shows who logged in to a subdomain (or to every exposed service) through
+// Cloudflare Access, and whether they were let in, by user, IP and country
+func (s *Service) AccessLogs(ctx context.Context, subdomain string, opts AccessLogOptions) error {
+	window, err := ParseExpiresDuration(opts.Since)
+	if err != nil {
+		return fmt.Errorf("invalid --since %q: use format like 30m, 24h, or 7d", opts.Since)
+	}
+	opts.User = strings.ToLower(strings.TrimSpace(opts.User))
+
+	var host string
+	if subdomain != "" {
+		if err := ValidateSubdomain(subdomain); err != nil {
+			return err
+		}
+		host = HostnameFor(subdomain, s.env.Domain)
+		if err := s.requireExposed(host); err != nil {
+			return err
+		}
+	}
+
+	since := time.Now().Add(-window)
+	entries, err := s.cloudflare.GetAccessLogs(ctx, host, since)
+	if err != nil {
+		return err
+	}
+	entries = filterAccessLogs(entries, opts)
+
+	if !opts.Follow {
+		return printAccessLogs(entries, host, opts.Since)
+	}
+
+	// poll from the newest decision seen; Cloudflare's since is inclusive, so skip repeats
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		printAccessLogLine(entry)
+		seen[accessLogKey(entry)] = true
+		since = entry.Time
+	}
+	fmt.Printf("Watching Access logins%s (every %s, Ctrl+C to stop)...\n", accessLogScope(host), accessLogPoll)
+
+	ticker := time.NewTicker(accessLogPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		entries, err := s.cloudflare.GetAccessLogs(ctx, host, since)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Printf("⚠ Warning: %v\n", err)
+			continue
+		}
+		for _, entry := range filterAccessLogs(entries, opts) {
+			if key := accessLogKey(entry); !seen[key] {
+				seen[key] = true
+				printAccessLogLine(entry)
+			}
+		}
+		if len(entries) > 0 {
+			since = entries[len(entries)-1].Time
+		}
+	}
+}
+
+// filterAccessLogs keeps the entries matching --denied and --user
+func filterAccessLogs(entries []dns.AccessLogEntry, opts AccessLogOptions) []dns.AccessLogEntry {
+	var kept []dns.AccessLogEntry
+	for _, entry := range entries {
+		if opts.Denied && entry.Allowed {
+			continue
+		}
+		if opts.User != "" && entry.Email != opts.User {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// printAccessLogs prints the decisions as a table with a count of allowed and denied logins
+func printAccessLogs(entries []dns.AccessLogEntry, host, since string) error {
+	if len(entries) == 0 {
+		fmt.Printf("No Access logins%s in the last %s\n", accessLogScope(host), since)
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Time", "Service", "User", "IP", "Country", "Decision")
+	denied := 0
+	for _, entry := range entries {
+		if !entry.Allowed {
+			denied++
+		}
+		if err := table.Append(entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Hostname, orDash(entry.Email), orDash(entry.IP), orDash(entry.Country), accessDecision(entry)); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nAccess logins%s in the last %s (%d allowed, %d denied):\n", accessLogScope(host), since, len(entries)-denied, denied)
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	if denied > 0 {
+		fmt.Println("\nSee who a service lets in with `orb tunnel access <subdomain>` or `orb access matrix --user <email>`")
+	}
+	return nil
+}
+
+// printAccessLogLine prints one decision as it arrives in follow mode
+func printAccessLogLine(entry dns.AccessLogEntry) {
+	fmt.Printf("%s  %-9s %s  %s  %s (%s)\n", entry.Time.Local().Format("2006-01-02 15:04:05"), accessDecision(entry), entry.Hostname, orDash(entry.Email), orDash(entry.IP), orDash(entry.Country))
+}
+
+// accessDecision renders whether a login was let in
+func accessDecision(entry dns.AccessLogEntry) string {
+	if entry.Allowed {
+		return "✔ allowed"
+	}
+	return "✖ denied"
+}
+
+// accessLogKey identifies a decision across polls
+func accessLogKey(entry dns.AccessLogEntry) string {
+	return entry.RayID + "|" + entry.Time.String() + "|" + entry.Email
+}
+
+// accessLogScope names the service the logs are for, if only one
+func accessLogScope(host string) string {
+	if host == "" {
+		return ""
+	}
+	return " to " + host
+}
+
+// orDash renders an empty value as "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
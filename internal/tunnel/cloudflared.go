@@ -0,0 +1,76 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+
+	"orb/internal/runner"
+)
+
+// cloudflaredUnit returns the systemd unit name for a tunnel's cloudflared service
+func cloudflaredUnit(tunnelName string) string {
+	return fmt.Sprintf("cloudflared-%s", tunnelName)
+}
+
+// flushLocalDNSCache flushes the local DNS cache to pick up new DNS records
+func (s *Service) flushLocalDNSCache(ctx context.Context) {
+	// Try systemd-resolved first (Ubuntu/Debian)
+	if _, err := s.runner.Output(ctx, "sudo", "systemd-resolve", "--flush-caches"); err == nil {
+		return
+	}
+
+	// Try resolvectl (newer systemd)
+	// If both fail, it's not critical - DNS will eventually refresh
+	s.runner.Output(ctx, "sudo", "resolvectl", "flush-caches")
+}
+
+// restartCloudflared restarts the cloudflared service to apply config changes
+func (s *Service) restartCloudflared(ctx context.Context, tunnelName string) error {
+	serviceName := cloudflaredUnit(tunnelName)
+	output, err := s.runner.Output(ctx, "sudo", "systemctl", "restart", serviceName)
+	if err != nil {
+		return fmt.Errorf("failed to restart %s service: %w\nOutput: %s", serviceName, err, string(output))
+	}
+	return nil
+}
+
+// cloudflaredStatus returns the systemctl status of the cloudflared service
+func (s *Service) cloudflaredStatus(ctx context.Context, tunnelName string) (string, error) {
+	serviceName := cloudflaredUnit(tunnelName)
+	output, err := s.runner.Output(ctx, "systemctl", "status", serviceName, "--no-pager")
+	if err != nil {
+		// systemctl status returns exit code 3 if service is not running, but still outputs status
+		if runner.ExitCode(err) == 3 {
+			return string(output), nil
+		}
+		return "", fmt.Errorf("failed to get %s service status: %w\nOutput: %s", serviceName, err, string(output))
+	}
+	return string(output), nil
+}
+
+// cloudflaredLogs returns the logs of the cloudflared service, optionally filtered by hostname
+func (s *Service) cloudflaredLogs(ctx context.Context, tunnelName string, lines int, hostname string) (string, error) {
+	serviceName := cloudflaredUnit(tunnelName)
+	args := []string{"-u", serviceName, "--no-pager", "-n", fmt.Sprintf("%d", lines)}
+
+	if hostname != "" {
+		args = append(args, "--grep", hostname)
+	}
+
+	output, err := s.runner.Output(ctx, "journalctl", args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s service logs: %w\nOutput: %s", serviceName, err, string(output))
+	}
+	return string(output), nil
+}
+
+// followCloudflaredLogs follows the logs of the cloudflared service in real-time
+func (s *Service) followCloudflaredLogs(ctx context.Context, tunnelName string, hostname string) error {
+	args := []string{"-u", cloudflaredUnit(tunnelName), "-f"}
+
+	if hostname != "" {
+		args = append(args, "--grep", hostname)
+	}
+
+	return s.runner.Attach(ctx, "journalctl", args...)
+}
@@ -5,14 +5,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Expiry backends: how pending expiries are applied when they fall due
+const (
+	ExpiryBackendSystemd = "systemd" // transient systemd --user timers (default)
+	ExpiryBackendAgent   = "agent"   // a long-running `orb agent`
+)
+
 // Environment holds validated environment configuration
 type Environment struct {
-	Domain     string
-	ConfigPath string
+	Domain        string
+	ConfigPath    string
+	ExpiryBackend string
 }
 
 // LoadEnvironment loads and validates required environment variables
@@ -27,9 +35,18 @@ func LoadEnvironment() (*Environment, error) {
 		return nil, fmt.Errorf("CONFIG_PATH environment variable is required")
 	}
 
+	expiryBackend := os.Getenv("EXPIRY_BACKEND")
+	if expiryBackend == "" {
+		expiryBackend = ExpiryBackendSystemd
+	}
+	if expiryBackend != ExpiryBackendSystemd && expiryBackend != ExpiryBackendAgent {
+		return nil, fmt.Errorf("invalid EXPIRY_BACKEND %q: must be %s or %s", expiryBackend, ExpiryBackendSystemd, ExpiryBackendAgent)
+	}
+
 	return &Environment{
-		Domain:     domain,
-		ConfigPath: configPath,
+		Domain:        domain,
+		ConfigPath:    configPath,
+		ExpiryBackend: expiryBackend,
 	}, nil
 }
 
@@ -45,10 +62,18 @@ type IngressRule struct {
 	Service  string `yaml:"service"`
 }
 
+// WarpRouting is the warp-routing section of the cloudflared configuration, which
+// lets the tunnel carry traffic for private network routes from WARP clients
+type WarpRouting struct {
+	Enabled bool `yaml:"enabled"`
+}
+
 // Config represents the cloudflared YAML configuration structure
 type Config struct {
 	Tunnel          string        `yaml:"tunnel"`
 	CredentialsFile string        `yaml:"credentials-file"`
+	Metrics         string        `yaml:"metrics,omitempty"` // host:port cloudflared serves Prometheus metrics on
+	WarpRouting     *WarpRouting  `yaml:"warp-routing,omitempty"`
 	Ingress         []IngressRule `yaml:"ingress"`
 }
 
@@ -82,6 +107,15 @@ func (m *ConfigManager) Load() (*Config, error) {
 	return &config, nil
 }
 
+// Render returns the YAML that Save would write for config
+func (m *ConfigManager) Render(config *Config) (string, error) {
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return string(out), nil
+}
+
 // Save writes the cloudflared config to file atomically
 func (m *ConfigManager) Save(config *Config) error {
 	out, err := yaml.Marshal(config)
@@ -111,6 +145,10 @@ func (m *ConfigManager) Backup(config *Config) *Config {
 	backup := *config
 	backup.Ingress = make([]IngressRule, len(config.Ingress))
 	copy(backup.Ingress, config.Ingress)
+	if config.WarpRouting != nil {
+		warpRouting := *config.WarpRouting
+		backup.WarpRouting = &warpRouting
+	}
 	return &backup
 }
 
@@ -157,6 +195,11 @@ func HostnameFor(subdomain, domain string) string {
 	return fmt.Sprintf("%s.%s", subdomain, domain)
 }
 
+// SubdomainFor is the inverse of HostnameFor
+func SubdomainFor(hostname, domain string) string {
+	return strings.TrimSuffix(hostname, "."+domain)
+}
+
 // Service type constants
 const (
 	ServiceTypeHTTP  = "http"
@@ -0,0 +1,169 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"orb/internal/runner"
+)
+
+// Supervision of the `cloudflared access` client run by Connect
+var (
+	// connectRestartDelay is how long Connect waits before restarting a client that exited
+	connectRestartDelay = 2 * time.Second
+	// connectMaxFailures is how many quick exits in a row make Connect give up
+	connectMaxFailures = 5
+	// connectHealthyAfter is how long a client must run for its exit not to count as a failure
+	connectHealthyAfter = 30 * time.Second
+)
+
+// connectLocalPorts are the local ports Connect listens on by default, by service
+// type; TCP services use their own port. SMB avoids 445, which needs root.
+var connectLocalPorts = map[string]string{
+	ServiceTypeSSH: "2222",
+	ServiceTypeRDP: "3389",
+	ServiceTypeSMB: "4445",
+}
+
+// connectDatabases are the connection strings printed for TCP services on the
+// default port of a database, by port; %s is the local port
+var connectDatabases = map[string]string{
+	"5432":  "psql \"postgresql://postgres@localhost:%s/postgres\"",
+	"3306":  "mysql -h 127.0.0.1 -P %s -u root -p",
+	"6379":  "redis-cli -u redis://localhost:%s",
+	"27017": "mongosh \"mongodb://root@localhost:%s/?authSource=admin\"",
+	"1433":  "sqlcmd -S localhost,%s -U sa",
+	"9000":  "clickhouse-client --host localhost --port %s",
+	"9042":  "cqlsh localhost %s",
+	"11211": "telnet localhost %s",
+}
+
+// ConnectOptions tune how Connect reaches a service
+type ConnectOptions struct {
+	LocalPort string // where the client listens; defaults by service type
+	Type      string // tcp, ssh, rdp or smb; read from the ingress rule if empty
+}
+
+// NewConnectService creates a service for Connect, which only needs DOMAIN and
+// cloudflared: no Cloudflare API token, and CONFIG_PATH only to read the service
+// type when the service is exposed from this machine
+func NewConnectService() (*Service, error) {
+	domain := os.Getenv("DOMAIN")
+	if domain == "" {
+		return nil, fmt.Errorf("DOMAIN environment variable is required")
+	}
+	env := &Environment{Domain: domain, ConfigPath: os.Getenv("CONFIG_PATH")}
+	return NewServiceWith(env, Deps{Runner: runner.Exec{}}, Options{}), nil
+}
+
+// Connect runs the `cloudflared access` client for a TCP, SSH, RDP or SMB service
+// on a local port, restarting it if it exits, until ctx is cancelled (Ctrl+C)
+func (s *Service) Connect(ctx context.Context, subdomain string, opts ConnectOptions) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+	host := HostnameFor(subdomain, s.env.Domain)
+
+	serviceType, remotePort := opts.Type, ""
+	if cfg, err := s.config.Load(); err == nil { // fails without CONFIG_PATH, like on a client machine
+		if idx := s.config.FindIngressIndex(cfg, host); idx != -1 {
+			if u, err := url.Parse(cfg.Ingress[idx].Service); err == nil {
+				remotePort = u.Port()
+				if serviceType == "" {
+					serviceType = u.Scheme
+				}
+			}
+		}
+	}
+
+	switch serviceType {
+	case "":
+		return fmt.Errorf("✖ %s is not exposed from this machine - give its type with --type (tcp, ssh, rdp or smb)", host)
+	case ServiceTypeHTTP, ServiceTypeHTTPS:
+		return fmt.Errorf("✖ %s is a web service - open https://%s in a browser instead", host, host)
+	case ServiceTypeTCP, ServiceTypeSSH, ServiceTypeRDP, ServiceTypeSMB:
+	default:
+		return fmt.Errorf("invalid --type %q: orb connect supports tcp, ssh, rdp or smb", serviceType)
+	}
+
+	localPort := opts.LocalPort
+	if localPort == "" {
+		localPort = connectLocalPorts[serviceType]
+		if serviceType == ServiceTypeTCP {
+			localPort = remotePort
+		}
+	}
+	if localPort == "" {
+		return fmt.Errorf("pick a local port for %s with --local-port (e.g., --local-port 5432)", host)
+	}
+	if err := ValidatePort(localPort); err != nil {
+		return err
+	}
+
+	// fail early with a clear message rather than through cloudflared's
+	listener, err := net.Listen("tcp", net.JoinHostPort("localhost", localPort))
+	if err != nil {
+		return fmt.Errorf("✖ local port %s is already in use - pick another with --local-port", localPort)
+	}
+	listener.Close()
+
+	address := net.JoinHostPort("localhost", localPort)
+	fmt.Printf("✔ Connecting to %s (%s) on %s\n", host, serviceType, address)
+	printConnectHint(serviceType, remotePort, localPort)
+	fmt.Println("  The first connection opens a browser to log in. Press Ctrl+C to disconnect.")
+
+	args := []string{"access", serviceType, "--hostname", host, "--url", address}
+	failures := 0
+	for {
+		started := time.Now()
+		err := s.runner.Attach(ctx, "cloudflared", args...)
+		if ctx.Err() != nil {
+			fmt.Println("\n✔ Disconnected")
+			return nil
+		}
+
+		if err != nil && runner.ExitCode(err) == -1 {
+			return fmt.Errorf("failed to run cloudflared (is it installed?): %w", err)
+		}
+
+		if time.Since(started) >= connectHealthyAfter {
+			failures = 0
+		}
+		failures++
+		if failures >= connectMaxFailures {
+			return fmt.Errorf("cloudflared access %s keeps exiting (%d times in a row) - check `cloudflared access %s --hostname %s` by hand", serviceType, failures, serviceType, host)
+		}
+
+		fmt.Printf("⚠ Warning: cloudflared exited (%v) - restarting in %s...\n", err, connectRestartDelay)
+		select {
+		case <-ctx.Done():
+			fmt.Println("\n✔ Disconnected")
+			return nil
+		case <-time.After(connectRestartDelay):
+		}
+	}
+}
+
+// printConnectHint prints how to use the local end of a connection
+func printConnectHint(serviceType, remotePort, localPort string) {
+	switch serviceType {
+	case ServiceTypeSSH:
+		fmt.Printf("  Connect: ssh -p %s <user>@localhost\n", localPort)
+	case ServiceTypeRDP:
+		fmt.Printf("  Connect: point your RDP client at localhost:%s\n", localPort)
+	case ServiceTypeSMB:
+		fmt.Printf("  Connect: smb://localhost:%s\n", localPort)
+	default:
+		port := remotePort
+		if _, ok := connectDatabases[port]; !ok {
+			port = localPort
+		}
+		if hint, ok := connectDatabases[port]; ok {
+			fmt.Printf("  Connect: %s\n", fmt.Sprintf(hint, localPort))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"orb/internal/dns"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// haConnections is how many edge connections each cloudflared connector opens by default (--ha-connections)
+const haConnections = 4
+
+// Connections shows the connectors running the tunnel and their edge connections,
+// as Cloudflare sees them. It warns when fewer connections are up than expected:
+// expect in total if set, otherwise 4 per connector.
+func (s *Service) Connections(ctx context.Context, expect int) error {
+	if expect < 0 {
+		return fmt.Errorf("invalid --expect %d: must be a number of connections (e.g., 4)", expect)
+	}
+
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+	tunnelName, err := s.cloudflare.GetTunnelName(ctx, cfg.Tunnel)
+	if err != nil {
+		return err
+	}
+	connectors, err := s.cloudflare.ListTunnelConnectors(ctx, cfg.Tunnel)
+	if err != nil {
+		return err
+	}
+
+	if len(connectors) == 0 {
+		fmt.Printf("✖ Tunnel %s has no connectors - no cloudflared is connected to Cloudflare\n", tunnelName)
+		fmt.Println("  Check the service with `orb tunnel status` and `orb tunnel logs`")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Connector", "Origin IP", "Version", "Colos", "Connections", "Connected Since")
+	var warnings []string
+	up := 0
+	for _, connector := range connectors {
+		active := connector.Active()
+		up += len(active)
+		if len(active) < haConnections {
+			warnings = append(warnings, fmt.Sprintf("connector %s has %d of %d connections up", connector.ID, len(active), haConnections))
+		}
+
+		since := "-"
+		if !connector.RunAt.IsZero() {
+			since = fmt.Sprintf("%s (%s ago)", connector.RunAt.Local().Format("2006-01-02 15:04"), FormatRemaining(time.Since(connector.RunAt)))
+		}
+		if err := table.Append(connector.ID, orDash(strings.Join(originIPs(connector), ", ")), orDash(connector.Version), orDash(strings.Join(colos(connector), ", ")), fmt.Sprint(len(active)), since); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nTunnel %s: %d connector(s), %d connection(s) up\n", tunnelName, len(connectors), up)
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+
+	if expect == 0 {
+		expect = haConnections * len(connectors)
+	}
+	if up < expect {
+		fmt.Printf("⚠ Warning: %d of %d expected connections are up\n", up, expect)
+		for _, warning := range warnings {
+			fmt.Printf("  • %s\n", warning)
+		}
+		return nil
+	}
+	fmt.Printf("✔ %d connection(s) up (%d expected)\n", up, expect)
+	return nil
+}
+
+// originIPs returns the distinct public IPs a connector connects from
+func originIPs(connector dns.Connector) []string {
+	var ips []string
+	for _, conn := range connector.Active() {
+		if conn.OriginIP != "" && !slices.Contains(ips, conn.OriginIP) {
+			ips = append(ips, conn.OriginIP)
+		}
+	}
+	return ips
+}
+
+// colos returns the distinct data centers a connector is connected to
+func colos(connector dns.Connector) []string {
+	var names []string
+	for _, conn := range connector.Active() {
+		if conn.Colo != "" && !slices.Contains(names, conn.Colo) {
+			names = append(names, conn.Colo)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,730 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/plan"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Kinds of expiry
+const (
+	ExpiryAccess = "access" // revert group access to private (--expires)
+	ExpiryTTL    = "ttl"    // remove the exposure entirely (--ttl)
+	ExpiryGroup  = "group"  // remove one group's access (--access team,contractors:7d)
+	ExpiryShare  = "share"  // stop sharing with one email (orb tunnel share --for)
+)
+
+// expiryKinds lists the valid kinds of expiry
+var expiryKinds = []string{ExpiryAccess, ExpiryTTL, ExpiryGroup, ExpiryShare}
+
+// unitUnsafeRe matches characters not allowed in a systemd unit name
+var unitUnsafeRe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// Expiry is a pending time-based change to an exposed service
+type Expiry struct {
+	Kind      string    `json:"kind"`
+	Subdomain string    `json:"subdomain"`
+	Target    string    `json:"target,omitempty"` // the group or email of a group or share expiry
+	At        time.Time `json:"at"`
+
+	// set when applying the expiry failed, so it is retried with backoff
+	Attempts  int       `json:"attempts,omitempty"`
+	RetryAt   time.Time `json:"retry_at,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Expiry retry backoff: the delay doubles after each failed attempt up to the cap
+const (
+	expiryRetryBase = 30 * time.Second
+	expiryRetryMax  = time.Hour
+)
+
+// ExpiryStore persists pending expiries so orb can show, extend and cancel them
+type ExpiryStore struct {
+	path string
+}
+
+// DefaultExpiryStore returns the expiry store in orb's config directory
+func DefaultExpiryStore() (*ExpiryStore, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return NewExpiryStore(filepath.Join(configDir, "orb", "expiries.json")), nil
+}
+
+// NewExpiryStore returns an expiry store backed by the file at path
+func NewExpiryStore(path string) *ExpiryStore {
+	return &ExpiryStore{path: path}
+}
+
+// List returns all pending expiries, soonest first. A nil store has none.
+func (s *ExpiryStore) List() ([]Expiry, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil // nothing scheduled yet
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expiries: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var expiries []Expiry
+	if err := json.Unmarshal(data, &expiries); err != nil {
+		return nil, fmt.Errorf("failed to parse expiries: %w", err)
+	}
+	sort.Slice(expiries, func(i, j int) bool { return expiries[i].At.Before(expiries[j].At) })
+	return expiries, nil
+}
+
+// Get returns the pending expiry of a kind and target for a subdomain, if any
+func (s *ExpiryStore) Get(kind, subdomain, target string) (Expiry, bool, error) {
+	expiries, err := s.List()
+	if err != nil {
+		return Expiry{}, false, err
+	}
+	for _, e := range expiries {
+		if e.matches(kind, subdomain, target) {
+			return e, true, nil
+		}
+	}
+	return Expiry{}, false, nil
+}
+
+// Set adds an expiry, replacing any existing one of the same kind and target for the subdomain
+func (s *ExpiryStore) Set(expiry Expiry) error {
+	if s == nil {
+		return nil
+	}
+	return s.update(func(expiries []Expiry) []Expiry {
+		return append(withoutExpiry(expiries, expiry.Kind, expiry.Subdomain, expiry.Target), expiry)
+	})
+}
+
+// Delete removes the expiry of a kind and target for a subdomain, if any
+func (s *ExpiryStore) Delete(kind, subdomain, target string) error {
+	if s == nil {
+		return nil
+	}
+	return s.update(func(expiries []Expiry) []Expiry {
+		return withoutExpiry(expiries, kind, subdomain, target)
+	})
+}
+
+// RecordFailure notes a failed attempt to apply an expiry and schedules its retry.
+// It returns the updated expiry; one extended or cancelled meanwhile is left alone.
+func (s *ExpiryStore) RecordFailure(expiry Expiry, cause error) (Expiry, error) {
+	if s == nil {
+		return expiry, nil
+	}
+	failed := expiry
+	err := s.update(func(expiries []Expiry) []Expiry {
+		for i, e := range expiries {
+			if e.matches(expiry.Kind, expiry.Subdomain, expiry.Target) && e.At.Equal(expiry.At) {
+				e.Attempts++
+				e.RetryAt = time.Now().Add(expiryRetryDelay(e.Attempts))
+				e.LastError = cause.Error()
+				expiries[i], failed = e, e
+			}
+		}
+		return expiries
+	})
+	return failed, err
+}
+
+// ForSubdomain returns the pending expiries of a subdomain, soonest first
+func (s *ExpiryStore) ForSubdomain(subdomain string) ([]Expiry, error) {
+	expiries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var matches []Expiry
+	for _, e := range expiries {
+		if e.Subdomain == subdomain {
+			matches = append(matches, e)
+		}
+	}
+	return matches, nil
+}
+
+// update applies change to the stored expiries while holding the store lock,
+// so concurrent orb commands and the agent do not overwrite each other
+func (s *ExpiryStore) update(change func([]Expiry) []Expiry) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	expiries, err := s.List()
+	if err != nil {
+		return err
+	}
+	return s.save(change(expiries))
+}
+
+// lock takes an exclusive lock on the store, returning the function that releases it
+func (s *ExpiryStore) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config dir: %w", err)
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open expiries lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock expiries: %w", err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// save writes expiries to a temporary file and renames it over the store file,
+// so a reader never sees a partial write
+func (s *ExpiryStore) save(expiries []Expiry) error {
+	if expiries == nil {
+		expiries = []Expiry{}
+	}
+	data, err := json.MarshalIndent(expiries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal expiries: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".expiries-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write expiries: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write expiries: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write expiries: %w", err)
+	}
+	return nil
+}
+
+// matches reports whether an expiry has the given kind, subdomain and target
+func (e Expiry) matches(kind, subdomain, target string) bool {
+	return e.Kind == kind && e.Subdomain == subdomain && e.Target == target
+}
+
+// nextAttempt returns when the expiry should next be applied: its deadline, or
+// the retry time after a failed attempt
+func (e Expiry) nextAttempt() time.Time {
+	if e.RetryAt.After(e.At) {
+		return e.RetryAt
+	}
+	return e.At
+}
+
+// expiryRetryDelay returns how long to wait before retrying after the given number of failed attempts
+func expiryRetryDelay(attempts int) time.Duration {
+	delay := expiryRetryBase
+	for i := 1; i < attempts && delay < expiryRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, expiryRetryMax)
+}
+
+// withoutExpiry returns expiries minus the one of a kind and target for a subdomain
+func withoutExpiry(expiries []Expiry, kind, subdomain, target string) []Expiry {
+	var kept []Expiry
+	for _, e := range expiries {
+		if !e.matches(kind, subdomain, target) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// Unit returns the systemd unit name of the timer for an expiry
+func (e Expiry) Unit() string {
+	switch e.Kind {
+	case ExpiryTTL:
+		return "orb-ttl-" + e.Subdomain
+	case ExpiryGroup:
+		return "orb-group-" + e.Subdomain + "-" + unitUnsafeRe.ReplaceAllString(e.Target, "_")
+	case ExpiryShare:
+		return "orb-share-" + e.Subdomain + "-" + unitUnsafeRe.ReplaceAllString(e.Target, "_")
+	default:
+		return "orb-expire-" + e.Subdomain
+	}
+}
+
+// Action describes what happens when the expiry lapses
+func (e Expiry) Action() string {
+	switch e.Kind {
+	case ExpiryTTL:
+		return "unexpose"
+	case ExpiryGroup:
+		return "remove group " + e.Target
+	case ExpiryShare:
+		return "unshare " + e.Target
+	default:
+		return "revert to private"
+	}
+}
+
+// Label names the expiry for messages, e.g. "ttl" or "group contractors"
+func (e Expiry) Label() string {
+	if e.Target != "" {
+		return e.Kind + " " + e.Target
+	}
+	return e.Kind
+}
+
+// command returns the orb arguments, audit trigger and unit description of the expiry's timer
+func (e Expiry) command() (args []string, trigger, description string) {
+	switch e.Kind {
+	case ExpiryTTL:
+		return []string{"tunnel", "unexpose", e.Subdomain}, "ttl", "Unexpose " + e.Subdomain + " when its TTL lapses"
+	case ExpiryGroup:
+		return []string{"tunnel", "access", e.Subdomain, "--remove-group", e.Target}, "expiry", "Remove group " + e.Target + " from " + e.Subdomain
+	case ExpiryShare:
+		return []string{"tunnel", "unshare", e.Subdomain, e.Target}, "expiry", "Stop sharing " + e.Subdomain + " with " + e.Target
+	default:
+		return []string{"tunnel", "revoke-access", e.Subdomain}, "expiry", "Revoke group access for " + e.Subdomain
+	}
+}
+
+// ValidateExpiryKind checks an expiry kind given on the command line
+func ValidateExpiryKind(kind string) error {
+	for _, valid := range expiryKinds {
+		if kind == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid expiry kind %q: must be one of %s", kind, strings.Join(expiryKinds, ", "))
+}
+
+// scheduleExpiry starts a timer that applies an expiry at its time and records it.
+// Any timer already scheduled for the same kind, subdomain and target is replaced.
+func (s *Service) scheduleExpiry(ctx context.Context, expiry Expiry) error {
+	// Subdomain is validated by callers, but check again so nothing unexpected reaches systemd-run
+	if err := ValidateSubdomain(expiry.Subdomain); err != nil {
+		return fmt.Errorf("invalid subdomain for scheduling: %w", err)
+	}
+
+	// replace any timer left from an earlier exposure, deadline or backend
+	unit := expiry.Unit()
+	s.stopTimer(ctx, unit)
+
+	// the agent (or the next orb command) applies it from the store
+	if s.env.ExpiryBackend == ExpiryBackendAgent {
+		return s.expiries.Set(expiry)
+	}
+
+	executable, err := orbExecutable()
+	if err != nil {
+		return err
+	}
+
+	args, trigger, description := expiry.command()
+	durationStr := fmt.Sprintf("%ds", max(1, int(time.Until(expiry.At).Round(time.Second).Seconds())))
+
+	// Pass arguments separately to prevent command injection
+	output, err := s.runner.Output(ctx, "systemd-run", append([]string{
+		"--user",
+		"--on-active=" + durationStr,
+		"--unit=" + unit,
+		"--description=" + description,
+		"--setenv=" + audit.TriggerEnv + "=" + trigger,
+		executable,
+	}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to schedule expiry timer: %w\nOutput: %s", err, string(output))
+	}
+
+	return s.expiries.Set(expiry)
+}
+
+// cancelExpiry stops the timer for an expiry, if any, and forgets it
+func (s *Service) cancelExpiry(ctx context.Context, kind, subdomain, target string) error {
+	expiry, ok, err := s.expiries.Get(kind, subdomain, target)
+	if err != nil || !ok {
+		return err
+	}
+	s.stopTimer(ctx, expiry.Unit())
+	return s.expiries.Delete(kind, subdomain, target)
+}
+
+// cancelExpiries cancels every pending expiry of a subdomain whose kind is listed,
+// warning about (rather than failing on) any that cannot be cancelled
+func (s *Service) cancelExpiries(ctx context.Context, subdomain string, kinds ...string) {
+	expiries, err := s.expiries.ForSubdomain(subdomain)
+	if err != nil {
+		fmt.Printf("⚠ Warning: failed to read expiries: %v\n", err)
+		return
+	}
+	for _, e := range expiries {
+		for _, kind := range kinds {
+			if e.Kind != kind {
+				continue
+			}
+			if err := s.cancelExpiry(ctx, e.Kind, e.Subdomain, e.Target); err != nil {
+				fmt.Printf("⚠ Warning: failed to cancel %s expiry for %s: %v\n", e.Label(), HostnameFor(subdomain, s.env.Domain), err)
+			}
+		}
+	}
+}
+
+// expiryBackendName describes an expiry backend for display
+func expiryBackendName(backend string) string {
+	if backend == ExpiryBackendAgent {
+		return "orb agent"
+	}
+	return "systemd timers"
+}
+
+// orbExecutable returns the path of the running orb binary for timers to invoke
+func orbExecutable() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate orb executable: %w", err)
+	}
+	return path, nil
+}
+
+// stopTimer stops a transient systemd timer and clears a failed run so the unit
+// name can be reused; both are no-ops if the unit does not exist
+func (s *Service) stopTimer(ctx context.Context, unit string) {
+	s.runner.Output(ctx, "systemctl", "--user", "stop", unit+".timer")
+	s.runner.Output(ctx, "systemctl", "--user", "reset-failed", unit+".service")
+}
+
+// FormatRemaining renders the time left until a deadline (e.g. "1d4h", "2h05m", "12m")
+func FormatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "overdue"
+	}
+
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%02dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return "<1m"
+	}
+}
+
+// ListExpiries shows pending access expiries and TTLs, soonest first
+func (s *Service) ListExpiries(ctx context.Context) error {
+	expiries, err := s.expiries.List()
+	if err != nil {
+		return err
+	}
+
+	if len(expiries) == 0 {
+		fmt.Println("No pending expiries")
+		fmt.Println("\nUse --expires (group access), --access group:duration or --ttl (whole exposure) on `orb tunnel expose`, or `orb tunnel share --for`, to schedule one")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("URL", "Kind", "Action", "Due", "In")
+
+	var failed []Expiry
+	for _, e := range expiries {
+		remaining := FormatRemaining(time.Until(e.At))
+		if e.Attempts > 0 {
+			remaining += fmt.Sprintf(" (retry in %s)", FormatRemaining(time.Until(e.RetryAt)))
+			failed = append(failed, e)
+		}
+		if err := table.Append(
+			fmt.Sprintf("https://%s", HostnameFor(e.Subdomain, s.env.Domain)),
+			e.Kind,
+			e.Action(),
+			e.At.Format("2006-01-02 15:04:05"),
+			remaining,
+		); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nPending expiries (applied by %s):\n", expiryBackendName(s.env.ExpiryBackend))
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+
+	for _, e := range failed {
+		fmt.Printf("⚠ Warning: %s expiry of %s failed %d time(s): %s\n", e.Label(), HostnameFor(e.Subdomain, s.env.Domain), e.Attempts, e.LastError)
+	}
+
+	return nil
+}
+
+// ExtendExpiry pushes back a pending expiry of subdomain. An empty kind (and target)
+// selects the only pending expiry; they are required when there are several.
+func (s *Service) ExtendExpiry(ctx context.Context, subdomain, kind, target, by string) error {
+	if err := ValidateExpiresDuration(by); err != nil {
+		return err
+	}
+	duration, _ := ParseExpiresDuration(by) // already validated
+
+	expiry, err := s.pendingExpiry(subdomain, kind, target)
+	if err != nil {
+		return err
+	}
+	host := HostnameFor(subdomain, s.env.Domain)
+
+	// extending an overdue deadline counts from now
+	deadline := expiry.At
+	if deadline.Before(time.Now()) {
+		deadline = time.Now()
+	}
+	deadline = deadline.Add(duration)
+
+	if s.dryRun {
+		return s.planExtendExpiry(host, expiry, deadline)
+	}
+
+	extended := expiry
+	extended.At = deadline
+	if err := s.scheduleExpiry(ctx, extended); err != nil {
+		return err
+	}
+
+	s.record(audit.Entry{
+		Action:  "expiry.extend",
+		Subject: host,
+		Before:  audit.Fields(expiryField(expiry), expiry.At.Format(time.RFC3339)),
+		After:   audit.Fields(expiryField(expiry), deadline.Format(time.RFC3339)),
+	})
+
+	fmt.Printf("✔ Extended %s expiry of %s by %s\n", expiry.Label(), host, by)
+	fmt.Printf("  Will %s: %s (in %s)\n", expiry.Action(), deadline.Format("2006-01-02 15:04:05"), FormatRemaining(time.Until(deadline)))
+	return nil
+}
+
+// CancelExpiry drops a pending expiry of subdomain so the current access or
+// exposure stays until changed by hand. An empty kind selects the only pending expiry.
+func (s *Service) CancelExpiry(ctx context.Context, subdomain, kind, target string) error {
+	expiry, err := s.pendingExpiry(subdomain, kind, target)
+	if err != nil {
+		return err
+	}
+	host := HostnameFor(subdomain, s.env.Domain)
+
+	if s.dryRun {
+		p := plan.New()
+		s.planCancelExpiry(p, expiry.Kind, subdomain, expiry.Target)
+		p.Print()
+		return nil
+	}
+
+	if err := s.cancelExpiry(ctx, expiry.Kind, subdomain, expiry.Target); err != nil {
+		return err
+	}
+
+	s.record(audit.Entry{
+		Action:  "expiry.cancel",
+		Subject: host,
+		Before:  audit.Fields(expiryField(expiry), expiry.At.Format(time.RFC3339)),
+	})
+
+	fmt.Printf("✔ Cancelled %s expiry of %s (was due %s)\n", expiry.Label(), host, expiry.At.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// expiryField names an expiry's deadline in the audit log (e.g. "ttl_due", "group:qa_due")
+func expiryField(e Expiry) string {
+	if e.Target != "" {
+		return e.Kind + ":" + e.Target + "_due"
+	}
+	return e.Kind + "_due"
+}
+
+// pendingExpiry finds the expiry of subdomain that extend or cancel should act on
+func (s *Service) pendingExpiry(subdomain, kind, target string) (Expiry, error) {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return Expiry{}, err
+	}
+	if kind != "" {
+		if err := ValidateExpiryKind(kind); err != nil {
+			return Expiry{}, err
+		}
+	}
+
+	expiries, err := s.expiries.List()
+	if err != nil {
+		return Expiry{}, err
+	}
+
+	var matches []Expiry
+	for _, e := range expiries {
+		if e.Subdomain == subdomain && (kind == "" || e.Kind == kind) && (target == "" || e.Target == target) {
+			matches = append(matches, e)
+		}
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	switch {
+	case len(matches) == 0 && kind != "":
+		return Expiry{}, fmt.Errorf("✖ %s has no pending %s expiry", host, kind)
+	case len(matches) == 0:
+		return Expiry{}, fmt.Errorf("✖ %s has no pending expiry", host)
+	case len(matches) > 1:
+		var labels []string
+		for _, e := range matches {
+			labels = append(labels, e.Label())
+		}
+		return Expiry{}, fmt.Errorf("✖ %s has several pending expiries (%s) - choose one with --kind and --target", host, strings.Join(labels, ", "))
+	}
+	return matches[0], nil
+}
+
+// RunDueExpiries applies every expiry whose deadline has passed, including ones
+// missed while the machine was off or no timer or agent was running, and
+// returns how many were applied
+func (s *Service) RunDueExpiries(ctx context.Context) (int, error) {
+	if s.dryRun {
+		return 0, nil
+	}
+
+	expiries, err := s.expiries.List()
+	if err != nil {
+		return 0, err
+	}
+
+	cfg, err := s.config.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	now := time.Now()
+	for _, e := range expiries {
+		if e.At.After(now) {
+			break // sorted soonest first
+		}
+
+		if e.RetryAt.After(now) {
+			continue // failed earlier; wait for its retry
+		}
+
+		host := HostnameFor(e.Subdomain, s.env.Domain)
+
+		// an exposure removed by other means leaves nothing to apply
+		if s.config.FindIngressIndex(cfg, host) == -1 {
+			fmt.Printf("ℹ️  Dropping %s expiry of %s (no longer exposed)\n", e.Label(), host)
+			if err := s.cancelExpiry(ctx, e.Kind, e.Subdomain, e.Target); err != nil {
+				return applied, err
+			}
+			continue
+		}
+
+		fmt.Printf("⏰ %s expiry of %s was due %s - applying\n", e.Label(), host, e.At.Format("2006-01-02 15:04:05"))
+		if err := s.applyExpiry(ctx, e); err != nil {
+			fmt.Printf("⚠ Warning: failed to apply %s expiry of %s: %v\n", e.Label(), host, err)
+			failed, recordErr := s.expiries.RecordFailure(e, err)
+			if recordErr != nil {
+				return applied, recordErr
+			}
+			if failed.Attempts > 0 {
+				fmt.Printf("  Retrying at %s (attempt %d failed)\n", failed.RetryAt.Format("2006-01-02 15:04:05"), failed.Attempts)
+			}
+			continue
+		}
+		applied++
+
+		// unexposing changes the config, so reload it for the next check
+		if cfg, err = s.config.Load(); err != nil {
+			return applied, err
+		}
+	}
+
+	return applied, nil
+}
+
+// applyExpiry carries out a lapsed expiry, recording it with the same trigger its timer would use
+func (s *Service) applyExpiry(ctx context.Context, e Expiry) error {
+	_, trigger, _ := e.command()
+	s.trigger = trigger
+	defer func() { s.trigger = "" }()
+
+	switch e.Kind {
+	case ExpiryTTL:
+		return s.Unexpose(ctx, e.Subdomain)
+	case ExpiryGroup:
+		return s.RemoveGroup(ctx, e.Subdomain, e.Target)
+	case ExpiryShare:
+		return s.Unshare(ctx, e.Subdomain, e.Target)
+	default:
+		return s.RevokeAccess(ctx, e.Subdomain)
+	}
+}
+
+// RunAgent applies expiries as they fall due until ctx is cancelled. The store is
+// re-read on every pass, so expiries scheduled by other orb commands are picked up.
+func (s *Service) RunAgent(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("agent interval must be positive")
+	}
+
+	fmt.Printf("✔ orb agent running (checking every %s, Ctrl-C to stop)\n", interval)
+	if s.env.ExpiryBackend != ExpiryBackendAgent {
+		fmt.Printf("ℹ️  EXPIRY_BACKEND is %s - systemd timers are still scheduled; the agent catches any they miss\n", s.env.ExpiryBackend)
+	}
+
+	for {
+		if _, err := s.RunDueExpiries(ctx); err != nil {
+			fmt.Printf("⚠ Warning: %v\n", err)
+		}
+
+		// wake at the next deadline or retry if it comes before the next regular check
+		wait := interval
+		if expiries, err := s.expiries.List(); err == nil {
+			for _, e := range expiries {
+				wait = min(wait, max(time.Second, time.Until(e.nextAttempt())))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("✔ orb agent stopped")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
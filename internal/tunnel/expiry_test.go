@@ -0,0 +1,121 @@
+package tunnel
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExpiryRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := expiryRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("expiryRetryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRunDueExpiriesBacksOff(t *testing.T) {
+	svc, cf, _ := testService(t)
+	svc.expiries = NewExpiryStore(filepath.Join(t.TempDir(), "expiries.json"))
+	ctx := context.Background()
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: AccessLevelPublic}); err != nil {
+		t.Fatal(err)
+	}
+	due := Expiry{Kind: ExpiryTTL, Subdomain: "api", At: time.Now().Add(-time.Minute).Truncate(time.Second)}
+	if err := svc.expiries.Set(due); err != nil {
+		t.Fatal(err)
+	}
+
+	cf.FailOn("RemoveDNSRoute", errInjected)
+	out, err := captureStdout(t, func() error {
+		applied, err := svc.RunDueExpiries(ctx)
+		if applied != 0 {
+			t.Errorf("applied = %d, want 0", applied)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Retrying at") {
+		t.Errorf("no retry notice in output:\n%s", out)
+	}
+
+	failed, ok, err := svc.expiries.Get(ExpiryTTL, "api", "")
+	if err != nil || !ok {
+		t.Fatalf("expiry gone after a failed apply: %v", err)
+	}
+	if failed.Attempts != 1 || !failed.RetryAt.After(time.Now()) || failed.LastError == "" {
+		t.Errorf("failure not recorded: %+v", failed)
+	}
+
+	// before its retry time the expiry is skipped, even once the failure is fixed
+	cf.FailOn("RemoveDNSRoute", nil)
+	out, err = captureStdout(t, func() error {
+		_, err := svc.RunDueExpiries(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "applying") {
+		t.Errorf("expiry retried before its retry time:\n%s", out)
+	}
+
+	// once the retry is due it is applied
+	failed.RetryAt = time.Now().Add(-time.Second)
+	if err := svc.expiries.Set(failed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := captureStdout(t, func() error {
+		applied, err := svc.RunDueExpiries(ctx)
+		if applied != 1 {
+			t.Errorf("applied = %d, want 1", applied)
+		}
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if hosts := ingressHosts(t, svc); len(hosts) != 0 {
+		t.Errorf("ingress after the retried TTL = %v", hosts)
+	}
+}
+
+func TestExpiryStoreConcurrentSets(t *testing.T) {
+	store := NewExpiryStore(filepath.Join(t.TempDir(), "expiries.json"))
+	at := time.Now().Add(time.Hour)
+
+	var wg sync.WaitGroup
+	for _, sub := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.Set(Expiry{Kind: ExpiryTTL, Subdomain: sub, At: at}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	expiries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiries) != 8 {
+		t.Errorf("got %d expiries, want 8: concurrent writes were lost", len(expiries))
+	}
+}
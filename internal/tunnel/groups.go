@@ -0,0 +1,430 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"orb/internal/audit"
+	"orb/internal/dns"
+	"orb/internal/plan"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Access group export formats
+const (
+	GroupsYAML = "yaml"
+	GroupsCSV  = "csv"
+)
+
+// GroupsFile is the declarative form of the Access groups read by `access sync`
+// and written by `access export`. Members are email addresses or rules such as
+// email-domain:example.com and group:contractors.
+type GroupsFile struct {
+	Groups map[string][]string `yaml:"groups"`
+}
+
+// groupChange is what SyncAccessGroups does to one group
+type groupChange struct {
+	name    string
+	create  bool       // the group does not exist yet
+	remove  bool       // the group is not in the file (deleted with prune)
+	added   []string   // members, as written in the file
+	removed []string   // members, as written in the file
+	members []dns.Rule // every member once synced
+}
+
+// ExportAccessGroups prints one group, or all of them, in the format `access sync` reads (yaml) or as CSV
+func (s *Service) ExportAccessGroups(ctx context.Context, groupName, format string) error {
+	if format != GroupsYAML && format != GroupsCSV {
+		return fmt.Errorf("invalid --format %q: use yaml or csv", format)
+	}
+
+	var names []string
+	if groupName != "" {
+		names = []string{groupName}
+	} else {
+		groups, err := s.cloudflare.ListAccessGroups(ctx)
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			names = append(names, group.Name)
+		}
+	}
+
+	file := GroupsFile{Groups: make(map[string][]string)}
+	for _, name := range names {
+		rules, err := s.cloudflare.GetAccessGroupRules(ctx, name)
+		if err != nil {
+			return err
+		}
+		members := make([]string, 0, len(rules))
+		for _, rule := range rules {
+			members = append(members, dns.FormatMember(rule))
+		}
+		sort.Strings(members)
+		file.Groups[name] = members
+	}
+
+	if format == GroupsCSV {
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"group", "member"})
+		for _, name := range names {
+			for _, member := range file.Groups[name] {
+				_ = w.Write([]string{name, member})
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode access groups: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+// LoadGroupsFile reads and validates a groups file, returning the members of each group
+func LoadGroupsFile(path string) (map[string][]dns.Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read groups file: %w", err)
+	}
+
+	var file GroupsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(file.Groups) == 0 {
+		return nil, fmt.Errorf("%s has no groups (e.g., groups: {friends: [alice@example.com, email-domain:example.com]})", path)
+	}
+
+	groups := make(map[string][]dns.Rule, len(file.Groups))
+	for name, members := range file.Groups {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%s: group names cannot be empty", path)
+		}
+		if len(members) == 0 {
+			return nil, fmt.Errorf("%s: group %q has no members - leave it out of the file (and use --prune) to delete it", path, name)
+		}
+		var rules []dns.Rule
+		for _, member := range members {
+			rule, err := dns.ParseMember(member)
+			if err != nil {
+				return nil, fmt.Errorf("%s: group %q: %w", path, name, err)
+			}
+			if rule.Type == dns.RuleGroup && rule.Value == name {
+				return nil, fmt.Errorf("%s: group %q cannot include itself", path, name)
+			}
+			if !slices.Contains(rules, rule) {
+				rules = append(rules, rule)
+			}
+		}
+		groups[name] = rules
+	}
+	return groups, nil
+}
+
+// SyncAccessGroups reconciles every Access group to a groups file: it shows the
+// members each group gains and loses, then applies them. Groups missing from the
+// file are only deleted with prune, and never while a service or another group
+// still uses them.
+func (s *Service) SyncAccessGroups(ctx context.Context, path string, prune bool) error {
+	desired, err := LoadGroupsFile(path)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.cloudflare.ListAccessGroups(ctx)
+	if err != nil {
+		return err
+	}
+	current := make(map[string][]dns.Rule, len(existing))
+	for _, group := range existing {
+		rules, err := s.cloudflare.GetAccessGroupRules(ctx, group.Name)
+		if err != nil {
+			return err
+		}
+		current[group.Name] = rules
+	}
+
+	changes, err := groupChanges(current, desired, prune)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	var kept []string // not in the file, left alone without prune
+	for _, change := range changes {
+		if change.remove && !prune {
+			kept = append(kept, change.name)
+		}
+	}
+	if len(kept) == len(changes) {
+		fmt.Printf("✔ Access groups already match %s\n", path)
+		printKeptGroups(kept, path)
+		return nil
+	}
+
+	if s.dryRun {
+		p := plan.New()
+		for _, change := range changes {
+			for _, line := range change.lines(prune) {
+				p.Add("Access groups", line[0], "%s", line[1])
+			}
+		}
+		p.Print()
+		return nil
+	}
+
+	fmt.Printf("Syncing Access groups to %s:\n", path)
+	for _, change := range changes {
+		for _, line := range change.lines(prune) {
+			fmt.Printf("  %s %s\n", line[0], line[1])
+		}
+	}
+	fmt.Println()
+
+	var synced int
+	for _, change := range changes {
+		if change.remove {
+			continue
+		}
+		id, err := s.cloudflare.SetAccessGroupRules(ctx, change.name, change.members)
+		if err != nil {
+			return fmt.Errorf("failed to sync group %q (%d group(s) already synced - run sync again once fixed): %w", change.name, synced, err)
+		}
+		synced++
+
+		s.record(audit.Entry{
+			Action:  "access.sync",
+			Subject: change.name,
+			Before:  audit.Fields("members", dns.JoinRules(current[change.name])),
+			After:   audit.Fields("members", dns.JoinRules(change.members)),
+			IDs:     audit.Fields("access_group", id),
+		})
+		if change.create {
+			fmt.Printf("✔ Created group %q with %d member(s)\n", change.name, len(change.members))
+		} else {
+			fmt.Printf("✔ Updated group %q: %d added, %d removed\n", change.name, len(change.added), len(change.removed))
+		}
+	}
+
+	for _, change := range changes {
+		if !change.remove || !prune {
+			continue
+		}
+		// a group still in use is kept, the rest of the prune goes on
+		if err := s.DeleteAccessGroup(ctx, change.name, false); err != nil {
+			fmt.Printf("⚠ Warning: kept group %q: %v\n", change.name, err)
+			continue
+		}
+		fmt.Printf("✔ Deleted group %q\n", change.name)
+	}
+	printKeptGroups(kept, path)
+	return nil
+}
+
+// printKeptGroups mentions the groups sync left alone because they are not in the file
+func printKeptGroups(kept []string, path string) {
+	if len(kept) > 0 {
+		fmt.Printf("ℹ️  Kept %d group(s) not in %s: %s (use --prune to delete them)\n", len(kept), path, strings.Join(kept, ", "))
+	}
+}
+
+// groupChanges compares the current groups with the desired ones. Groups to
+// create or update come first, ordered so that groups exist before others
+// include them; groups missing from desired come last, ordered so that groups
+// are deleted before the groups they include.
+func groupChanges(current, desired map[string][]dns.Rule, prune bool) ([]groupChange, error) {
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []groupChange
+	for _, name := range names {
+		have, exists := current[name]
+		change := groupChange{name: name, create: !exists, members: desired[name]}
+		for _, rule := range desired[name] {
+			if !slices.Contains(have, rule) {
+				change.added = append(change.added, dns.FormatMember(rule))
+			}
+		}
+		for _, rule := range have {
+			if !slices.Contains(desired[name], rule) {
+				change.removed = append(change.removed, dns.FormatMember(rule))
+			}
+		}
+
+		for _, rule := range desired[name] {
+			if rule.Type == dns.RuleGroup {
+				if _, ok := desired[rule.Value]; !ok {
+					if _, ok := current[rule.Value]; !ok {
+						return nil, fmt.Errorf("group %q includes group %q, which is neither in the file nor in Cloudflare", name, rule.Value)
+					}
+					if prune {
+						return nil, fmt.Errorf("group %q includes group %q, which is not in the file and would be pruned - add it to the file or drop the reference", name, rule.Value)
+					}
+				}
+			}
+		}
+		if change.create || len(change.added) > 0 || len(change.removed) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	ordered, err := orderGroupChanges(changes, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra []string
+	for name := range current {
+		if _, ok := desired[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range orderGroupRemovals(extra, current) {
+		change := groupChange{name: name, remove: true}
+		for _, rule := range current[name] {
+			change.removed = append(change.removed, dns.FormatMember(rule))
+		}
+		ordered = append(ordered, change)
+	}
+	return ordered, nil
+}
+
+// orderGroupRemovals puts every group before the groups it includes, so none is
+// deleted while another group still refers to it. Groups in a cycle keep their order.
+func orderGroupRemovals(names []string, current map[string][]dns.Rule) []string {
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[name] = true
+	}
+
+	var ordered []string
+	for len(ordered) < len(names) {
+		progress := false
+		for _, name := range names {
+			if !pending[name] || includedByPending(name, names, pending, current) {
+				continue
+			}
+			ordered = append(ordered, name)
+			pending[name] = false
+			progress = true
+		}
+		if !progress {
+			for _, name := range names {
+				if pending[name] {
+					ordered = append(ordered, name)
+				}
+			}
+			break
+		}
+	}
+	return ordered
+}
+
+// includedByPending reports whether another pending group includes group:name
+func includedByPending(name string, names []string, pending map[string]bool, current map[string][]dns.Rule) bool {
+	for _, other := range names {
+		if other != name && pending[other] && slices.Contains(current[other], dns.Rule{Type: dns.RuleGroup, Value: name}) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupIncluders returns the groups that have group:name as a member
+func (s *Service) groupIncluders(ctx context.Context, name string) ([]string, error) {
+	groups, err := s.cloudflare.ListAccessGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var includers []string
+	for _, group := range groups {
+		if group.Name == name {
+			continue
+		}
+		rules, err := s.cloudflare.GetAccessGroupRules(ctx, group.Name)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(rules, dns.Rule{Type: dns.RuleGroup, Value: name}) {
+			includers = append(includers, group.Name)
+		}
+	}
+	sort.Strings(includers)
+	return includers, nil
+}
+
+// orderGroupChanges puts every change after the changes of the groups it includes
+func orderGroupChanges(changes []groupChange, desired map[string][]dns.Rule) ([]groupChange, error) {
+	pending := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		pending[change.name] = true
+	}
+
+	var ordered []groupChange
+	for len(ordered) < len(changes) {
+		progress := false
+		for _, change := range changes {
+			if !pending[change.name] {
+				continue
+			}
+			ready := true
+			for _, rule := range desired[change.name] {
+				if rule.Type == dns.RuleGroup && pending[rule.Value] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, change)
+				pending[change.name] = false
+				progress = true
+			}
+		}
+		if !progress {
+			var cycle []string
+			for _, change := range changes {
+				if pending[change.name] {
+					cycle = append(cycle, change.name)
+				}
+			}
+			return nil, fmt.Errorf("groups %s include each other", strings.Join(cycle, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+// lines describes the change as plan markers and text, one line per member
+func (c groupChange) lines(prune bool) [][2]string {
+	switch {
+	case c.remove && !prune:
+		return [][2]string{{" ", fmt.Sprintf("group %s is not in the file (kept - use --prune to delete it)", c.name)}}
+	case c.remove:
+		return [][2]string{{plan.Delete, fmt.Sprintf("group %s (%d member(s))", c.name, len(c.removed))}}
+	}
+
+	var lines [][2]string
+	if c.create {
+		lines = append(lines, [2]string{plan.Create, fmt.Sprintf("group %s", c.name)})
+	}
+	for _, member := range c.added {
+		lines = append(lines, [2]string{plan.Create, fmt.Sprintf("%s: %s", c.name, member)})
+	}
+	for _, member := range c.removed {
+		lines = append(lines, [2]string{plan.Delete, fmt.Sprintf("%s: %s", c.name, member)})
+	}
+	return lines
+}
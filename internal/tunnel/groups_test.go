@@ -0,0 +1,77 @@
+package tunnel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"orb/internal/dns"
+	"orb/internal/dns/dnstest"
+)
+
+func TestDeleteAccessGroupIncludedByAnotherGroup(t *testing.T) {
+	svc, cf, _ := testService(t)
+	cf.Groups["family"] = &dnstest.Group{ID: "group-family", Name: "family", Rules: []dns.Rule{{Type: dns.RuleGroup, Value: "friends"}}}
+
+	err := svc.DeleteAccessGroup(context.Background(), "friends", true)
+	if err == nil || !strings.Contains(err.Error(), "family") {
+		t.Fatalf("DeleteAccessGroup = %v, want a refusal naming family", err)
+	}
+	if _, ok := cf.Groups["friends"]; !ok {
+		t.Error("group deleted although another group includes it")
+	}
+}
+
+func TestSyncAccessGroupsPrune(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+		kept    []string
+		deleted []string
+	}{
+		{
+			name:    "file includes a group prune would delete",
+			file:    "groups:\n  team:\n    - group:friends\n",
+			wantErr: "would be pruned",
+			kept:    []string{"friends", "old"},
+		},
+		{
+			name:    "pruned group includes another pruned group",
+			file:    "groups:\n  team:\n    - carol@example.com\n",
+			kept:    []string{"team"},
+			deleted: []string{"friends", "old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, cf, _ := testService(t)
+			cf.Groups["old"] = &dnstest.Group{ID: "group-old", Name: "old", Rules: []dns.Rule{{Type: dns.RuleGroup, Value: "friends"}}}
+			path := filepath.Join(t.TempDir(), "groups.yaml")
+			if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := captureStdout(t, func() error { return svc.SyncAccessGroups(context.Background(), path, true) })
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatal(err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("SyncAccessGroups = %v, want an error containing %q", err, tt.wantErr)
+			}
+			for _, name := range tt.kept {
+				if _, ok := cf.Groups[name]; !ok {
+					t.Errorf("group %q deleted", name)
+				}
+			}
+			for _, name := range tt.deleted {
+				if _, ok := cf.Groups[name]; ok {
+					t.Errorf("group %q not pruned", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"orb/internal/dns"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Access matrix output formats
+const (
+	MatrixTable = "table"
+	MatrixCSV   = "csv"
+	MatrixJSON  = "json"
+)
+
+// MatrixEntry is one row of the access matrix: a principal that can reach a hostname and why
+type MatrixEntry struct {
+	Hostname   string `json:"hostname"`
+	Principal  string `json:"principal"`            // an email, or a rule like email-domain:example.com
+	Via        string `json:"via"`                  // owner, group:<name>, rule, service token, share, public...
+	Conditions string `json:"conditions,omitempty"` // require and exclude rules that also apply
+	Until      string `json:"until,omitempty"`      // when orb takes the access away (RFC 3339)
+}
+
+// MatrixFilter narrows the access matrix to one user and/or one service
+type MatrixFilter struct {
+	User    string // email address; rows for their domain and everyone match too
+	Service string // subdomain
+}
+
+// AccessMatrix joins the ingress rules, Access policies, group memberships and
+// pending expiries of every exposed service into hostname × principal rows
+func (s *Service) AccessMatrix(ctx context.Context, filter MatrixFilter) ([]MatrixEntry, error) {
+	filter.User = strings.ToLower(strings.TrimSpace(filter.User))
+	if filter.Service != "" {
+		if err := ValidateSubdomain(filter.Service); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := s.config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+	for _, rule := range cfg.Ingress {
+		if rule.Hostname == "" {
+			continue
+		}
+		if filter.Service != "" && SubdomainFor(rule.Hostname, s.env.Domain) != filter.Service {
+			continue
+		}
+		hosts = append(hosts, rule.Hostname)
+	}
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	// list the Access applications once rather than once per hostname
+	accesses, err := s.cloudflare.ListAccess(ctx, hosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	members := make(map[string][]dns.Rule) // group -> member rules, looked up once per group
+	var entries []MatrixEntry
+	for _, host := range hosts {
+		rows, err := s.matrixRows(ctx, host, SubdomainFor(host, s.env.Domain), accesses[host], members)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if filter.User == "" || row.covers(filter.User) {
+				entries = append(entries, row)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// matrixRows lists who can reach one hostname
+func (s *Service) matrixRows(ctx context.Context, host, subdomain string, access *dns.Access, members map[string][]dns.Rule) ([]MatrixEntry, error) {
+	deadlines, err := s.matrixDeadlines(subdomain)
+	if err != nil {
+		return nil, err
+	}
+	if access == nil {
+		return []MatrixEntry{{Hostname: host, Principal: dns.RuleEveryone, Via: "public", Until: deadlines.until(ExpiryTTL, "")}}, nil
+	}
+
+	var rows []MatrixEntry
+	for _, policy := range access.Policies {
+		conditions := matrixConditions(policy)
+		for _, rule := range policy.Include {
+			row := MatrixEntry{Hostname: host, Principal: rule.String(), Via: "rule", Conditions: conditions, Until: deadlines.until(ExpiryAccess, "")}
+			switch kind := policy.Kind(); {
+			case kind == "owner":
+				row.Via, row.Until = "owner", deadlines.until(ExpiryTTL, "")
+			case kind == "share":
+				row.Via, row.Until = "share", deadlines.until(ExpiryShare, rule.Value)
+			case kind == "tokens" || rule.Type == dns.RuleServiceToken:
+				row.Via = "service token"
+			case kind == "":
+				row.Via = "policy " + policy.Name
+			}
+			if rule.Type == dns.RuleEmail {
+				row.Principal = rule.Value
+			}
+
+			if rule.Type != dns.RuleGroup {
+				rows = append(rows, row)
+				continue
+			}
+
+			// expand groups into their members, including those of nested groups
+			row.Via = "group:" + rule.Value
+			if policy.Kind() == "group" {
+				row.Until = deadlines.until(ExpiryGroup, rule.Value)
+			}
+			expanded, err := s.groupMembers(ctx, rule.Value, members, map[string]bool{})
+			if err != nil {
+				return nil, err
+			}
+			if len(expanded) == 0 {
+				rows = append(rows, row) // keep the grant visible even without members
+			}
+			for _, member := range expanded {
+				entry := row
+				entry.Principal = member.String()
+				if member.Type == dns.RuleEmail {
+					entry.Principal = strings.ToLower(member.Value)
+				}
+				rows = append(rows, entry)
+			}
+		}
+	}
+
+	for _, path := range access.PublicPaths {
+		rows = append(rows, MatrixEntry{Hostname: host + path, Principal: dns.RuleEveryone, Via: "public path", Until: deadlines.until(ExpiryTTL, "")})
+	}
+	return rows, nil
+}
+
+// groupMembers returns the member rules of a group with nested groups replaced by
+// their own members. seen holds the groups being expanded, so a cycle ends there.
+func (s *Service) groupMembers(ctx context.Context, name string, members map[string][]dns.Rule, seen map[string]bool) ([]dns.Rule, error) {
+	if seen[name] {
+		return nil, nil
+	}
+	seen[name] = true
+	defer delete(seen, name)
+
+	rules, ok := members[name]
+	if !ok {
+		var err error
+		if rules, err = s.cloudflare.GetAccessGroupRules(ctx, name); err != nil {
+			return nil, fmt.Errorf("failed to get members of group %q: %w", name, err)
+		}
+		members[name] = rules
+	}
+
+	var expanded []dns.Rule
+	for _, rule := range rules {
+		nested := []dns.Rule{rule}
+		if rule.Type == dns.RuleGroup {
+			var err error
+			if nested, err = s.groupMembers(ctx, rule.Value, members, seen); err != nil {
+				return nil, err
+			}
+		}
+		for _, member := range nested {
+			if !slices.Contains(expanded, member) {
+				expanded = append(expanded, member)
+			}
+		}
+	}
+	return expanded, nil
+}
+
+// matrixConditions renders the require and exclude rules of a policy
+func matrixConditions(policy dns.Policy) string {
+	var conditions []string
+	if len(policy.Require) > 0 {
+		conditions = append(conditions, "require "+dns.JoinRules(policy.Require))
+	}
+	if len(policy.Exclude) > 0 {
+		conditions = append(conditions, "exclude "+dns.JoinRules(policy.Exclude))
+	}
+	return strings.Join(conditions, "; ")
+}
+
+// covers reports whether the row grants access to the given (lowercase) email
+// address: directly, through its email domain or to everyone
+func (e MatrixEntry) covers(email string) bool {
+	principal := strings.ToLower(e.Principal)
+	switch {
+	case principal == email, principal == dns.RuleEveryone:
+		return true
+	case strings.HasPrefix(principal, dns.RuleEmailDomain+":"):
+		domain := strings.TrimPrefix(strings.TrimPrefix(principal, dns.RuleEmailDomain+":"), "@")
+		return strings.HasSuffix(email, "@"+domain)
+	}
+	return false
+}
+
+// matrixDeadlines are the pending expiries of one subdomain
+type matrixDeadlines []Expiry
+
+// matrixDeadlines returns the pending expiries of a subdomain
+func (s *Service) matrixDeadlines(subdomain string) (matrixDeadlines, error) {
+	expiries, err := s.expiries.ForSubdomain(subdomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expiries of %s: %w", subdomain, err)
+	}
+	return expiries, nil
+}
+
+// until returns the first deadline that ends access granted through kind and
+// target: its own expiry, or the TTL that removes the whole service
+func (d matrixDeadlines) until(kind, target string) string {
+	var first time.Time
+	for _, e := range d {
+		if (e.Kind == kind && e.Target == target) || e.Kind == ExpiryTTL {
+			if first.IsZero() || e.At.Before(first) {
+				first = e.At
+			}
+		}
+	}
+	if first.IsZero() {
+		return ""
+	}
+	return first.Format(time.RFC3339)
+}
+
+// PrintAccessMatrix prints the access matrix as a table, CSV or JSON
+func (s *Service) PrintAccessMatrix(ctx context.Context, filter MatrixFilter, format string) error {
+	switch format {
+	case MatrixTable, MatrixCSV, MatrixJSON:
+	default:
+		return fmt.Errorf("invalid --format %q: use table, csv or json", format)
+	}
+
+	entries, err := s.AccessMatrix(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case MatrixCSV:
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"hostname", "principal", "via", "conditions", "until"})
+		for _, e := range entries {
+			_ = w.Write([]string{e.Hostname, e.Principal, e.Via, e.Conditions, e.Until})
+		}
+		w.Flush()
+		return w.Error()
+	case MatrixJSON:
+		if entries == nil {
+			entries = []MatrixEntry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode access matrix: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No access found")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Hostname", "Principal", "Via", "Conditions", "Until")
+	for _, e := range entries {
+		until := "-"
+		if at, err := time.Parse(time.RFC3339, e.Until); err == nil {
+			until = at.Format("2006-01-02 15:04")
+		}
+		conditions := e.Conditions
+		if conditions == "" {
+			conditions = "-"
+		}
+		if err := table.Append(e.Hostname, e.Principal, e.Via, conditions, until); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nAccess matrix (%d rows):\n", len(entries))
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package tunnel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"orb/internal/dns"
+	"orb/internal/dns/dnstest"
+)
+
+func TestAccessMatrixExpandsNestedGroups(t *testing.T) {
+	svc, cf, _ := testService(t)
+	ctx := context.Background()
+	cf.Groups["team"] = &dnstest.Group{ID: "group-team", Name: "team", Emails: []string{"Alice@example.com"}, Rules: []dns.Rule{
+		{Type: dns.RuleGroup, Value: "contractors"},
+	}}
+	cf.Groups["contractors"] = &dnstest.Group{ID: "group-contractors", Name: "contractors", Rules: []dns.Rule{
+		{Type: dns.RuleEmailDomain, Value: "acme.com"},
+		{Type: dns.RuleGroup, Value: "team"}, // a cycle
+	}}
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: "team"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := svc.AccessMatrix(ctx, MatrixFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var principals []string
+	for _, e := range entries {
+		if e.Via == "group:team" {
+			principals = append(principals, e.Principal)
+		}
+	}
+	if want := []string{"alice@example.com", "email-domain:acme.com"}; !slices.Equal(principals, want) {
+		t.Errorf("group:team rows = %v, want %v", principals, want)
+	}
+
+	tests := []struct {
+		user string
+		want bool
+	}{
+		{"alice@example.com", true},
+		{"bob@acme.com", true},
+		{"mallory@notacme.com", false},
+	}
+	for _, tt := range tests {
+		entries, err := svc.AccessMatrix(ctx, MatrixFilter{User: tt.user})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := slices.ContainsFunc(entries, func(e MatrixEntry) bool { return e.Via == "group:team" })
+		if got != tt.want {
+			t.Errorf("--user %s reaches api through team = %v, want %v", tt.user, got, tt.want)
+		}
+	}
+}
+
+func TestAccessMatrixListsAccessOnce(t *testing.T) {
+	svc, cf, _ := testService(t)
+	ctx := context.Background()
+	for _, sub := range []string{"api", "web", "docs"} {
+		if err := svc.Expose(ctx, sub, "8080", "http", ExposeOptions{Access: "private"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// per-hostname lookups would list every application again for each service
+	cf.FailOn("GetAccess", errInjected)
+	entries, err := svc.AccessMatrix(ctx, MatrixFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hosts []string
+	for _, e := range entries {
+		if !slices.Contains(hosts, e.Hostname) {
+			hosts = append(hosts, e.Hostname)
+		}
+	}
+	if want := []string{"api.example.com", "web.example.com", "docs.example.com"}; !slices.Equal(hosts, want) {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+
+	cf.FailOn("ListAccess", errInjected)
+	if _, err := svc.AccessMatrix(ctx, MatrixFilter{}); err == nil {
+		t.Error("AccessMatrix succeeded although listing access failed")
+	}
+}
+
+func TestAccessMatrixReportsUnreadableExpiries(t *testing.T) {
+	svc, _, _ := testService(t)
+	ctx := context.Background()
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: "private"}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "expiries.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc.expiries = NewExpiryStore(path)
+	if _, err := svc.AccessMatrix(ctx, MatrixFilter{}); err == nil {
+		t.Error("AccessMatrix succeeded without its expiries, want an error")
+	}
+}
@@ -0,0 +1,184 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"orb/internal/audit"
+	"orb/internal/dns"
+	"orb/internal/plan"
+)
+
+// OwnersFromEnv returns who the precedence-1 owner policy of every protected service
+// lets in: the email addresses in OWNER_EMAIL and USER_EMAIL (comma-separated, either
+// or both) and the Access group named by OWNER_GROUP, e.g. an admin group
+func OwnersFromEnv() ([]dns.Rule, error) {
+	var owners []dns.Rule
+	for _, key := range []string{"OWNER_EMAIL", "USER_EMAIL"} {
+		for _, email := range strings.Split(os.Getenv(key), ",") {
+			if strings.TrimSpace(email) == "" {
+				continue
+			}
+			rule, err := dns.ParseRule(dns.RuleEmail + ":" + email)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: use comma-separated email addresses (e.g., alice@example.com,bob@example.com)", key, os.Getenv(key))
+			}
+			if !slices.Contains(owners, rule) {
+				owners = append(owners, rule)
+			}
+		}
+	}
+	if group := strings.TrimSpace(os.Getenv("OWNER_GROUP")); group != "" {
+		owners = append(owners, dns.Rule{Type: dns.RuleGroup, Value: group})
+	}
+	return owners, nil
+}
+
+// errNoOwners is the error for an action that needs owners when none are configured
+func errNoOwners(what string) error {
+	return fmt.Errorf("OWNER_EMAIL (or USER_EMAIL or OWNER_GROUP) environment variable required %s", what)
+}
+
+// ShowOwners prints the configured owners and the exposed services whose owner
+// policy still lets someone else in
+func (s *Service) ShowOwners(ctx context.Context) error {
+	owners, err := OwnersFromEnv()
+	if err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		fmt.Println("No owners configured - set OWNER_EMAIL (comma-separated) and/or OWNER_GROUP")
+		return nil
+	}
+
+	fmt.Printf("Owners (%d):\n", len(owners))
+	for _, owner := range owners {
+		fmt.Printf("  • %s\n", dns.FormatMember(owner))
+	}
+
+	stale, err := s.staleOwners(ctx, owners)
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		fmt.Println("\n✔ Every protected service lets these owners in")
+		return nil
+	}
+
+	fmt.Printf("\n⚠ %d service(s) have different owners:\n", len(stale))
+	for _, service := range stale {
+		fmt.Printf("  • %s: %s\n", service.host, describeOwners(service.access.Owners()))
+	}
+	fmt.Println("\nMigrate them with `orb access owners sync`")
+	return nil
+}
+
+// SyncOwners migrates the owner policy of every protected service to the configured
+// owners, keeping its other policies
+func (s *Service) SyncOwners(ctx context.Context) error {
+	owners, err := OwnersFromEnv()
+	if err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		return errNoOwners("to sync the owner policies")
+	}
+	for _, owner := range owners {
+		if owner.Type == dns.RuleGroup {
+			if err := s.requireGroup(ctx, owner.Value); err != nil {
+				return fmt.Errorf("OWNER_GROUP: %w", err)
+			}
+		}
+	}
+
+	stale, err := s.staleOwners(ctx, owners)
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		fmt.Println("✔ Every protected service already lets these owners in")
+		return nil
+	}
+
+	if s.dryRun {
+		p := plan.New()
+		for _, service := range stale {
+			p.Add("Access", plan.Modify, "policy orb-%s-owner: allow %s (was %s)", service.host, describeOwners(owners), describeOwners(service.access.Owners()))
+		}
+		p.Print()
+		return nil
+	}
+
+	for _, service := range stale {
+		host, before := service.host, service.access
+		fmt.Printf("Updating owners of %s...\n", host)
+		appID, err := s.applyAccessChange(ctx, host, before, before.WithOwners(host, owners))
+		if err != nil {
+			return fmt.Errorf("failed to update owners of %s: %w", host, err)
+		}
+
+		s.record(audit.Entry{
+			Action:  "access.owners",
+			Subject: host,
+			Before:  audit.Fields("owners", dns.JoinRules(before.Owners())),
+			After:   audit.Fields("owners", dns.JoinRules(owners)),
+			IDs:     audit.Fields("access_app", appID),
+		})
+	}
+
+	fmt.Printf("✔ Updated the owners of %d service(s) to %s\n", len(stale), describeOwners(owners))
+	return nil
+}
+
+// ownedService is a protected service and its current Access application
+type ownedService struct {
+	host   string
+	access *dns.Access
+}
+
+// staleOwners returns the protected services whose owner policy differs from owners
+func (s *Service) staleOwners(ctx context.Context, owners []dns.Rule) ([]ownedService, error) {
+	cfg, err := s.config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+	for _, rule := range cfg.Ingress {
+		if rule.Hostname != "" {
+			hosts = append(hosts, rule.Hostname)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	// list the Access applications once rather than once per hostname
+	accesses, err := s.cloudflare.ListAccess(ctx, hosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	var stale []ownedService
+	for _, host := range hosts {
+		if access := accesses[host]; access != nil && !dns.SameRules(access.Owners(), owners) {
+			stale = append(stale, ownedService{host: host, access: access})
+		}
+	}
+	return stale, nil
+}
+
+// describeOwners renders owner rules the way they are configured
+func describeOwners(owners []dns.Rule) string {
+	if len(owners) == 0 {
+		return "nobody"
+	}
+	parts := make([]string, 0, len(owners))
+	for _, owner := range owners {
+		parts = append(parts, dns.FormatMember(owner))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,58 @@
+package tunnel
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"orb/internal/dns"
+)
+
+func TestSyncOwnersListsAccessOnce(t *testing.T) {
+	svc, cf, _ := testService(t)
+	ctx := context.Background()
+	for _, sub := range []string{"api", "web"} {
+		if err := svc.Expose(ctx, sub, "8080", "http", ExposeOptions{Access: "private"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("USER_EMAIL", "")
+	t.Setenv("OWNER_EMAIL", "alice@example.com")
+	owners, err := OwnersFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// per-hostname lookups would list every application again for each service
+	cf.FailOn("GetAccess", errInjected)
+	stale, err := svc.staleOwners(ctx, owners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hosts []string
+	for _, service := range stale {
+		hosts = append(hosts, service.host)
+	}
+	if want := []string{"api.example.com", "web.example.com"}; !slices.Equal(hosts, want) {
+		t.Fatalf("stale owners = %v, want %v", hosts, want)
+	}
+
+	cf.FailOn("GetAccess", nil)
+	if _, err := captureStdout(t, func() error { return svc.SyncOwners(ctx) }); err != nil {
+		t.Fatal(err)
+	}
+	accesses, err := cf.ListAccess(ctx, []string{"api.example.com", "web.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for host, access := range accesses {
+		if got := access.Owners(); !dns.SameRules(got, owners) {
+			t.Errorf("owners of %s = %v, want %v", host, got, owners)
+		}
+	}
+
+	cf.FailOn("ListAccess", errInjected)
+	if _, err := svc.staleOwners(ctx, owners); err == nil {
+		t.Error("staleOwners succeeded although listing access failed")
+	}
+}
@@ -0,0 +1,449 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"orb/internal/dns"
+	"orb/internal/plan"
+)
+
+// planExpose prints the changes Expose would make without applying them
+func (s *Service) planExpose(ctx context.Context, before, after *Config, host, accessLevel string, grants []GroupGrant, expires, ttl string, rules dns.AccessRules, publicPaths []string, settings dns.AppSettings) error {
+	p := plan.New()
+	if err := s.planConfig(p, before, after); err != nil {
+		return err
+	}
+
+	p.Add("DNS", plan.Create, "CNAME %s → %s.cfargotunnel.com (proxied)", host, after.Tunnel)
+	s.planAccessPolicy(ctx, p, host, accessLevel, rules)
+	if summary := settingsSummary(settings); summary != "" {
+		p.Add("Access", plan.Modify, "settings of orb-%s: %s", host, summary)
+	}
+	for _, path := range publicPaths {
+		p.Add("Access", plan.Create, "application orb-%s%s (self_hosted, domain %s%s)", host, path, host, path)
+		p.Add("Access", plan.Create, "  policy orb-%s-bypass: bypass everyone", host)
+	}
+	s.planRestart(ctx, p, after.Tunnel)
+
+	if expires != "" {
+		p.Add("Expiry", plan.Create, "revert %s to private after %s (%s)", host, expires, expiryBackendName(s.env.ExpiryBackend))
+	}
+	planGroupExpiries(p, host, grants)
+	if ttl != "" {
+		p.Add("Expiry", plan.Create, "unexpose %s after %s (%s)", host, ttl, expiryBackendName(s.env.ExpiryBackend))
+	}
+
+	p.Print()
+	return nil
+}
+
+// planUnexpose prints the changes Unexpose would make without applying them
+func (s *Service) planUnexpose(ctx context.Context, before, after *Config, subdomain, host string) error {
+	p := plan.New()
+	if err := s.planConfig(p, before, after); err != nil {
+		return err
+	}
+
+	p.Add("DNS", plan.Delete, "CNAME %s", host)
+	if access, err := s.cloudflare.GetAccess(ctx, host); err != nil {
+		p.Note("Access", "⚠ could not read access policies: %v", err)
+	} else if access != nil {
+		p.Add("Access", plan.Delete, "application orb-%s and its policies (currently %s)", host, access)
+		planRemovePublicPaths(p, host, access)
+	}
+	s.planRestart(ctx, p, after.Tunnel)
+
+	s.planCancelExpiries(p, subdomain, expiryKinds...)
+
+	p.Print()
+	return nil
+}
+
+// planUpdate prints the changes Update would make without applying them
+func (s *Service) planUpdate(ctx context.Context, before, after *Config) error {
+	p := plan.New()
+	if err := s.planConfig(p, before, after); err != nil {
+		return err
+	}
+	s.planRestart(ctx, p, after.Tunnel)
+
+	p.Print()
+	return nil
+}
+
+// planExtendExpiry prints the deadline change ExtendExpiry would make without applying it
+func (s *Service) planExtendExpiry(host string, expiry Expiry, to time.Time) error {
+	p := plan.New()
+	p.Add("Expiry", plan.Modify, "%s %s at %s (was %s)", expiry.Action(), host, to.Format("2006-01-02 15:04:05"), expiry.At.Format("2006-01-02 15:04:05"))
+	p.Note("Expiry", "timer %s is replaced", expiry.Unit())
+	p.Print()
+	return nil
+}
+
+// planCancelExpiry adds the removal of a pending expiry and its timer, if there is one
+func (s *Service) planCancelExpiry(p *plan.Plan, kind, subdomain, target string) {
+	if expiry, ok, _ := s.expiries.Get(kind, subdomain, target); ok {
+		p.Add("Expiry", plan.Delete, "%s timer %s (was due %s)", expiry.Label(), expiry.Unit(), expiry.At.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// planCancelExpiries adds the removal of every pending expiry of subdomain whose kind is listed
+func (s *Service) planCancelExpiries(p *plan.Plan, subdomain string, kinds ...string) {
+	expiries, _ := s.expiries.ForSubdomain(subdomain)
+	for _, e := range expiries {
+		for _, kind := range kinds {
+			if e.Kind == kind {
+				s.planCancelExpiry(p, e.Kind, e.Subdomain, e.Target)
+			}
+		}
+	}
+}
+
+// planRevokeAccess prints the changes RevokeAccess would make without applying them
+func (s *Service) planRevokeAccess(ctx context.Context, subdomain, host string) error {
+	p := plan.New()
+
+	access, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+	if !access.Shared() {
+		p.Note("Access", "no group or --allow policy on %s (currently %s) - nothing to revoke", host, access)
+	} else {
+		for _, policy := range access.Policies {
+			if strings.HasSuffix(policy.Name, "-owner") {
+				continue
+			}
+			p.Add("Access", plan.Delete, "policy %s: %s", policy.Name, dns.DescribePolicy(policy))
+		}
+		p.Note("Access", "owner policy orb-%s-owner is kept", host)
+	}
+	planRemovePublicPaths(p, host, access)
+	s.planCancelExpiries(p, subdomain, ExpiryAccess, ExpiryGroup, ExpiryShare)
+
+	p.Print()
+	return nil
+}
+
+// planCreateAccessGroup prints the group CreateAccessGroup would create
+func (s *Service) planCreateAccessGroup(groupName, emails string) error {
+	p := plan.New()
+	p.Add("Access groups", plan.Create, "group %s", groupName)
+	for _, email := range strings.Split(emails, ",") {
+		p.Add("Access groups", plan.Create, "  member %s", strings.TrimSpace(email))
+	}
+	p.Print()
+	return nil
+}
+
+// planUpdateAccessGroupMembers prints the membership changes UpdateAccessGroupMembers would make
+func (s *Service) planUpdateAccessGroupMembers(ctx context.Context, groupName string, addEmails, removeEmails []string) error {
+	members, err := s.cloudflare.GetAccessGroupMembers(ctx, groupName)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool)
+	for _, email := range members {
+		current[email] = true
+	}
+
+	p := plan.New()
+	p.Note("Access groups", "group %s (%d member(s))", groupName, len(members))
+	for _, email := range addEmails {
+		if !current[email] {
+			p.Add("Access groups", plan.Create, "member %s", email)
+		}
+	}
+	for _, email := range removeEmails {
+		if current[email] {
+			p.Add("Access groups", plan.Delete, "member %s", email)
+		}
+	}
+	p.Print()
+	return nil
+}
+
+// planDeleteAccessGroup prints the group DeleteAccessGroup would delete and the
+// services it would revert to private first
+func (s *Service) planDeleteAccessGroup(ctx context.Context, groupName string, hosts []string) error {
+	members, err := s.cloudflare.GetAccessGroupRules(ctx, groupName)
+	if err != nil {
+		return err
+	}
+
+	p := plan.New()
+	for _, host := range hosts {
+		p.Add("Access", plan.Modify, "revert %s to private (uses group %s)", host, groupName)
+	}
+	p.Add("Access groups", plan.Delete, "group %s (%d member(s))", groupName, len(members))
+	p.Print()
+	return nil
+}
+
+// planConfig adds the YAML diff between two versions of the cloudflared config
+func (s *Service) planConfig(p *plan.Plan, before, after *Config) error {
+	oldYAML, err := s.config.Render(before)
+	if err != nil {
+		return err
+	}
+	newYAML, err := s.config.Render(after)
+	if err != nil {
+		return err
+	}
+	p.Diff(fmt.Sprintf("Config (%s)", s.env.ConfigPath), oldYAML, newYAML)
+	return nil
+}
+
+// planAccessPolicy adds the Access application and policies CreateAccessPolicy would create
+func (s *Service) planAccessPolicy(ctx context.Context, p *plan.Plan, host, accessLevel string, rules dns.AccessRules) {
+	if accessLevel == AccessLevelPublic && len(rules.Allow) == 0 {
+		p.Note("Access", "none - %s will be public", host)
+		return
+	}
+
+	owners, _ := OwnersFromEnv() // already validated
+	p.Add("Access", plan.Create, "application orb-%s (self_hosted, domain %s)", host, host)
+	p.Add("Access", plan.Create, "policy orb-%s-owner: allow %s (precedence 1)", host, describeOwners(owners))
+
+	// rule policies come after the group policy in precedence
+	defer planRulePolicies(p, host, nil, rules)
+
+	if accessLevel == AccessLevelPrivate || accessLevel == AccessLevelPublic {
+		return
+	}
+	groupRules := dns.GroupRules(accessLevel)
+	p.Add("Access", plan.Create, "policy orb-%s-group: %s (precedence 2)", host, dns.DescribePolicy(dns.Policy{
+		Decision: "allow",
+		Include:  groupRules,
+		Require:  rules.Require,
+		Exclude:  rules.Exclude,
+	}))
+
+	groups, err := s.cloudflare.ListAccessGroups(ctx)
+	if err != nil {
+		p.Note("Access", "⚠ could not verify groups %q exist: %v", accessLevel, err)
+		return
+	}
+	existing := make(map[string]bool)
+	for _, group := range groups {
+		existing[group.Name] = true
+	}
+	for _, rule := range groupRules {
+		if !existing[rule.Value] {
+			p.Note("Access", "⚠ access group %q does not exist - this would fail", rule.Value)
+		}
+	}
+}
+
+// planSetAccess prints the policy changes SetAccess would make without applying them
+func (s *Service) planSetAccess(host string, current *dns.Access, owners []dns.Rule, rules dns.AccessRules) error {
+	p := plan.New()
+	p.Note("Access", "currently %s", current)
+
+	if current == nil {
+		p.Add("Access", plan.Create, "application orb-%s (self_hosted, domain %s)", host, host)
+		p.Add("Access", plan.Create, "policy orb-%s-owner: allow %s (precedence 1)", host, describeOwners(owners))
+	} else {
+		for _, policy := range current.Policies {
+			if !strings.HasSuffix(policy.Name, "-group") {
+				continue
+			}
+			updated := policy
+			updated.Require, updated.Exclude = rules.Require, rules.Exclude
+			if dns.DescribePolicy(updated) != dns.DescribePolicy(policy) {
+				p.Add("Access", plan.Modify, "policy %s: %s (was %s)", policy.Name, dns.DescribePolicy(updated), dns.DescribePolicy(policy))
+			}
+		}
+	}
+
+	var existing []dns.Policy
+	if current != nil {
+		existing = current.Policies
+	}
+	planRulePolicies(p, host, existing, rules)
+
+	p.Print()
+	return nil
+}
+
+// planChangeAccess prints the policy changes ChangeAccess would make without applying them
+func (s *Service) planChangeAccess(subdomain, host string, before, desired *dns.Access, grants []GroupGrant, expires string) error {
+	p := plan.New()
+	p.Note("Access", "currently %s", before)
+
+	switch {
+	case desired == nil && before == nil:
+		p.Note("Access", "%s is already public - nothing to change", host)
+	case desired == nil:
+		p.Add("Access", plan.Delete, "application orb-%s and its policies", host)
+		planRemovePublicPaths(p, host, before)
+	default:
+		if before == nil {
+			p.Add("Access", plan.Create, "application orb-%s (self_hosted, domain %s)", host, host)
+		}
+		planPolicyChanges(p, before, desired)
+	}
+	p.Note("Access", "ingress and DNS for %s are unchanged", host)
+
+	if expires != "" {
+		p.Add("Expiry", plan.Create, "revert %s to private after %s", host, expires)
+	} else {
+		s.planCancelExpiry(p, ExpiryAccess, subdomain, "")
+	}
+	s.planCancelExpiries(p, subdomain, ExpiryGroup)
+	planGroupExpiries(p, host, grants)
+	if len(grants) == 0 {
+		s.planCancelExpiries(p, subdomain, ExpiryShare)
+	}
+
+	p.Print()
+	return nil
+}
+
+// planRemovePublicPaths adds the removal of the bypass applications of public paths
+func planRemovePublicPaths(p *plan.Plan, host string, access *dns.Access) {
+	if access == nil {
+		return
+	}
+	for _, path := range access.PublicPaths {
+		p.Add("Access", plan.Delete, "application orb-%s%s (public path)", host, path)
+	}
+}
+
+// planGroupExpiries adds the timers that would remove groups granted for a limited time
+func planGroupExpiries(p *plan.Plan, host string, grants []GroupGrant) {
+	for _, grant := range grants {
+		if grant.Expires != "" {
+			p.Add("Expiry", plan.Create, "remove group %s from %s after %s", grant.Name, host, grant.Expires)
+		}
+	}
+}
+
+// planGroupChange prints the policy and expiry changes AddGroup or RemoveGroup would
+// make without applying them
+func (s *Service) planGroupChange(subdomain, host string, before, desired *dns.Access, grant GroupGrant, removing bool) error {
+	p := plan.New()
+	p.Note("Access", "currently %s", before)
+	if before == nil {
+		p.Add("Access", plan.Create, "application orb-%s (self_hosted, domain %s)", host, host)
+	}
+	planPolicyChanges(p, before, desired)
+
+	if removing {
+		s.planCancelExpiry(p, ExpiryGroup, subdomain, grant.Name)
+		if !desired.Shared() {
+			s.planCancelExpiry(p, ExpiryAccess, subdomain, "")
+		}
+	} else {
+		planGroupExpiries(p, host, []GroupGrant{grant})
+	}
+
+	p.Print()
+	return nil
+}
+
+// planShareChange prints the policy and expiry changes Share (duration set) or
+// Unshare would make without applying them
+func (s *Service) planShareChange(subdomain, host string, before, desired *dns.Access, email, duration string) error {
+	p := plan.New()
+	p.Note("Access", "currently %s", before)
+	planPolicyChanges(p, before, desired)
+
+	if duration != "" {
+		p.Add("Expiry", plan.Create, "stop sharing %s with %s after %s", host, email, duration)
+	} else {
+		s.planCancelExpiry(p, ExpiryShare, subdomain, email)
+	}
+
+	p.Print()
+	return nil
+}
+
+// planPolicyChanges adds the policies that would be created, updated or deleted
+// to turn before into desired
+func planPolicyChanges(p *plan.Plan, before, desired *dns.Access) {
+	current := make(map[string]dns.Policy)
+	if before != nil {
+		for _, policy := range before.Policies {
+			current[policy.Name] = policy
+		}
+	}
+
+	wanted := make(map[string]bool)
+	for _, policy := range desired.Policies {
+		wanted[policy.Name] = true
+		have, ok := current[policy.Name]
+		switch {
+		case !ok:
+			p.Add("Access", plan.Create, "policy %s: %s (precedence %d)", policy.Name, dns.DescribePolicy(policy), policy.Precedence)
+		case dns.DescribePolicy(have) != dns.DescribePolicy(policy) || have.Precedence != policy.Precedence:
+			p.Add("Access", plan.Modify, "policy %s: %s (was %s)", policy.Name, dns.DescribePolicy(policy), dns.DescribePolicy(have))
+		}
+	}
+	if before != nil {
+		for _, policy := range before.Policies {
+			if !wanted[policy.Name] {
+				p.Add("Access", plan.Delete, "policy %s: %s", policy.Name, dns.DescribePolicy(policy))
+			}
+		}
+	}
+}
+
+// planRulePolicies adds the changes to the policies holding --allow rules: one
+// for rules that need a login and a non_identity one for service tokens
+func planRulePolicies(p *plan.Plan, host string, existing []dns.Policy, rules dns.AccessRules) {
+	var identity, tokens []dns.Rule
+	for _, rule := range rules.Allow {
+		if rule.Type == dns.RuleServiceToken {
+			tokens = append(tokens, rule)
+		} else {
+			identity = append(identity, rule)
+		}
+	}
+
+	specs := []struct {
+		suffix     string
+		decision   string
+		precedence int
+		include    []dns.Rule
+	}{
+		{"rules", "allow", 3, identity},
+		{"tokens", "non_identity", 4, tokens},
+	}
+
+	for _, spec := range specs {
+		name := fmt.Sprintf("orb-%s-%s", host, spec.suffix)
+		wanted := dns.Policy{Decision: spec.decision, Include: spec.include, Require: rules.Require, Exclude: rules.Exclude}
+
+		var current *dns.Policy
+		for i := range existing {
+			if existing[i].Name == name {
+				current = &existing[i]
+				break
+			}
+		}
+
+		switch {
+		case len(spec.include) == 0 && current != nil:
+			p.Add("Access", plan.Delete, "policy %s: %s", name, dns.DescribePolicy(*current))
+		case len(spec.include) == 0:
+		case current == nil:
+			p.Add("Access", plan.Create, "policy %s: %s (precedence %d)", name, dns.DescribePolicy(wanted), spec.precedence)
+		case dns.DescribePolicy(*current) != dns.DescribePolicy(wanted):
+			p.Add("Access", plan.Modify, "policy %s: %s (was %s)", name, dns.DescribePolicy(wanted), dns.DescribePolicy(*current))
+		}
+	}
+}
+
+// planRestart adds the cloudflared restart needed to apply config changes
+func (s *Service) planRestart(ctx context.Context, p *plan.Plan, tunnelID string) {
+	unit := fmt.Sprintf("cloudflared service for tunnel %s", tunnelID)
+	if tunnelName, err := s.cloudflare.GetTunnelName(ctx, tunnelID); err == nil {
+		unit = cloudflaredUnit(tunnelName)
+	}
+	p.Add("Service", plan.Modify, "restart %s", unit)
+}
@@ -0,0 +1,41 @@
+package tunnel
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPlanExposeNamesExpiryBackend(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    string
+	}{
+		{ExpiryBackendSystemd, "(systemd timers)"},
+		{ExpiryBackendAgent, "(orb agent)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			svc, cf, r := testService(t)
+			svc.env.ExpiryBackend = tt.backend
+			svc.dryRun = true
+
+			out, err := captureStdout(t, func() error {
+				return svc.Expose(context.Background(), "api", "8080", "http", ExposeOptions{Access: "friends", Expires: "1h", TTL: "2h"})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, line := range []string{"revert api.example.com to private after 1h " + tt.want, "unexpose api.example.com after 2h " + tt.want} {
+				if !strings.Contains(out, line) {
+					t.Errorf("plan lacks %q:\n%s", line, out)
+				}
+			}
+			if len(cf.Records) != 0 || len(r.Calls()) != 0 {
+				t.Errorf("dry run changed things: records %v, commands %v", cf.Records, r.Calls())
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"orb/internal/audit"
+	"orb/internal/dns"
+	"orb/internal/plan"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// AddRoute routes a private network through the tunnel to WARP clients, turning on
+// warp-routing in the cloudflared config (and restarting it) if it is off
+func (s *Service) AddRoute(ctx context.Context, network, comment string) error {
+	network, err := ParseNetwork(network)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.findRoute(ctx, network)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.TunnelID != cfg.Tunnel {
+		return fmt.Errorf("✖ %s is already routed through tunnel %s - remove it there first", network, existing.TunnelName)
+	}
+
+	// start of TRANSACTION
+	orginalCfg := s.config.Backup(cfg)
+	enableWarp := !warpRoutingEnabled(cfg)
+	if enableWarp {
+		cfg.WarpRouting = &WarpRouting{Enabled: true}
+	}
+
+	if existing != nil && !enableWarp {
+		fmt.Printf("ℹ️  %s is already routed through this tunnel (no changes needed)\n", network)
+		return nil
+	}
+
+	if s.dryRun {
+		p := plan.New()
+		if existing == nil {
+			p.Add("Routes", plan.Create, "%s → tunnel %s%s", network, cfg.Tunnel, routeComment(comment))
+		}
+		if enableWarp {
+			if err := s.planConfig(p, orginalCfg, cfg); err != nil {
+				return err
+			}
+			s.planRestart(ctx, p, cfg.Tunnel)
+		}
+		p.Print()
+		return nil
+	}
+
+	routeAdded := false
+	configSaved := false
+
+	defer func() {
+		if !routeAdded && !configSaved {
+			return
+		}
+
+		ctx, cancel := rollbackContext(ctx)
+		defer cancel()
+
+		if routeAdded {
+			fmt.Printf("Rolling back: Removing route for %s...\n", network)
+			if err := s.cloudflare.DeleteTunnelRoute(ctx, network); err != nil {
+				fmt.Printf("Failed to rollback route for %s: %v\n", network, err)
+			}
+		}
+
+		if configSaved {
+			fmt.Println("Rolling back: Restoring original config...")
+			if err := s.config.Save(orginalCfg); err != nil {
+				fmt.Printf("Failed to restore original config: %v\n", err)
+			}
+		}
+	}()
+
+	if existing == nil {
+		fmt.Printf("Creating route for %s...\n", network)
+		if err := s.cloudflare.CreateTunnelRoute(ctx, cfg.Tunnel, network, comment); err != nil {
+			return err
+		}
+		routeAdded = true
+	}
+
+	if enableWarp {
+		fmt.Println("Enabling warp-routing in the cloudflared config...")
+		if err := s.config.Save(cfg); err != nil {
+			return err
+		}
+		configSaved = true
+		if err := s.restartTunnel(ctx, cfg.Tunnel); err != nil {
+			return err
+		}
+	}
+
+	// reset rollback
+	routeAdded = false
+	configSaved = false
+
+	s.record(audit.Entry{
+		Action:  "tunnel.route.add",
+		Subject: network,
+		After:   audit.Fields("comment", comment, "warp_routing", fmt.Sprint(enableWarp || warpRoutingEnabled(orginalCfg))),
+		IDs:     audit.Fields("tunnel", cfg.Tunnel),
+	})
+
+	if existing == nil {
+		fmt.Printf("✔ Routed %s through the tunnel%s\n", network, routeComment(comment))
+	}
+	if enableWarp {
+		fmt.Println("✔ Enabled warp-routing in the cloudflared config")
+	}
+	fmt.Println("  WARP clients in your Zero Trust organization reach it directly (check it is not excluded by Split Tunnels)")
+	return nil
+}
+
+// ListRoutes lists the private network routes of the tunnel and whether the
+// cloudflared config lets them through
+func (s *Service) ListRoutes(ctx context.Context) error {
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+
+	routes, err := s.cloudflare.ListTunnelRoutes(ctx, cfg.Tunnel)
+	if err != nil {
+		return err
+	}
+	warp := "disabled"
+	if warpRoutingEnabled(cfg) {
+		warp = "enabled"
+	}
+
+	if len(routes) == 0 {
+		fmt.Printf("No private network routes (warp-routing %s)\n", warp)
+		fmt.Println("\nUse `orb tunnel route add 10.0.0.0/24 --comment lab` to add one")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Network", "Comment", "Created")
+	for _, route := range routes {
+		created := "-"
+		if !route.CreatedAt.IsZero() {
+			created = route.CreatedAt.Local().Format("2006-01-02 15:04")
+		}
+		if err := table.Append(route.Network, orDash(route.Comment), created); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nPrivate Network Routes (%d, warp-routing %s):\n", len(routes), warp)
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	if !warpRoutingEnabled(cfg) {
+		fmt.Printf("⚠ Warning: warp-routing is disabled in %s, so WARP clients cannot reach these networks - run `orb tunnel route add %s` to turn it on\n", s.env.ConfigPath, routes[0].Network)
+	}
+	return nil
+}
+
+// RemoveRoute stops routing a private network through the tunnel. Removing the
+// last route turns warp-routing off in the cloudflared config again.
+func (s *Service) RemoveRoute(ctx context.Context, network string) error {
+	network, err := ParseNetwork(network)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+
+	routes, err := s.cloudflare.ListTunnelRoutes(ctx, cfg.Tunnel)
+	if err != nil {
+		return err
+	}
+	var route *dns.Route
+	for i := range routes {
+		if routes[i].Network == network {
+			route = &routes[i]
+		}
+	}
+	if route == nil {
+		return fmt.Errorf("✖ %s is not routed through this tunnel - see `orb tunnel route list`", network)
+	}
+
+	// start of TRANSACTION
+	orginalCfg := s.config.Backup(cfg)
+	disableWarp := len(routes) == 1 && warpRoutingEnabled(cfg)
+	if disableWarp {
+		cfg.WarpRouting = &WarpRouting{Enabled: false}
+	}
+
+	if s.dryRun {
+		p := plan.New()
+		p.Add("Routes", plan.Delete, "%s → tunnel %s%s", network, cfg.Tunnel, routeComment(route.Comment))
+		if disableWarp {
+			p.Note("Routes", "last route of the tunnel - warp-routing is turned off")
+			if err := s.planConfig(p, orginalCfg, cfg); err != nil {
+				return err
+			}
+			s.planRestart(ctx, p, cfg.Tunnel)
+		}
+		p.Print()
+		return nil
+	}
+
+	routeRemoved := false
+	configSaved := false
+
+	defer func() {
+		if !routeRemoved {
+			return
+		}
+
+		ctx, cancel := rollbackContext(ctx)
+		defer cancel()
+
+		fmt.Printf("Rolling back: Re-adding route for %s...\n", network)
+		if err := s.cloudflare.CreateTunnelRoute(ctx, cfg.Tunnel, network, route.Comment); err != nil {
+			fmt.Printf("Failed to rollback route for %s: %v\n", network, err)
+		}
+
+		if configSaved {
+			fmt.Println("Rolling back: Restoring original config...")
+			if err := s.config.Save(orginalCfg); err != nil {
+				fmt.Printf("Failed to restore original config: %v\n", err)
+			}
+		}
+	}()
+
+	fmt.Printf("Removing route for %s...\n", network)
+	if err := s.cloudflare.DeleteTunnelRoute(ctx, network); err != nil {
+		return err
+	}
+	routeRemoved = true
+
+	if disableWarp {
+		fmt.Println("Disabling warp-routing in the cloudflared config (no routes left)...")
+		if err := s.config.Save(cfg); err != nil {
+			return err
+		}
+		configSaved = true
+		if err := s.restartTunnel(ctx, cfg.Tunnel); err != nil {
+			return err
+		}
+	}
+
+	// disable rollback
+	routeRemoved = false
+	configSaved = false
+
+	s.record(audit.Entry{
+		Action:  "tunnel.route.remove",
+		Subject: network,
+		Before:  audit.Fields("comment", route.Comment),
+		After:   audit.Fields("warp_routing", fmt.Sprint(warpRoutingEnabled(cfg))),
+		IDs:     audit.Fields("tunnel", cfg.Tunnel),
+	})
+
+	fmt.Printf("✔ Removed route for %s\n", network)
+	if disableWarp {
+		fmt.Println("✔ Disabled warp-routing in the cloudflared config")
+	}
+	return nil
+}
+
+// findRoute returns the route of a network through any tunnel, or nil if there is none
+func (s *Service) findRoute(ctx context.Context, network string) (*dns.Route, error) {
+	routes, err := s.cloudflare.ListTunnelRoutes(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range routes {
+		if routes[i].Network == network {
+			return &routes[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// restartTunnel restarts the cloudflared service of a tunnel to load a changed config
+func (s *Service) restartTunnel(ctx context.Context, tunnelID string) error {
+	tunnelName, err := s.cloudflare.GetTunnelName(ctx, tunnelID)
+	if err != nil {
+		return fmt.Errorf("failed to get tunnel name: %w", err)
+	}
+	if err := s.restartCloudflared(ctx, tunnelName); err != nil {
+		return fmt.Errorf("failed to restart cloudflared service: %w", err)
+	}
+	return nil
+}
+
+// warpRoutingEnabled reports whether the cloudflared config carries WARP traffic
+func warpRoutingEnabled(cfg *Config) bool {
+	return cfg.WarpRouting != nil && cfg.WarpRouting.Enabled
+}
+
+// routeComment renders a route comment for messages
+func routeComment(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", comment)
+}
@@ -1,15 +1,17 @@
 package tunnel
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
-	"os/exec"
+	"strings"
 	"sync"
 	"time"
 
+	"orb/internal/audit"
 	"orb/internal/dns"
+	"orb/internal/runner"
 
 	"github.com/olekukonko/tablewriter"
 )
@@ -17,12 +19,49 @@ import (
 // Service struct for tunnel operations
 type Service struct {
 	config     *ConfigManager
-	cloudflare *dns.Client
+	cloudflare dns.API
+	runner     runner.Runner
+	http       *http.Client
+	audit      *audit.Log
+	expiries   *ExpiryStore
+	sshConfig  string // OpenSSH client config holding orb's Host entries
 	env        *Environment
+	dryRun     bool
+	trigger    string // recorded as the audit trigger while applying expiries in-process
 }
 
+// Options are the command-line settings a Service runs with
+type Options struct {
+	Timeout time.Duration // bounds each Cloudflare API request, retries included (0 means no limit)
+	DryRun  bool          // print planned changes instead of applying them
+}
+
+// ExposeOptions are the optional settings of an exposure
+type ExposeOptions struct {
+	Access      string          // "public", "private", or group names (team,contractors:7d)
+	Expires     string          // revert to private after this long (group access or --allow rules)
+	TTL         string          // remove the whole exposure after this long
+	Rules       dns.AccessRules // --allow, --require and --exclude rules for everyone but the owner
+	PublicPaths []string        // paths reachable without login on a protected service (e.g. /webhook/*)
+	Settings    SettingsChange  // session duration, identity providers, CORS... of the Access application
+}
+
+// Deps are the external collaborators of a Service. NewService wires the real
+// implementations; tests can substitute dnstest.Fake and runnertest.Fake.
+type Deps struct {
+	Cloudflare dns.API
+	Runner     runner.Runner
+	HTTP       *http.Client
+	Audit      *audit.Log   // nil disables the audit log
+	Expiries   *ExpiryStore // nil disables expiry tracking
+	SSHConfig  string       // path of ~/.ssh/config; empty disables ssh-config and its sync
+}
+
+// rollbackTimeout bounds the cleanup work done after a failed or interrupted operation
+const rollbackTimeout = 30 * time.Second
+
 // NewService creates a new tunnel service
-func NewService() (*Service, error) {
+func NewService(opts Options) (*Service, error) {
 	// Validate environment variables first
 	env, err := LoadEnvironment()
 	if err != nil {
@@ -30,20 +69,57 @@ func NewService() (*Service, error) {
 	}
 
 	// Create Cloudflare client
-	client, err := dns.New()
+	client, err := dns.New(opts.Timeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloudflare client: %w", err)
 	}
 
+	auditLog, err := audit.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	expiries, err := DefaultExpiryStore()
+	if err != nil {
+		return nil, err
+	}
+
+	// without a home directory there is no ssh config to keep in sync
+	sshConfig, _ := SSHConfigPath()
+
+	return NewServiceWith(env, Deps{
+		Cloudflare: client,
+		Runner:     runner.Exec{},
+		HTTP:       &http.Client{},
+		Audit:      auditLog,
+		Expiries:   expiries,
+		SSHConfig:  sshConfig,
+	}, opts), nil
+}
+
+// NewServiceWith creates a tunnel service from an environment and explicit dependencies
+func NewServiceWith(env *Environment, deps Deps, opts Options) *Service {
+	if deps.HTTP == nil {
+		deps.HTTP = &http.Client{}
+	}
 	return &Service{
 		config:     NewConfigManager(env.ConfigPath),
-		cloudflare: client,
+		cloudflare: deps.Cloudflare,
+		runner:     deps.Runner,
+		http:       deps.HTTP,
+		audit:      deps.Audit,
+		expiries:   deps.Expiries,
+		sshConfig:  deps.SSHConfig,
 		env:        env,
-	}, nil
+		dryRun:     opts.DryRun,
+	}
 }
 
 // Expose makes a local port accessible through a Cloudflare Tunnel subdomain
-func (s *Service) Expose(subdomain, port, serviceType, accessLevel, expires string) error {
+// A non-empty TTL removes the whole exposure once it lapses
+func (s *Service) Expose(ctx context.Context, subdomain, port, serviceType string, opts ExposeOptions) error {
+	accessLevel, expires, ttl, rules, publicPaths := opts.Access, opts.Expires, opts.TTL, opts.Rules, opts.PublicPaths
+
 	// validation of arguments and if server is running
 	if err := ValidateSubdomain(subdomain); err != nil {
 		return err
@@ -57,15 +133,58 @@ func (s *Service) Expose(subdomain, port, serviceType, accessLevel, expires stri
 	if err := ValidateAccessLevel(accessLevel); err != nil {
 		return err
 	}
+	// a group level may name several groups, each with its own expiry (team,contractors:7d)
+	grants, _ := ParseGroupGrants(accessLevel) // already validated
+	if len(grants) > 0 {
+		accessLevel = GroupNames(grants)
+	}
+	// --allow rules restrict access, so a public service becomes owner-only plus the rules
+	if len(rules.Allow) > 0 && accessLevel == AccessLevelPublic {
+		accessLevel = AccessLevelPrivate
+	}
+	shared := len(rules.Allow) > 0 || (accessLevel != AccessLevelPublic && accessLevel != AccessLevelPrivate)
+	if !shared && (len(rules.Require) > 0 || len(rules.Exclude) > 0) {
+		return fmt.Errorf("--require and --exclude narrow group or --allow access (e.g., --access friends --require country:CA)")
+	}
 	if expires != "" {
 		if err := ValidateExpiresDuration(expires); err != nil {
 			return err
 		}
-		// --expires only makes sense with group access
-		if accessLevel == AccessLevelPublic || accessLevel == AccessLevelPrivate {
-			return fmt.Errorf("--expires can only be used with group access (e.g., --access friends --expires 24h)")
+		// --expires only makes sense when someone besides the owner has access
+		if !shared {
+			return fmt.Errorf("--expires can only be used with group access or --allow rules (e.g., --access friends --expires 24h)")
+		}
+	}
+	if ttl != "" {
+		if err := ValidateExpiresDuration(ttl); err != nil {
+			return fmt.Errorf("invalid --ttl: %w", err)
 		}
 	}
+	for _, path := range publicPaths {
+		if err := ValidatePublicPath(path); err != nil {
+			return err
+		}
+	}
+	// a bypass only matters where the rest of the hostname needs a login
+	if len(publicPaths) > 0 && accessLevel == AccessLevelPublic {
+		return fmt.Errorf("--public-path needs a protected service - a public one is reachable without login already (e.g., --access private --public-path /webhook/*)")
+	}
+
+	settings := opts.Settings.Apply(dns.AppSettings{})
+	if err := ValidateAppSettings(settings); err != nil {
+		return err
+	}
+	if !opts.Settings.IsZero() && accessLevel == AccessLevelPublic {
+		return fmt.Errorf("access settings need a protected service - a public one has no login to configure (e.g., --access private --session-duration 8h)")
+	}
+	// the owners are let in by the first policy of a protected service
+	owners, err := OwnersFromEnv()
+	if err != nil {
+		return err
+	}
+	if accessLevel != AccessLevelPublic && len(owners) == 0 {
+		return errNoOwners(fmt.Sprintf("for %s access", accessLevel))
+	}
 
 	// get hostname and service
 	host := HostnameFor(subdomain, s.env.Domain)
@@ -97,19 +216,38 @@ func (s *Service) Expose(subdomain, port, serviceType, accessLevel, expires stri
 
 	// combine catchall and new subdomain to form new cloudlfare yaml
 	catchAll := cfg.Ingress[len(cfg.Ingress)-1]
+	cfg.Ingress = append(cfg.Ingress[:len(cfg.Ingress)-1], IngressRule{Hostname: host, Service: svc}, catchAll)
+
+	if s.dryRun {
+		return s.planExpose(ctx, orginalCfg, cfg, host, accessLevel, grants, expires, ttl, rules, publicPaths, settings)
+	}
 
 	configSaved := false
 	dnsAdded := false
+	accessAttempted := false
 
 	defer func() {
-		if !dnsAdded {
+		if !configSaved && !dnsAdded && !accessAttempted {
 			return
 		}
 
+		ctx, cancel := rollbackContext(ctx)
+		defer cancel()
+
+		// a policy call that failed halfway can leave an application behind
+		if accessAttempted {
+			fmt.Printf("Rolling back: Removing Access application for %s...\n", host)
+			if _, err := s.cloudflare.RemoveAccessPolicy(ctx, host); err != nil {
+				fmt.Printf("Failed to rollback Access application for %s: %v\n", host, err)
+			}
+		}
+
 		// rollback and remove dns route
-		fmt.Printf("Rolling back: Removing DNS route for %s...\n", host)
-		if err := s.cloudflare.RemoveDNSRoute(orginalCfg.Tunnel, host); err != nil {
-			fmt.Printf("Failed to rollback DNS route for %s: %v\n", host, err)
+		if dnsAdded {
+			fmt.Printf("Rolling back: Removing DNS route for %s...\n", host)
+			if _, err := s.cloudflare.RemoveDNSRoute(ctx, orginalCfg.Tunnel, host); err != nil {
+				fmt.Printf("Failed to rollback DNS route for %s: %v\n", host, err)
+			}
 		}
 
 		if configSaved {
@@ -120,8 +258,6 @@ func (s *Service) Expose(subdomain, port, serviceType, accessLevel, expires stri
 		}
 	}()
 
-	cfg.Ingress = append(cfg.Ingress[:len(cfg.Ingress)-1], IngressRule{Hostname: host, Service: svc}, catchAll)
-
 	// save to yaml file
 	if err := s.config.Save(cfg); err != nil {
 		return err
@@ -130,62 +266,109 @@ func (s *Service) Expose(subdomain, port, serviceType, accessLevel, expires stri
 
 	// create dns route
 	fmt.Printf("Creating DNS route for %s...\n", host)
-	if err := s.cloudflare.CreateDNSRoute(cfg.Tunnel, host); err != nil {
+	recordID, err := s.cloudflare.CreateDNSRoute(ctx, cfg.Tunnel, host)
+	if err != nil {
 		return fmt.Errorf("config updated but failed to create DNS route: %w", err)
 	}
 	dnsAdded = true
 
 	// flush local DNS cache to pick up new record immediately
-	s.cloudflare.FlushLocalDNSCache()
+	s.flushLocalDNSCache(ctx)
 
 	// create access policy if not public
+	var appID string
 	if accessLevel != AccessLevelPublic {
 		fmt.Printf("Creating Zero Trust access policy (%s)...\n", accessLevel)
-		userEmail := os.Getenv("USER_EMAIL")
-		if userEmail == "" && accessLevel == AccessLevelPrivate {
-			return fmt.Errorf("USER_EMAIL environment variable required for private access")
-		}
-		if err := s.cloudflare.CreateAccessPolicy(host, accessLevel, userEmail); err != nil {
+		accessAttempted = true
+		appID, err = s.cloudflare.CreateAccessPolicy(ctx, host, accessLevel, owners, rules, settings)
+		if err != nil {
 			return fmt.Errorf("failed to create access policy: %w", err)
 		}
 	}
 
+	// public paths get their own bypass applications, removed along with the main one
+	var pathAppIDs []string
+	for _, path := range publicPaths {
+		fmt.Printf("Creating public path %s (bypass)...\n", path)
+		id, err := s.cloudflare.CreatePublicPath(ctx, host, path)
+		if err != nil {
+			return fmt.Errorf("failed to create public path: %w", err)
+		}
+		pathAppIDs = append(pathAppIDs, id)
+	}
+
 	// get tunnel name from tunnel ID
-	tunnelName, err := s.cloudflare.GetTunnelName(cfg.Tunnel)
+	tunnelName, err := s.cloudflare.GetTunnelName(ctx, cfg.Tunnel)
 	if err != nil {
 		return fmt.Errorf("failed to get tunnel name: %w", err)
 	}
 
 	// restart cloudflared service
-	if err := s.cloudflare.RestartCloudflaredService(tunnelName, host); err != nil {
+	if err := s.restartCloudflared(ctx, tunnelName); err != nil {
 		return fmt.Errorf("failed to restart cloudflared service: %w", err)
 	}
 
 	// reset rollback
 	configSaved = false
 	dnsAdded = false
+	accessAttempted = false
+
+	s.record(audit.Entry{
+		Action:  "tunnel.expose",
+		Subject: host,
+		After:   audit.Fields("service", svc, "access", FormatGrants(grants, accessLevel), "rules", rules.String(), "public_paths", strings.Join(publicPaths, ","), "settings", settingsSummary(settings), "expires", expires, "ttl", ttl),
+		IDs:     audit.Fields("tunnel", cfg.Tunnel, "dns_record", recordID, "access_app", appID, "path_apps", strings.Join(pathAppIDs, ",")),
+	})
+
+	// an ssh service gets a Host entry if `ssh-config --install` manages ~/.ssh/config
+	s.syncSSHConfig(cfg)
 
 	// schedule access expiry if specified
-	if expires != "" && accessLevel != AccessLevelPublic && accessLevel != AccessLevelPrivate {
+	if expires != "" {
 		duration, _ := ParseExpiresDuration(expires) // already validated
 		expiryTime := time.Now().Add(duration)
-		if err := s.scheduleAccessExpiry(subdomain, duration); err != nil {
+		if err := s.scheduleExpiry(ctx, Expiry{Kind: ExpiryAccess, Subdomain: subdomain, At: expiryTime}); err != nil {
 			fmt.Printf("⚠ Warning: failed to schedule access expiry: %v\n", err)
 		} else {
 			fmt.Printf("  Access reverts to private: %s (in %s)\n", expiryTime.Format("2006-01-02 15:04:05"), expires)
 		}
 	}
 
+	// schedule removal of groups granted for a limited time
+	s.scheduleGroupExpiries(ctx, subdomain, grants)
+
+	// schedule removal of the whole exposure if a TTL is set
+	if ttl != "" {
+		duration, _ := ParseExpiresDuration(ttl) // already validated
+		deadline := time.Now().Add(duration)
+		if err := s.scheduleExpiry(ctx, Expiry{Kind: ExpiryTTL, Subdomain: subdomain, At: deadline}); err != nil {
+			fmt.Printf("⚠ Warning: failed to schedule TTL - %s will stay exposed: %v\n", host, err)
+		} else {
+			fmt.Printf("  Exposure ends: %s (in %s)\n", deadline.Format("2006-01-02 15:04:05"), ttl)
+		}
+	}
+
+	if (expires != "" || ttl != "" || hasGroupExpiry(grants)) && s.env.ExpiryBackend == ExpiryBackendAgent {
+		fmt.Println("  Applied by `orb agent` (or the next orb command) when due")
+	}
+
 	fmt.Printf("✔ Exposed %s → %s", host, svc)
 	if accessLevel != AccessLevelPublic {
 		fmt.Printf(" [%s access]", accessLevel)
 	}
-	fmt.Printf("\n  Visit: https://%s\n", host)
+	fmt.Println()
+	if !rules.IsZero() {
+		fmt.Printf("  Rules: %s\n", rules)
+	}
+	for _, path := range publicPaths {
+		fmt.Printf("  Public: https://%s%s (no login)\n", host, path)
+	}
+	fmt.Printf("  Visit: https://%s\n", host)
 	return nil
 }
 
 // Unexpose removes a subdomain from the Cloudflare Tunnel
-func (s *Service) Unexpose(subdomain string) error {
+func (s *Service) Unexpose(ctx context.Context, subdomain string) error {
 	// validate subdomain
 	if err := ValidateSubdomain(subdomain); err != nil {
 		return err
@@ -210,18 +393,41 @@ func (s *Service) Unexpose(subdomain string) error {
 	orginalCfg := s.config.Backup(cfg)
 	oldService := cfg.Ingress[idx].Service
 
+	// new yaml without previous ingress rule
+	cfg.Ingress = append(cfg.Ingress[:idx], cfg.Ingress[idx+1:]...)
+
+	if s.dryRun {
+		return s.planUnexpose(ctx, orginalCfg, cfg, subdomain, host)
+	}
+
 	configSaved := false
 	dnsRemoved := false
+	accessRemoved := false
+
+	// the Access application as it was, so a failed unexpose can put it back
+	beforeAccess, accessErr := s.cloudflare.GetAccess(ctx, host)
 
 	defer func() {
-		if !dnsRemoved {
+		if !configSaved && !dnsRemoved && !accessRemoved {
 			return
 		}
 
+		ctx, cancel := rollbackContext(ctx)
+		defer cancel()
+
+		if accessRemoved && accessErr == nil && beforeAccess != nil {
+			fmt.Printf("Rolling back: Restoring Access application for %s...\n", host)
+			if _, err := s.cloudflare.ApplyAccess(ctx, host, beforeAccess); err != nil {
+				fmt.Printf("Failed to rollback Access application for %s: %v\n", host, err)
+			}
+		}
+
 		// rollback and re create dns route
-		fmt.Printf("Rolling back: Re-adding DNS route for %s...\n", host)
-		if err := s.cloudflare.CreateDNSRoute(orginalCfg.Tunnel, host); err != nil {
-			fmt.Printf("Failed to rollback DNS route for %s: %v\n", host, err)
+		if dnsRemoved {
+			fmt.Printf("Rolling back: Re-adding DNS route for %s...\n", host)
+			if _, err := s.cloudflare.CreateDNSRoute(ctx, orginalCfg.Tunnel, host); err != nil {
+				fmt.Printf("Failed to rollback DNS route for %s: %v\n", host, err)
+			}
 		}
 
 		if configSaved {
@@ -232,8 +438,8 @@ func (s *Service) Unexpose(subdomain string) error {
 		}
 	}()
 
-	// save new yaml without previous ingress rule
-	cfg.Ingress = append(cfg.Ingress[:idx], cfg.Ingress[idx+1:]...)
+	// remember the access level for the audit log before it is removed
+	oldAccess := s.cloudflare.GetAccessInfo(ctx, host)
 
 	// save to yaml
 	if err := s.config.Save(cfg); err != nil {
@@ -243,42 +449,58 @@ func (s *Service) Unexpose(subdomain string) error {
 
 	// remove domain from cloudflare dashboard
 	fmt.Printf("Removing DNS route for %s...\n", host)
-	if err := s.cloudflare.RemoveDNSRoute(cfg.Tunnel, host); err != nil {
+	recordIDs, err := s.cloudflare.RemoveDNSRoute(ctx, cfg.Tunnel, host)
+	if err != nil {
 		return fmt.Errorf("config updated but failed to remove DNS route: %w", err)
 	}
 	dnsRemoved = true
 
 	// flush local DNS cache to remove stale record immediately
-	s.cloudflare.FlushLocalDNSCache()
+	s.flushLocalDNSCache(ctx)
 
 	// remove access policy if it exists
 	fmt.Printf("Removing Zero Trust access policy (if any)...\n")
-	if err := s.cloudflare.RemoveAccessPolicy(host); err != nil {
+	appID, err := s.cloudflare.RemoveAccessPolicy(ctx, host)
+	if err != nil {
 		fmt.Printf("Warning: failed to remove access policy: %v\n", err)
 		// Don't fail the whole operation if access policy removal fails
+	} else {
+		accessRemoved = true
 	}
 
 	// get tunnel name from tunnel ID
-	tunnelName, err := s.cloudflare.GetTunnelName(cfg.Tunnel)
+	tunnelName, err := s.cloudflare.GetTunnelName(ctx, cfg.Tunnel)
 	if err != nil {
 		return fmt.Errorf("failed to get tunnel name: %w", err)
 	}
 
 	// restart cloudflared service
-	if err := s.cloudflare.RestartCloudflaredService(tunnelName, host); err != nil {
+	if err := s.restartCloudflared(ctx, tunnelName); err != nil {
 		return fmt.Errorf("failed to restart cloudflared service: %w", err)
 	}
 
 	// disable rollback
 	dnsRemoved = false
 	configSaved = false
+	accessRemoved = false
+
+	s.record(audit.Entry{
+		Action:  "tunnel.unexpose",
+		Subject: host,
+		Before:  audit.Fields("service", oldService, "access", oldAccess),
+		IDs:     audit.Fields("tunnel", cfg.Tunnel, "dns_record", strings.Join(recordIDs, ","), "access_app", appID),
+	})
+
+	// the exposure is gone, so its expiry timers (if any) have nothing left to do
+	s.cancelExpiries(ctx, subdomain, expiryKinds...)
+	s.syncSSHConfig(cfg)
 
 	fmt.Printf("✔ Removed %s (was → %s)\n", host, oldService)
 	return nil
 }
 
 // Update changes the port mapping for an existing subdomain
-func (s *Service) Update(subdomain, port, serviceType string) error {
+func (s *Service) Update(ctx context.Context, subdomain, port, serviceType string) error {
 	// validate arguments
 	if err := ValidateSubdomain(subdomain); err != nil {
 		return err
@@ -317,26 +539,50 @@ func (s *Service) Update(subdomain, port, serviceType string) error {
 		return err
 	}
 
+	if s.dryRun {
+		return s.planUpdate(ctx, orginalCfg, cfg)
+	}
+
 	// save to yaml
 	if err := s.config.Save(cfg); err != nil {
 		return err
 	}
 	configSaved = true
 
+	// get tunnel name from tunnel ID
+	tunnelName, err := s.cloudflare.GetTunnelName(ctx, cfg.Tunnel)
+	if err != nil {
+		return fmt.Errorf("failed to get tunnel name: %w", err)
+	}
+
 	// restart cloudflared service
-	if err := s.cloudflare.RestartCloudflaredService(cfg.Tunnel, HostnameFor(subdomain, s.env.Domain)); err != nil {
+	if err := s.restartCloudflared(ctx, tunnelName); err != nil {
 		return fmt.Errorf("failed to restart cloudflared service: %w", err)
 	}
 
 	// reset rollback
 	configSaved = false
 
-	fmt.Printf("✔ Updated %s to point to %s\n", HostnameFor(subdomain, s.env.Domain), ServiceURL(port, serviceType))
+	host := HostnameFor(subdomain, s.env.Domain)
+	var oldService string
+	if idx := s.config.FindIngressIndex(orginalCfg, host); idx != -1 {
+		oldService = orginalCfg.Ingress[idx].Service
+	}
+	s.record(audit.Entry{
+		Action:  "tunnel.update",
+		Subject: host,
+		Before:  audit.Fields("service", oldService),
+		After:   audit.Fields("service", ServiceURL(port, serviceType)),
+		IDs:     audit.Fields("tunnel", cfg.Tunnel),
+	})
+	s.syncSSHConfig(cfg)
+
+	fmt.Printf("✔ Updated %s to point to %s\n", host, ServiceURL(port, serviceType))
 	return nil
 }
 
 // Health checks if a subdomain is healthy and reachable
-func (s *Service) Health(subdomain string) error {
+func (s *Service) Health(ctx context.Context, subdomain string) error {
 	// validate subdomain
 	if err := ValidateSubdomain(subdomain); err != nil {
 		return err
@@ -360,18 +606,15 @@ func (s *Service) Health(subdomain string) error {
 	url := fmt.Sprintf("https://%s", host)
 	fmt.Printf("Checking health of %s...\n", url)
 
-	// create http client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: false,
-			},
-		},
-	}
+	// make request with timeout
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-	// make request
-	resp, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.http.Do(req)
 	if err != nil {
 		fmt.Printf("✖ %s is unhealthy\n", host)
 		fmt.Printf("  Error: %v\n", err)
@@ -390,19 +633,19 @@ func (s *Service) Health(subdomain string) error {
 }
 
 // Restart restarts the cloudflared service
-func (s *Service) Restart() error {
+func (s *Service) Restart(ctx context.Context) error {
 	cfg, err := s.config.Load()
 	if err != nil {
 		return err
 	}
 
-	tunnelName, err := s.cloudflare.GetTunnelName(cfg.Tunnel)
+	tunnelName, err := s.cloudflare.GetTunnelName(ctx, cfg.Tunnel)
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("Restarting cloudflared-%s service...\n", tunnelName)
-	if err := s.cloudflare.RestartCloudflaredService(tunnelName, ""); err != nil {
+	if err := s.restartCloudflared(ctx, tunnelName); err != nil {
 		return err
 	}
 	fmt.Printf("✔ cloudflared-%s service restarted successfully\n", tunnelName)
@@ -410,18 +653,18 @@ func (s *Service) Restart() error {
 }
 
 // Status shows the cloudflared service status
-func (s *Service) Status() error {
+func (s *Service) Status(ctx context.Context) error {
 	cfg, err := s.config.Load()
 	if err != nil {
 		return err
 	}
 
-	tunnelName, err := s.cloudflare.GetTunnelName(cfg.Tunnel)
+	tunnelName, err := s.cloudflare.GetTunnelName(ctx, cfg.Tunnel)
 	if err != nil {
 		return err
 	}
 
-	output, err := s.cloudflare.GetServiceStatus(tunnelName)
+	output, err := s.cloudflaredStatus(ctx, tunnelName)
 	if err != nil {
 		return err
 	}
@@ -430,13 +673,13 @@ func (s *Service) Status() error {
 }
 
 // Logs shows the cloudflared service logs, optionally filtered by subdomain
-func (s *Service) Logs(subdomain string, lines int, follow bool) error {
+func (s *Service) Logs(ctx context.Context, subdomain string, lines int, follow bool) error {
 	cfg, err := s.config.Load()
 	if err != nil {
 		return err
 	}
 
-	tunnelName, err := s.cloudflare.GetTunnelName(cfg.Tunnel)
+	tunnelName, err := s.cloudflare.GetTunnelName(ctx, cfg.Tunnel)
 	if err != nil {
 		return err
 	}
@@ -456,10 +699,10 @@ func (s *Service) Logs(subdomain string, lines int, follow bool) error {
 	}
 
 	if follow {
-		return s.cloudflare.FollowServiceLogs(tunnelName, hostname)
+		return s.followCloudflaredLogs(ctx, tunnelName, hostname)
 	}
 
-	output, err := s.cloudflare.GetServiceLogs(tunnelName, lines, hostname)
+	output, err := s.cloudflaredLogs(ctx, tunnelName, lines, hostname)
 	if err != nil {
 		return err
 	}
@@ -468,28 +711,15 @@ func (s *Service) Logs(subdomain string, lines int, follow bool) error {
 }
 
 // checkHealth makes an HTTP request to check if a hostname is healthy
-func (s *Service) checkHealth(hostname string) string {
-	url := fmt.Sprintf("https://%s", hostname)
-
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: false,
-			},
-		},
-	}
-
-	resp, err := client.Get(url)
-	if err != nil {
+func (s *Service) checkHealth(ctx context.Context, hostname string) string {
+	code, _ := s.probe(ctx, hostname)
+	switch {
+	case code == 0:
 		return "✖ unhealthy"
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+	case healthyStatus(code):
 		return "✔ healthy"
 	}
-	return fmt.Sprintf("⚠ %d", resp.StatusCode)
+	return fmt.Sprintf("⚠ %d", code)
 }
 
 // serviceInfo holds the result of parallel health/access checks
@@ -501,7 +731,7 @@ type serviceInfo struct {
 }
 
 // List displays all exposed subdomains and their port mappings
-func (s *Service) List() error {
+func (s *Service) List(ctx context.Context) error {
 	// load cloudflare config
 	cfg, err := s.config.Load()
 	if err != nil {
@@ -522,6 +752,25 @@ func (s *Service) List() error {
 		}
 	}
 
+	// pending access expiries and remaining lifetime of services exposed with --ttl
+	expiries, err := s.expiries.List()
+	if err != nil {
+		return err
+	}
+	deadlines := make(map[string]time.Time)
+	accessNotes := make(map[string][]string)
+	for _, e := range expiries {
+		host := HostnameFor(e.Subdomain, s.env.Domain)
+		switch e.Kind {
+		case ExpiryTTL:
+			deadlines[host] = e.At
+		case ExpiryAccess:
+			accessNotes[host] = append(accessNotes[host], "private in "+FormatRemaining(time.Until(e.At)))
+		case ExpiryGroup, ExpiryShare:
+			accessNotes[host] = append(accessNotes[host], e.Target+" lapses in "+FormatRemaining(time.Until(e.At)))
+		}
+	}
+
 	fmt.Println("\nChecking health of exposed services...")
 
 	// Use goroutines to check health and access in parallel
@@ -535,8 +784,8 @@ func (s *Service) List() error {
 			results <- serviceInfo{
 				hostname: r.Hostname,
 				service:  r.Service,
-				access:   s.cloudflare.GetAccessInfo(r.Hostname),
-				status:   s.checkHealth(r.Hostname),
+				access:   s.cloudflare.GetAccessInfo(ctx, r.Hostname),
+				status:   s.checkHealth(ctx, r.Hostname),
 			}
 		}(rule)
 	}
@@ -549,14 +798,23 @@ func (s *Service) List() error {
 
 	// Collect results and build table
 	table := tablewriter.NewWriter(os.Stdout)
-	table.Header("URL", "Target", "Access", "Status")
+	table.Header("URL", "Target", "Access", "Status", "Expires In")
 
 	for info := range results {
+		lifetime := "-"
+		if deadline, ok := deadlines[info.hostname]; ok {
+			lifetime = FormatRemaining(time.Until(deadline))
+		}
+		access := info.access
+		if notes, ok := accessNotes[info.hostname]; ok {
+			access = fmt.Sprintf("%s (%s)", access, strings.Join(notes, "; "))
+		}
 		if err := table.Append(
 			fmt.Sprintf("https://%s", info.hostname),
 			info.service,
-			info.access,
+			access,
 			info.status,
+			lifetime,
 		); err != nil {
 			return fmt.Errorf("failed to add table row: %w", err)
 		}
@@ -572,72 +830,183 @@ func (s *Service) List() error {
 }
 
 // CreateAccessGroup creates a Cloudflare Access group with email addresses
-func (s *Service) CreateAccessGroup(groupName, emails string) error {
-	return s.cloudflare.CreateAccessGroup(groupName, emails)
+func (s *Service) CreateAccessGroup(ctx context.Context, groupName, emails string) error {
+	if s.dryRun {
+		return s.planCreateAccessGroup(groupName, emails)
+	}
+
+	groupID, err := s.cloudflare.CreateAccessGroup(ctx, groupName, emails)
+	if err != nil {
+		return err
+	}
+
+	members, _ := s.cloudflare.GetAccessGroupMembers(ctx, groupName)
+	s.record(audit.Entry{
+		Action:  "access.create",
+		Subject: groupName,
+		After:   audit.Fields("members", strings.Join(members, ",")),
+		IDs:     audit.Fields("access_group", groupID),
+	})
+	return nil
 }
 
 // ListAccessGroups lists all Cloudflare Access groups
-func (s *Service) ListAccessGroups() error {
-	return s.cloudflare.ListAccessGroupsFormatted()
+func (s *Service) ListAccessGroups(ctx context.Context) error {
+	groups, err := s.cloudflare.ListAccessGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No Access groups found")
+		return nil
+	}
+
+	// usage is a nicety - still list the groups if the services can't be checked
+	usage, err := s.ruleUsage(ctx, dns.RuleGroup)
+	if err != nil {
+		fmt.Printf("⚠ Warning: failed to check which services use the groups: %v\n", err)
+	}
+
+	fmt.Printf("\nAccess Groups (%d):\n", len(groups))
+	for _, group := range groups {
+		if usage == nil {
+			fmt.Printf("  • %s (ID: %s)\n", group.Name, group.ID)
+			continue
+		}
+		fmt.Printf("  • %s (ID: %s) - used by %d service(s)\n", group.Name, group.ID, len(usage[group.Name]))
+	}
+
+	return nil
 }
 
-// DeleteAccessGroup deletes a Cloudflare Access group by name
-func (s *Service) DeleteAccessGroup(groupName string) error {
-	return s.cloudflare.DeleteAccessGroup(groupName)
+// DeleteAccessGroup deletes a Cloudflare Access group by name. It refuses while
+// exposed services still refer to the group, unless force is set: then those
+// services are reverted to private first.
+func (s *Service) DeleteAccessGroup(ctx context.Context, groupName string, force bool) error {
+	usage, err := s.ruleUsage(ctx, dns.RuleGroup)
+	if err != nil {
+		return err
+	}
+	hosts := usage[groupName]
+	if len(hosts) > 0 && !force {
+		var list strings.Builder
+		for _, host := range hosts {
+			fmt.Fprintf(&list, "  • %s\n", host)
+		}
+		return fmt.Errorf("✖ access group %q is still used by %d service(s):\n%s  Remove it with `orb tunnel access <subdomain> --remove-group %s`, or use --force to revert them to private first", groupName, len(hosts), list.String(), groupName)
+	}
+
+	// --force only reverts services; other groups have to be edited by hand
+	includers, err := s.groupIncluders(ctx, groupName)
+	if err != nil {
+		return err
+	}
+	if len(includers) > 0 {
+		return fmt.Errorf("✖ access group %q is included by group(s) %s - remove it from them first (e.g. with `orb access sync`)", groupName, strings.Join(includers, ", "))
+	}
+
+	if s.dryRun {
+		return s.planDeleteAccessGroup(ctx, groupName, hosts)
+	}
+
+	for _, host := range hosts {
+		fmt.Printf("Reverting %s to private (it uses group %q)...\n", host, groupName)
+		if err := s.ChangeAccess(ctx, SubdomainFor(host, s.env.Domain), AccessLevelPrivate, ""); err != nil {
+			return fmt.Errorf("failed to revert %s to private - group %q was not deleted: %w", host, groupName, err)
+		}
+	}
+
+	members, _ := s.cloudflare.GetAccessGroupRules(ctx, groupName)
+	groupID, err := s.cloudflare.DeleteAccessGroup(ctx, groupName)
+	if err != nil {
+		return err
+	}
+
+	s.record(audit.Entry{
+		Action:  "access.delete",
+		Subject: groupName,
+		Before:  audit.Fields("members", dns.JoinRules(members), "services", strings.Join(hosts, ",")),
+		IDs:     audit.Fields("access_group", groupID),
+	})
+	return nil
 }
 
 // UpdateAccessGroupMembers adds or removes members from an Access group
-func (s *Service) UpdateAccessGroupMembers(groupName string, addEmails, removeEmails []string) error {
-	return s.cloudflare.UpdateAccessGroupMembers(groupName, addEmails, removeEmails)
+func (s *Service) UpdateAccessGroupMembers(ctx context.Context, groupName string, addEmails, removeEmails []string) error {
+	if s.dryRun {
+		return s.planUpdateAccessGroupMembers(ctx, groupName, addEmails, removeEmails)
+	}
+
+	before, _ := s.cloudflare.GetAccessGroupMembers(ctx, groupName)
+	if err := s.cloudflare.UpdateAccessGroupMembers(ctx, groupName, addEmails, removeEmails); err != nil {
+		return err
+	}
+
+	after, _ := s.cloudflare.GetAccessGroupMembers(ctx, groupName)
+	s.record(audit.Entry{
+		Action:  "access.update",
+		Subject: groupName,
+		Before:  audit.Fields("members", strings.Join(before, ",")),
+		After:   audit.Fields("members", strings.Join(after, ",")),
+	})
+	return nil
 }
 
 // GetAccessGroupMembers returns the list of members in an Access group
-func (s *Service) GetAccessGroupMembers(groupName string) ([]string, error) {
-	return s.cloudflare.GetAccessGroupMembers(groupName)
+func (s *Service) GetAccessGroupMembers(ctx context.Context, groupName string) ([]string, error) {
+	return s.cloudflare.GetAccessGroupMembers(ctx, groupName)
 }
 
-// RevokeAccess removes group access from a subdomain, reverting to private (owner-only)
-func (s *Service) RevokeAccess(subdomain string) error {
+// RevokeAccess removes group access, --allow rules, shares and public paths from a subdomain,
+// reverting to private (owner-only)
+func (s *Service) RevokeAccess(ctx context.Context, subdomain string) error {
 	if err := ValidateSubdomain(subdomain); err != nil {
 		return err
 	}
 
 	host := HostnameFor(subdomain, s.env.Domain)
 
+	if s.dryRun {
+		return s.planRevokeAccess(ctx, subdomain, host)
+	}
+
 	fmt.Printf("Revoking group access for %s...\n", host)
-	if err := s.cloudflare.RevokeGroupAccess(host); err != nil {
+	before := s.cloudflare.GetAccessInfo(ctx, host)
+	policyIDs, err := s.cloudflare.RevokeGroupAccess(ctx, host)
+	if len(policyIDs) > 0 {
+		s.record(audit.Entry{
+			Action:  "tunnel.revoke-access",
+			Subject: host,
+			Before:  audit.Fields("access", before),
+			After:   audit.Fields("access", AccessLevelPrivate),
+			IDs:     audit.Fields("access_policy", strings.Join(policyIDs, ",")),
+		})
+	}
+	if err != nil {
 		return fmt.Errorf("failed to revoke group access: %w", err)
 	}
 
+	// access is private now, so pending access, group and share expiries have nothing left to revoke
+	s.cancelExpiries(ctx, subdomain, ExpiryAccess, ExpiryGroup, ExpiryShare)
+
 	fmt.Printf("✔ Access reverted to private for %s\n", host)
 	return nil
 }
 
-// scheduleAccessExpiry schedules a systemd timer to revoke group access after the specified duration
-func (s *Service) scheduleAccessExpiry(subdomain string, duration time.Duration) error {
-	// Subdomain is already validated by ValidateSubdomain() before this function is called
-	// But we double-check to ensure no injection is possible
-	if err := ValidateSubdomain(subdomain); err != nil {
-		return fmt.Errorf("invalid subdomain for scheduling: %w", err)
+// record appends a completed change to the audit log; a failure to record is
+// reported but does not fail the change, which has already been applied
+func (s *Service) record(entry audit.Entry) {
+	if entry.Trigger == "" {
+		entry.Trigger = s.trigger
 	}
-
-	// Use systemd-run to schedule the revoke command
-	// Format: systemd-run --on-active=<duration> orb tunnel revoke-access <subdomain>
-	durationStr := fmt.Sprintf("%ds", int(duration.Seconds()))
-
-	// Pass arguments separately to prevent command injection
-	cmd := exec.Command("systemd-run",
-		"--user",
-		"--on-active="+durationStr,
-		"--unit=orb-expire-"+subdomain,
-		"--description=Revoke group access for "+subdomain,
-		"/usr/local/bin/orb", "tunnel", "revoke-access", subdomain,
-	)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to schedule expiry timer: %w\nOutput: %s", err, string(output))
+	if err := s.audit.Record(entry); err != nil {
+		fmt.Printf("⚠ Warning: failed to record change in audit log: %v\n", err)
 	}
+}
 
-	return nil
+// rollbackContext returns a context for undoing changes that outlives cancellation of ctx,
+// so an interrupted operation (e.g. Ctrl-C) still cleans up after itself
+func rollbackContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
 }
@@ -0,0 +1,324 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"orb/internal/dns"
+	"orb/internal/dns/dnstest"
+	"orb/internal/runner/runnertest"
+)
+
+// restartCmd is the command restarting the cloudflared service of the test tunnel
+const restartCmd = "sudo systemctl restart cloudflared-home"
+
+// errInjected is the failure injected into the fakes
+var errInjected = errors.New("injected failure")
+
+// testService returns a service on a fresh config with a single catch-all rule,
+// backed by the Cloudflare and runner fakes
+func testService(t *testing.T) (*Service, *dnstest.Fake, *runnertest.Fake) {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "config.yml")
+	config := "tunnel: tid\ncredentials-file: /etc/cloudflared/tid.json\ningress:\n  - service: http_status:404\n"
+	if err := os.WriteFile(cfgPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("USER_EMAIL", "owner@example.com")
+
+	cf := dnstest.New()
+	cf.Tunnels["tid"] = "home"
+	cf.Groups["friends"] = &dnstest.Group{ID: "group-friends", Name: "friends", Emails: []string{"bob@example.com"}}
+	r := runnertest.New()
+	svc := NewServiceWith(&Environment{Domain: "example.com", ConfigPath: cfgPath}, Deps{
+		Cloudflare: cf,
+		Runner:     r,
+		HTTP:       &http.Client{Transport: okTransport{}},
+		SSHConfig:  filepath.Join(t.TempDir(), "ssh_config"),
+	}, Options{})
+	return svc, cf, r
+}
+
+// okTransport answers every request with 200 OK, so health checks stay offline
+type okTransport struct{}
+
+func (okTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+// ingressHosts returns the hostnames in the saved config
+func ingressHosts(t *testing.T, svc *Service) []string {
+	t.Helper()
+	cfg, err := svc.config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hosts []string
+	for _, rule := range cfg.Ingress {
+		if rule.Hostname != "" {
+			hosts = append(hosts, rule.Hostname+" "+rule.Service)
+		}
+	}
+	return hosts
+}
+
+// captureStdout returns what fn prints
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	fnErr := fn()
+	w.Close()
+	return <-done, fnErr
+}
+
+func TestExposeRollsBackOnFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   ExposeOptions
+		inject func(cf *dnstest.Fake, r *runnertest.Fake)
+	}{
+		{
+			name:   "dns route",
+			opts:   ExposeOptions{Access: AccessLevelPrivate},
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { cf.FailOn("CreateDNSRoute", errInjected) },
+		},
+		{
+			name:   "access policy",
+			opts:   ExposeOptions{Access: AccessLevelPrivate},
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { cf.FailOn("CreateAccessPolicy", errInjected) },
+		},
+		{
+			name:   "access group missing",
+			opts:   ExposeOptions{Access: "strangers"},
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) {},
+		},
+		{
+			name:   "tunnel name",
+			opts:   ExposeOptions{Access: "friends"},
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { cf.FailOn("GetTunnelName", errInjected) },
+		},
+		{
+			name:   "restart",
+			opts:   ExposeOptions{Access: AccessLevelPublic},
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { r.On(restartCmd, runnertest.Result{Err: errInjected}) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, cf, r := testService(t)
+			tt.inject(cf, r)
+
+			if err := svc.Expose(context.Background(), "api", "8080", "http", tt.opts); err == nil {
+				t.Fatal("Expose succeeded despite the injected failure")
+			}
+
+			if hosts := ingressHosts(t, svc); len(hosts) != 0 {
+				t.Errorf("config not restored, ingress still has %v", hosts)
+			}
+			if _, ok := cf.Records["api.example.com"]; ok {
+				t.Error("DNS record not removed")
+			}
+			if len(cf.Apps) != 0 {
+				t.Errorf("Access applications not removed: %d left", len(cf.Apps))
+			}
+		})
+	}
+}
+
+func TestUnexposeRollsBackOnFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		inject func(cf *dnstest.Fake, r *runnertest.Fake)
+	}{
+		{
+			name:   "dns route",
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { cf.FailOn("RemoveDNSRoute", errInjected) },
+		},
+		{
+			name:   "tunnel name",
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { cf.FailOn("GetTunnelName", errInjected) },
+		},
+		{
+			name:   "restart",
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { r.On(restartCmd, runnertest.Result{Err: errInjected}) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, cf, r := testService(t)
+			ctx := context.Background()
+			if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: "friends", PublicPaths: []string{"/webhook/*"}}); err != nil {
+				t.Fatal(err)
+			}
+			cf.Apps["api.example.com"].Settings = dns.AppSettings{SessionDuration: "2h", DenyMessage: "ask bob"}
+			before, err := cf.GetAccess(ctx, "api.example.com")
+			if err != nil || before == nil {
+				t.Fatalf("no Access application after expose: %v", err)
+			}
+
+			tt.inject(cf, r)
+			if err := svc.Unexpose(ctx, "api"); err == nil {
+				t.Fatal("Unexpose succeeded despite the injected failure")
+			}
+
+			if hosts := ingressHosts(t, svc); !slices.Equal(hosts, []string{"api.example.com http://localhost:8080"}) {
+				t.Errorf("config not restored, ingress is %v", hosts)
+			}
+			if _, ok := cf.Records["api.example.com"]; !ok {
+				t.Error("DNS record not restored")
+			}
+			cf.FailOn("GetAccess", nil)
+			after, err := cf.GetAccess(ctx, "api.example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after.String() != before.String() {
+				t.Errorf("Access not restored: got %q, want %q", after.String(), before.String())
+			}
+			if !slices.Equal(after.PublicPaths, []string{"/webhook/*"}) {
+				t.Errorf("public paths not restored: %v", after.PublicPaths)
+			}
+			if !reflect.DeepEqual(after.Settings, before.Settings) {
+				t.Errorf("settings not restored: got %+v, want %+v", *after.Settings, *before.Settings)
+			}
+		})
+	}
+}
+
+func TestUpdateRollsBackOnFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		inject func(cf *dnstest.Fake, r *runnertest.Fake)
+	}{
+		{
+			name:   "tunnel name",
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { cf.FailOn("GetTunnelName", errInjected) },
+		},
+		{
+			name:   "restart",
+			inject: func(cf *dnstest.Fake, r *runnertest.Fake) { r.On(restartCmd, runnertest.Result{Err: errInjected}) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, cf, r := testService(t)
+			ctx := context.Background()
+			if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: AccessLevelPublic}); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.inject(cf, r)
+			if err := svc.Update(ctx, "api", "9090", "http"); err == nil {
+				t.Fatal("Update succeeded despite the injected failure")
+			}
+
+			if hosts := ingressHosts(t, svc); !slices.Equal(hosts, []string{"api.example.com http://localhost:8080"}) {
+				t.Errorf("config not restored, ingress is %v", hosts)
+			}
+		})
+	}
+}
+
+func TestExposeUnexposeUpdate(t *testing.T) {
+	svc, cf, r := testService(t)
+	ctx := context.Background()
+
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: AccessLevelPrivate}); err != nil {
+		t.Fatal(err)
+	}
+	if cf.Records["api.example.com"] != "tid.cfargotunnel.com" {
+		t.Errorf("DNS record = %q", cf.Records["api.example.com"])
+	}
+	if cf.Apps["api.example.com"] == nil {
+		t.Error("no Access application for a private service")
+	}
+	if !r.Ran(restartCmd) {
+		t.Errorf("cloudflared not restarted: %v", r.Calls())
+	}
+
+	if err := svc.Update(ctx, "api", "9090", "http"); err != nil {
+		t.Fatal(err)
+	}
+	if hosts := ingressHosts(t, svc); !slices.Equal(hosts, []string{"api.example.com http://localhost:9090"}) {
+		t.Errorf("ingress after update = %v", hosts)
+	}
+
+	if err := svc.Unexpose(ctx, "api"); err != nil {
+		t.Fatal(err)
+	}
+	if hosts := ingressHosts(t, svc); len(hosts) != 0 {
+		t.Errorf("ingress after unexpose = %v", hosts)
+	}
+	if len(cf.Records) != 0 || len(cf.Apps) != 0 {
+		t.Errorf("leftover records %v or apps %v", cf.Records, cf.Apps)
+	}
+}
+
+func TestList(t *testing.T) {
+	svc, _, _ := testService(t)
+	ctx := context.Background()
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: AccessLevelPrivate}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Expose(ctx, "www", "3000", "http", ExposeOptions{Access: AccessLevelPublic}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error { return svc.List(ctx) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"https://api.example.com", "http://localhost:8080", "private", "https://www.example.com", "public", "healthy"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("List output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRevokeAccess(t *testing.T) {
+	svc, cf, _ := testService(t)
+	ctx := context.Background()
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: "friends"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := cf.GetAccessInfo(ctx, "api.example.com"); got != "friends" {
+		t.Fatalf("access after expose = %q, want friends", got)
+	}
+
+	if err := svc.RevokeAccess(ctx, "api"); err != nil {
+		t.Fatal(err)
+	}
+	access, err := cf.GetAccess(ctx, "api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := access.String(); got != AccessLevelPrivate {
+		t.Errorf("access after revoke = %q, want private", got)
+	}
+	if owners := access.Policies[0].Include; !slices.Equal(owners, []dns.Rule{{Type: dns.RuleEmail, Value: "owner@example.com"}}) {
+		t.Errorf("owners after revoke = %v", owners)
+	}
+}
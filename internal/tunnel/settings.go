@@ -0,0 +1,268 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/dns"
+	"orb/internal/plan"
+)
+
+// SettingsChange holds the Access application settings given on the command line.
+// Nil fields keep their current value.
+type SettingsChange struct {
+	SessionDuration   *string
+	IdentityProviders *[]string // "all" (or empty) offers every identity provider
+	AutoRedirect      *bool
+	CORSOrigins       *[]string
+	CORSMethods       *[]string
+	CORSHeaders       *[]string
+	CORSCredentials   *bool
+	NoCORS            bool // drop the CORS settings
+	Launcher          *bool
+	DenyMessage       *string
+	DenyURL           *string
+}
+
+// corsMethods are the methods Cloudflare accepts in CORS settings
+var corsMethods = map[string]bool{
+	"GET": true, "POST": true, "HEAD": true, "PUT": true, "DELETE": true,
+	"CONNECT": true, "OPTIONS": true, "TRACE": true, "PATCH": true, "*": true,
+}
+
+// IsZero reports whether the change leaves every setting as it is
+func (c SettingsChange) IsZero() bool {
+	return c == SettingsChange{}
+}
+
+// Apply returns current with the change applied
+func (c SettingsChange) Apply(current dns.AppSettings) dns.AppSettings {
+	next := current
+	if c.SessionDuration != nil {
+		next.SessionDuration = *c.SessionDuration
+	}
+	if c.IdentityProviders != nil {
+		next.IdentityProviders = nil
+		for _, name := range *c.IdentityProviders {
+			if name != "all" {
+				next.IdentityProviders = append(next.IdentityProviders, name)
+			}
+		}
+	}
+	if c.AutoRedirect != nil {
+		next.AutoRedirect = *c.AutoRedirect
+	}
+	if c.Launcher != nil {
+		next.HideFromLauncher = !*c.Launcher
+	}
+	if c.DenyMessage != nil {
+		next.DenyMessage = *c.DenyMessage
+	}
+	if c.DenyURL != nil {
+		next.DenyURL = *c.DenyURL
+	}
+
+	if c.NoCORS {
+		next.CORS = nil
+	} else if c.CORSOrigins != nil || c.CORSMethods != nil || c.CORSHeaders != nil || c.CORSCredentials != nil {
+		cors := dns.CORS{}
+		if current.CORS != nil {
+			cors = *current.CORS
+		}
+		if c.CORSOrigins != nil {
+			cors.AllowedOrigins = *c.CORSOrigins
+		}
+		if c.CORSMethods != nil {
+			cors.AllowedMethods = nil
+			for _, method := range *c.CORSMethods {
+				cors.AllowedMethods = append(cors.AllowedMethods, strings.ToUpper(method))
+			}
+		}
+		if c.CORSHeaders != nil {
+			cors.AllowedHeaders = *c.CORSHeaders
+		}
+		if c.CORSCredentials != nil {
+			cors.AllowCredentials = *c.CORSCredentials
+		}
+		next.CORS = &cors
+	}
+	return next
+}
+
+// ValidateAppSettings checks settings before they are sent to Cloudflare
+func ValidateAppSettings(settings dns.AppSettings) error {
+	if settings.SessionDuration != "" {
+		d, err := time.ParseDuration(settings.SessionDuration)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid --session-duration %q (e.g., 30m, 24h, 720h)", settings.SessionDuration)
+		}
+	}
+	if settings.AutoRedirect && len(settings.IdentityProviders) != 1 {
+		return fmt.Errorf("--auto-redirect needs exactly one identity provider (e.g., --idp github --auto-redirect)")
+	}
+	if settings.DenyURL != "" {
+		u, err := url.Parse(settings.DenyURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid --deny-url %q (e.g., https://example.com/no-access)", settings.DenyURL)
+		}
+	}
+	if cors := settings.CORS; cors != nil {
+		for _, method := range cors.AllowedMethods {
+			if !corsMethods[method] {
+				return fmt.Errorf("invalid --cors-method %q (e.g., GET, POST, OPTIONS or *)", method)
+			}
+		}
+		for _, origin := range cors.AllowedOrigins {
+			if origin == "*" && cors.AllowCredentials {
+				return fmt.Errorf("--cors-credentials cannot be combined with --cors-origin '*' - list the origins instead")
+			}
+		}
+	}
+	return nil
+}
+
+// settingsLines describes every setting on its own line, defaults included
+func settingsLines(settings dns.AppSettings) []string {
+	session := settings.SessionDuration
+	if session == "" {
+		session = "24h (default)"
+	}
+	idps := "all"
+	if len(settings.IdentityProviders) > 0 {
+		idps = strings.Join(settings.IdentityProviders, ", ")
+	}
+	redirect := "off"
+	if settings.AutoRedirect {
+		redirect = "on"
+	}
+	launcher := "shown"
+	if settings.HideFromLauncher {
+		launcher = "hidden"
+	}
+	message := settings.DenyMessage
+	if message == "" {
+		message = "(default)"
+	}
+	denyURL := settings.DenyURL
+	if denyURL == "" {
+		denyURL = "(none)"
+	}
+
+	return []string{
+		"Session duration: " + session,
+		"Identity providers: " + idps,
+		"Auto-redirect: " + redirect,
+		"CORS: " + settings.CORS.String(),
+		"App Launcher: " + launcher,
+		"Deny message: " + message,
+		"Deny URL: " + denyURL,
+	}
+}
+
+// settingsSummary describes the settings that differ from the defaults on one line
+func settingsSummary(settings dns.AppSettings) string {
+	defaults := settingsLines(dns.AppSettings{})
+	var changed []string
+	for i, line := range settingsLines(settings) {
+		if line != defaults[i] {
+			changed = append(changed, line)
+		}
+	}
+	return strings.Join(changed, "; ")
+}
+
+// SetAppSettings changes the settings of the Access application of an exposed
+// subdomain, keeping its policies
+func (s *Service) SetAppSettings(ctx context.Context, subdomain string, change SettingsChange) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+	if change.IsZero() {
+		return fmt.Errorf("nothing to change (e.g., orb tunnel settings %s --session-duration 8h)", subdomain)
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	if err := s.requireExposed(host); err != nil {
+		return err
+	}
+
+	before, err := s.cloudflare.GetAppSettings(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get application settings: %w", err)
+	}
+	if before == nil {
+		return fmt.Errorf("✖ %s is public - it has no Access application to configure\n  Protect it first with `orb tunnel access %s private`", host, subdomain)
+	}
+
+	after := change.Apply(*before)
+	if err := ValidateAppSettings(after); err != nil {
+		return err
+	}
+
+	if s.dryRun {
+		p := plan.New()
+		p.Diff("Settings", strings.Join(settingsLines(*before), "\n")+"\n", strings.Join(settingsLines(after), "\n")+"\n")
+		p.Note("Access", "policies of %s are unchanged", host)
+		p.Print()
+		return nil
+	}
+
+	fmt.Printf("Updating Access settings for %s...\n", host)
+	if err := s.cloudflare.SetAppSettings(ctx, host, after); err != nil {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+
+	s.record(audit.Entry{
+		Action:  "tunnel.settings",
+		Subject: host,
+		Before:  audit.Fields("settings", settingsSummary(*before)),
+		After:   audit.Fields("settings", settingsSummary(after)),
+	})
+
+	fmt.Printf("✔ Updated Access settings for %s\n", host)
+	for _, line := range settingsLines(after) {
+		fmt.Printf("  %s\n", line)
+	}
+	return nil
+}
+
+// Inspect prints everything orb knows about an exposed subdomain: where it points,
+// the settings of its Access application and who can reach it
+func (s *Service) Inspect(ctx context.Context, subdomain string) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+	idx := s.config.FindIngressIndex(cfg, host)
+	if idx == -1 {
+		return fmt.Errorf("✖ %s is not currently exposed", host)
+	}
+
+	fmt.Printf("%s → %s\n", host, cfg.Ingress[idx].Service)
+	if ttl, ok, _ := s.expiries.Get(ExpiryTTL, subdomain, ""); ok {
+		fmt.Printf("  Exposure ends: %s (in %s)\n", ttl.At.Format("2006-01-02 15:04:05"), FormatRemaining(time.Until(ttl.At)))
+	}
+
+	settings, err := s.cloudflare.GetAppSettings(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get application settings: %w", err)
+	}
+	if settings != nil {
+		fmt.Println("\nSettings:")
+		for _, line := range settingsLines(*settings) {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	fmt.Println()
+	return s.ShowAccess(ctx, subdomain)
+}
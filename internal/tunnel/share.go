@@ -0,0 +1,188 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/dns"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Share lets a single email address reach an exposed subdomain for a limited time.
+// The grant is its own Access policy, removed by orb once duration has passed;
+// sharing again with the same address moves the deadline.
+func (s *Service) Share(ctx context.Context, subdomain, email, duration string) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+	rule, err := dns.ParseRule(dns.RuleEmail + ":" + email)
+	if err != nil {
+		return err
+	}
+	email = rule.Value
+	if duration == "" {
+		return fmt.Errorf("--for is required (e.g., orb tunnel share %s %s --for 4h)", subdomain, email)
+	}
+	if err := ValidateExpiresDuration(duration); err != nil {
+		return fmt.Errorf("invalid --for: %w", err)
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	if err := s.requireExposed(host); err != nil {
+		return err
+	}
+
+	before, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+	if before == nil {
+		return fmt.Errorf("✖ %s is public - anyone with the URL can already reach it", host)
+	}
+
+	_, shared := before.Shares()[email]
+	desired := before
+	if !shared {
+		desired = &dns.Access{AppID: before.AppID}
+		desired.Policies = append(desired.Policies, before.Policies...)
+		desired.Policies = append(desired.Policies, dns.SharePolicy(host, email, before.NextSharePrecedence()))
+	}
+
+	if s.dryRun {
+		return s.planShareChange(subdomain, host, before, desired, email, duration)
+	}
+
+	appID := before.AppID
+	if !shared {
+		fmt.Printf("Sharing %s with %s...\n", host, email)
+		if appID, err = s.applyAccessChange(ctx, host, before, desired); err != nil {
+			return fmt.Errorf("failed to share: %w", err)
+		}
+	}
+
+	d, _ := ParseExpiresDuration(duration) // already validated
+	until := time.Now().Add(d)
+	s.record(audit.Entry{
+		Action:  "tunnel.share",
+		Subject: host,
+		After:   audit.Fields("email", email, "until", until.Format(time.RFC3339)),
+		IDs:     audit.Fields("access_app", appID),
+	})
+
+	if err := s.scheduleExpiry(ctx, Expiry{Kind: ExpiryShare, Subdomain: subdomain, Target: email, At: until}); err != nil {
+		fmt.Printf("⚠ Warning: failed to schedule end of share - %s keeps access until unshared: %v\n", email, err)
+	}
+
+	if shared {
+		fmt.Printf("✔ %s already had access to %s - now until %s (in %s)\n", email, host, until.Format("2006-01-02 15:04:05"), duration)
+	} else {
+		fmt.Printf("✔ Shared %s with %s until %s (in %s)\n", host, email, until.Format("2006-01-02 15:04:05"), duration)
+	}
+	return nil
+}
+
+// Shares lists the email addresses an exposed subdomain is shared with and until when
+func (s *Service) Shares(ctx context.Context, subdomain string) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	access, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	shares := access.Shares()
+	if len(shares) == 0 {
+		fmt.Printf("%s is not shared with anyone\n", host)
+		fmt.Printf("\nUse `orb tunnel share %s <email> --for 4h` to share it\n", subdomain)
+		return nil
+	}
+
+	emails := make([]string, 0, len(shares))
+	for email := range shares {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Email", "Until", "In")
+	for _, email := range emails {
+		until, in := "never (unshare by hand)", "-"
+		if expiry, ok, _ := s.expiries.Get(ExpiryShare, subdomain, email); ok {
+			until = expiry.At.Format("2006-01-02 15:04:05")
+			in = FormatRemaining(time.Until(expiry.At))
+		}
+		if err := table.Append(email, until, in); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\n%s is shared with:\n", host)
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	return nil
+}
+
+// Unshare removes the share policy of an email address from an exposed subdomain
+// before its time is up
+func (s *Service) Unshare(ctx context.Context, subdomain, email string) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	host := HostnameFor(subdomain, s.env.Domain)
+	before, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+
+	name, shared := before.Shares()[email]
+	if !shared {
+		// nothing to remove, but a stale expiry for the share has nothing left to do
+		if err := s.cancelExpiry(ctx, ExpiryShare, subdomain, email); err != nil {
+			fmt.Printf("⚠ Warning: failed to cancel share expiry for %s: %v\n", host, err)
+		}
+		return fmt.Errorf("✖ %s is not shared with %s", host, email)
+	}
+
+	desired := &dns.Access{AppID: before.AppID}
+	for _, policy := range before.Policies {
+		if policy.Name != name {
+			desired.Policies = append(desired.Policies, policy)
+		}
+	}
+
+	if s.dryRun {
+		return s.planShareChange(subdomain, host, before, desired, email, "")
+	}
+
+	fmt.Printf("Removing %s's access to %s...\n", email, host)
+	appID, err := s.applyAccessChange(ctx, host, before, desired)
+	if err != nil {
+		return fmt.Errorf("failed to unshare: %w", err)
+	}
+
+	s.record(audit.Entry{
+		Action:  "tunnel.unshare",
+		Subject: host,
+		Before:  audit.Fields("email", email),
+		IDs:     audit.Fields("access_app", appID),
+	})
+
+	if err := s.cancelExpiry(ctx, ExpiryShare, subdomain, email); err != nil {
+		fmt.Printf("⚠ Warning: failed to cancel share expiry for %s: %v\n", host, err)
+	}
+
+	fmt.Printf("✔ %s no longer has access to %s\n", email, host)
+	return nil
+}
@@ -0,0 +1,250 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"orb/internal/plan"
+)
+
+// Markers around the block orb manages in ~/.ssh/config
+const (
+	sshConfigBegin = "# BEGIN orb ssh-config (managed by `orb ssh-config --install` - do not edit)"
+	sshConfigEnd   = "# END orb ssh-config"
+)
+
+// SSHConfigPath returns the OpenSSH client config orb merges its Host entries into
+func SSHConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".ssh", "config"), nil
+}
+
+// NewSSHConfigService creates a service for SSHConfig and UninstallSSHConfig, which
+// only read the tunnel config at CONFIG_PATH and edit ~/.ssh/config: no Cloudflare
+// API token is used
+func NewSSHConfigService(dryRun bool) (*Service, error) {
+	configPath := os.Getenv("CONFIG_PATH")
+	if configPath == "" {
+		return nil, fmt.Errorf("CONFIG_PATH environment variable is required")
+	}
+	sshConfig, err := SSHConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	env := &Environment{Domain: os.Getenv("DOMAIN"), ConfigPath: configPath}
+	return NewServiceWith(env, Deps{SSHConfig: sshConfig}, Options{DryRun: dryRun}), nil
+}
+
+// errNoSSHConfig is the error for installing or removing ssh hosts without an ssh config path
+var errNoSSHConfig = errors.New("no ssh config path: failed to get home directory")
+
+// SSHConfig prints the Host entries of every ssh service in the tunnel config, or
+// with install merges them into ~/.ssh/config between marker comments, where
+// expose, update and unexpose keep them in sync from then on
+func (s *Service) SSHConfig(install bool) error {
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+	hosts := sshHosts(cfg)
+
+	if !install {
+		if len(hosts) == 0 {
+			fmt.Println("# No ssh services - expose one with `orb tunnel expose <subdomain> 22 --type ssh`")
+			return nil
+		}
+		fmt.Print(renderSSHHosts(hosts))
+		return nil
+	}
+
+	path := s.sshConfig
+	if path == "" {
+		return errNoSSHConfig
+	}
+	before, err := readSSHConfig(path)
+	if err != nil {
+		return err
+	}
+	after := mergeSSHConfig(before, renderSSHBlock(hosts))
+
+	if s.dryRun {
+		p := plan.New()
+		p.Diff(path, before, after)
+		p.Print()
+		return nil
+	}
+
+	if after != before {
+		if err := writeSSHConfig(path, after); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("✔ Installed %d ssh host(s) in %s\n", len(hosts), path)
+	for _, host := range hosts {
+		fmt.Printf("  ssh %s\n", host)
+	}
+	fmt.Println("  Kept in sync as ssh services are exposed and unexposed (remove with `orb ssh-config --uninstall`)")
+	return nil
+}
+
+// UninstallSSHConfig removes orb's block from ~/.ssh/config
+func (s *Service) UninstallSSHConfig() error {
+	path := s.sshConfig
+	if path == "" {
+		return errNoSSHConfig
+	}
+	before, err := readSSHConfig(path)
+	if err != nil {
+		return err
+	}
+	if !hasSSHBlock(before) {
+		fmt.Printf("ℹ️  %s has no orb block (nothing to remove)\n", path)
+		return nil
+	}
+	after := mergeSSHConfig(before, "")
+
+	if s.dryRun {
+		p := plan.New()
+		p.Diff(path, before, after)
+		p.Print()
+		return nil
+	}
+
+	if err := writeSSHConfig(path, after); err != nil {
+		return err
+	}
+	fmt.Printf("✔ Removed orb's ssh hosts from %s\n", path)
+	return nil
+}
+
+// syncSSHConfig refreshes orb's block in ~/.ssh/config after the tunnel config
+// changed, if `ssh-config --install` put one there. Failures only warn: the
+// tunnel change itself already went through. Without an ssh config path it does nothing.
+func (s *Service) syncSSHConfig(cfg *Config) {
+	path := s.sshConfig
+	if path == "" {
+		return
+	}
+	before, err := readSSHConfig(path)
+	if err != nil || !hasSSHBlock(before) {
+		return
+	}
+	after := mergeSSHConfig(before, renderSSHBlock(sshHosts(cfg)))
+	if after == before {
+		return
+	}
+	if err := writeSSHConfig(path, after); err != nil {
+		fmt.Printf("⚠ Warning: failed to update ssh hosts in %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("  Updated ssh hosts in %s\n", path)
+}
+
+// sshHosts returns the hostnames of the ssh services in the tunnel config
+func sshHosts(cfg *Config) []string {
+	var hosts []string
+	for _, rule := range cfg.Ingress {
+		if rule.Hostname == "" {
+			continue
+		}
+		if u, err := url.Parse(rule.Service); err == nil && u.Scheme == ServiceTypeSSH {
+			hosts = append(hosts, rule.Hostname)
+		}
+	}
+	return hosts
+}
+
+// renderSSHHosts renders one Host entry per hostname, reached through cloudflared
+func renderSSHHosts(hosts []string) string {
+	var b strings.Builder
+	for i, host := range hosts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Host %s\n  ProxyCommand cloudflared access ssh --hostname %%h\n", host)
+	}
+	return b.String()
+}
+
+// renderSSHBlock renders the Host entries between orb's markers
+func renderSSHBlock(hosts []string) string {
+	block := sshConfigBegin + "\n"
+	if len(hosts) == 0 {
+		block += "# (no ssh services exposed)\n"
+	} else {
+		block += renderSSHHosts(hosts)
+	}
+	return block + sshConfigEnd + "\n"
+}
+
+// hasSSHBlock reports whether an ssh config contains orb's block
+func hasSSHBlock(content string) bool {
+	return strings.Contains(content, sshConfigBegin) && strings.Contains(content, sshConfigEnd)
+}
+
+// mergeSSHConfig replaces orb's block in an ssh config with block, appending it if
+// there is none yet; an empty block removes it. The rest of the file is kept as is.
+func mergeSSHConfig(content, block string) string {
+	start := strings.Index(content, sshConfigBegin)
+	end := strings.Index(content, sshConfigEnd)
+	if start != -1 && end > start {
+		rest := strings.TrimPrefix(content[end+len(sshConfigEnd):], "\n")
+		if block == "" {
+			// drop the blank line that separated the block from what came before
+			head := strings.TrimRight(content[:start], "\n")
+			if head != "" {
+				head += "\n"
+				if rest != "" {
+					head += "\n"
+				}
+			}
+			return head + strings.TrimLeft(rest, "\n")
+		}
+		return content[:start] + block + rest
+	}
+
+	if block == "" {
+		return content
+	}
+	if content == "" {
+		return block
+	}
+	// the block goes after the user's own entries, separated by a blank line
+	return strings.TrimRight(content, "\n") + "\n\n" + block
+}
+
+// readSSHConfig reads an ssh config, treating a missing file as empty
+func readSSHConfig(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// writeSSHConfig writes an ssh config, creating ~/.ssh if needed and keeping the
+// file's permissions (ssh refuses a config others can write)
+func writeSSHConfig(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package tunnel
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSSHConfigSyncFollowsExposures(t *testing.T) {
+	svc, _, _ := testService(t)
+	ctx := context.Background()
+	user := "Host laptop\n  User me\n"
+	if err := os.WriteFile(svc.sshConfig, []byte(user), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is written before ssh-config --install adds orb's block
+	if err := svc.Expose(ctx, "box", "22", ServiceTypeSSH, ExposeOptions{Access: "private"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, svc.sshConfig); got != user {
+		t.Fatalf("ssh config changed without an installed block:\n%s", got)
+	}
+
+	if _, err := captureStdout(t, func() error { return svc.SSHConfig(true) }); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, svc.sshConfig); !strings.HasPrefix(got, user) || !strings.Contains(got, "Host box.example.com\n") {
+		t.Fatalf("installed ssh config:\n%s", got)
+	}
+
+	if err := svc.Unexpose(ctx, "box"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, svc.sshConfig); strings.Contains(got, "box.example.com") {
+		t.Errorf("unexposed host still in ssh config:\n%s", got)
+	}
+
+	if _, err := captureStdout(t, svc.UninstallSSHConfig); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, svc.sshConfig); got != user {
+		t.Errorf("uninstalled ssh config = %q, want %q", got, user)
+	}
+}
+
+func TestSSHConfigWithoutPath(t *testing.T) {
+	svc, _, _ := testService(t)
+	svc.sshConfig = ""
+	if err := svc.Expose(context.Background(), "box", "22", ServiceTypeSSH, ExposeOptions{Access: "private"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SSHConfig(true); err == nil {
+		t.Error("ssh-config --install succeeded without an ssh config path")
+	}
+	if err := svc.UninstallSSHConfig(); err == nil {
+		t.Error("ssh-config --uninstall succeeded without an ssh config path")
+	}
+}
+
+// readFile returns the content of a file the test expects to exist
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
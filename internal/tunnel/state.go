@@ -0,0 +1,123 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"orb/internal/dns"
+)
+
+// Access kinds reported by ServiceStates, a fixed set so they can label metrics
+const (
+	AccessKindPublic    = "public"    // no Access application
+	AccessKindProtected = "protected" // an Access application without policies (nobody gets in)
+	AccessKindPrivate   = "private"   // owner only
+	AccessKindGroup     = "group"     // owner and Access groups
+	AccessKindShared    = "shared"    // other --allow rules, service tokens or shares
+	AccessKindUnknown   = "unknown"   // the Access lookup failed
+)
+
+// AccessKinds lists every access kind, so callers can report kinds with no services
+var AccessKinds = []string{AccessKindPublic, AccessKindProtected, AccessKindPrivate, AccessKindGroup, AccessKindShared, AccessKindUnknown}
+
+// ServiceState is an exposed service with its access and the result of probing it
+type ServiceState struct {
+	Hostname   string
+	Target     string
+	Access     string // one of AccessKinds
+	Healthy    bool
+	StatusCode int // 0 if the request failed
+	Latency    time.Duration
+}
+
+// ServiceStates looks up the access of every exposed service and probes it,
+// in parallel, returning them by hostname
+func (s *Service) ServiceStates(ctx context.Context) ([]ServiceState, error) {
+	cfg, err := s.config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var states []ServiceState
+	for _, rule := range cfg.Ingress {
+		if rule.Hostname == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(r IngressRule) {
+			defer wg.Done()
+			state := ServiceState{Hostname: r.Hostname, Target: r.Service, Access: AccessKindUnknown}
+			if access, err := s.cloudflare.GetAccess(ctx, r.Hostname); err == nil {
+				state.Access = accessKind(access)
+			}
+			state.StatusCode, state.Latency = s.probe(ctx, r.Hostname)
+			state.Healthy = healthyStatus(state.StatusCode)
+
+			mu.Lock()
+			states = append(states, state)
+			mu.Unlock()
+		}(rule)
+	}
+	wg.Wait()
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Hostname < states[j].Hostname })
+	return states, nil
+}
+
+// PendingExpiries returns the expiries that have not been applied yet
+func (s *Service) PendingExpiries() ([]Expiry, error) {
+	return s.expiries.List()
+}
+
+// probe requests a hostname over HTTPS, returning the status code (0 if the
+// request failed) and how long the response took
+func (s *Service) probe(ctx context.Context, hostname string) (int, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s", hostname), nil)
+	if err != nil {
+		return 0, 0
+	}
+	resp, err := s.http.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return 0, latency
+	}
+	resp.Body.Close()
+	return resp.StatusCode, latency
+}
+
+// healthyStatus reports whether a probe status counts as healthy (2xx or 3xx,
+// which includes the redirect to the Access login page)
+func healthyStatus(code int) bool {
+	return code >= 200 && code < 400
+}
+
+// accessKind classifies the Access application of a hostname
+func accessKind(access *dns.Access) string {
+	if access == nil {
+		return AccessKindPublic
+	}
+	if len(access.Policies) == 0 {
+		return AccessKindProtected
+	}
+
+	allow := access.Rules().Allow
+	if len(allow) == 0 {
+		return AccessKindPrivate
+	}
+	for _, rule := range allow {
+		if rule.Type != dns.RuleGroup {
+			return AccessKindShared
+		}
+	}
+	return AccessKindGroup
+}
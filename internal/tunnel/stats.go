@@ -0,0 +1,495 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/plan"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// DefaultMetricsAddress is the metrics address suggested when none is set, the
+// first of the ports cloudflared itself tries
+const DefaultMetricsAddress = "localhost:20241"
+
+// statsInterval is how long `tunnel stats` samples the counters to compute rates,
+// and how often --watch refreshes
+var statsInterval = 5 * time.Second
+
+// cloudflared metrics read by `tunnel stats`
+const (
+	metricRequests = "cloudflared_tunnel_total_requests"
+	metricErrors   = "cloudflared_tunnel_request_errors"
+	metricCodes    = "cloudflared_tunnel_response_by_code"
+	metricActive   = "cloudflared_tunnel_concurrent_requests_per_tunnel"
+)
+
+// hostnameLabels are the labels that break a metric down by service, where present
+var hostnameLabels = []string{"hostname", "host"}
+
+// metricSample is one line of the Prometheus text format
+type metricSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// traffic is what the counters of one service (or of the whole tunnel) add up to
+type traffic struct {
+	requests float64
+	errors   float64
+	active   float64
+	codes    map[string]float64 // status code -> responses
+}
+
+// trafficSnapshot is the traffic of every service at one scrape, by hostname
+// ("" for the tunnel-wide metrics)
+type trafficSnapshot struct {
+	at       time.Time
+	services map[string]*traffic
+}
+
+// ShowMetrics prints the address cloudflared serves Prometheus metrics on, if any
+func (s *Service) ShowMetrics() error {
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.Metrics == "" {
+		fmt.Println("cloudflared has no metrics address configured")
+		fmt.Printf("\nUse `orb tunnel metrics %s` to set one\n", DefaultMetricsAddress)
+		return nil
+	}
+	fmt.Printf("cloudflared serves metrics on %s\n", metricsURL(cfg.Metrics))
+	fmt.Println("\nSee traffic per service with `orb tunnel stats`")
+	return nil
+}
+
+// SetMetrics sets the address cloudflared serves Prometheus metrics on (an empty
+// address removes it) and restarts cloudflared to apply it
+func (s *Service) SetMetrics(ctx context.Context, address string) error {
+	if address != "" {
+		if err := ValidateMetricsAddress(address); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.Metrics == address {
+		fmt.Println("ℹ️  Metrics address unchanged (no changes needed)")
+		return nil
+	}
+
+	// start of TRANSACTION
+	orginalCfg := s.config.Backup(cfg)
+	cfg.Metrics = address
+
+	if s.dryRun {
+		p := plan.New()
+		if err := s.planConfig(p, orginalCfg, cfg); err != nil {
+			return err
+		}
+		s.planRestart(ctx, p, cfg.Tunnel)
+		p.Print()
+		return nil
+	}
+
+	configSaved := false
+
+	defer func() {
+		if !configSaved {
+			return
+		}
+
+		fmt.Println("Rolling back: Restoring original config...")
+		if err := s.config.Save(orginalCfg); err != nil {
+			fmt.Printf("Failed to restore original config: %v\n", err)
+		}
+	}()
+
+	if err := s.config.Save(cfg); err != nil {
+		return err
+	}
+	configSaved = true
+
+	if err := s.restartTunnel(ctx, cfg.Tunnel); err != nil {
+		return err
+	}
+
+	// reset rollback
+	configSaved = false
+
+	s.record(audit.Entry{
+		Action:  "tunnel.metrics",
+		Subject: cfg.Tunnel,
+		Before:  audit.Fields("metrics", orginalCfg.Metrics),
+		After:   audit.Fields("metrics", address),
+		IDs:     audit.Fields("tunnel", cfg.Tunnel),
+	})
+
+	if address == "" {
+		fmt.Println("✔ Removed the metrics address")
+		return nil
+	}
+	fmt.Printf("✔ cloudflared serves metrics on %s\n", metricsURL(address))
+	fmt.Println("  See traffic per service with `orb tunnel stats`")
+	return nil
+}
+
+// Stats scrapes cloudflared's metrics twice, statsInterval apart, and shows the
+// request rate, error rate and status codes of a subdomain (or of every service).
+// With watch it keeps refreshing until ctx is cancelled (Ctrl+C).
+func (s *Service) Stats(ctx context.Context, subdomain string, watch bool) error {
+	cfg, err := s.config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.Metrics == "" {
+		return fmt.Errorf("cloudflared has no metrics address - set one with `orb tunnel metrics %s`", DefaultMetricsAddress)
+	}
+
+	var host string
+	if subdomain != "" {
+		if err := ValidateSubdomain(subdomain); err != nil {
+			return err
+		}
+		host = HostnameFor(subdomain, s.env.Domain)
+		if err := s.requireExposed(host); err != nil {
+			return err
+		}
+	}
+
+	url := metricsURL(cfg.Metrics)
+	before, err := s.scrapeTraffic(ctx, url)
+	if err != nil {
+		return err
+	}
+	if watch {
+		fmt.Printf("Watching %s (every %s, Ctrl+C to stop)...\n", url, statsInterval)
+	} else {
+		fmt.Printf("Sampling %s for %s...\n", url, statsInterval)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(statsInterval):
+		}
+
+		after, err := s.scrapeTraffic(ctx, url)
+		if err != nil {
+			if !watch {
+				return err
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Printf("⚠ Warning: %v\n", err)
+			continue
+		}
+		if err := printTraffic(before, after, host); err != nil {
+			return err
+		}
+		if !watch {
+			return nil
+		}
+		before = after
+	}
+}
+
+// ValidateMetricsAddress checks a host:port for cloudflared's metrics server
+func ValidateMetricsAddress(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid metrics address %q: use host:port (e.g., %s)", address, DefaultMetricsAddress)
+	}
+	if err := ValidatePort(port); err != nil {
+		return fmt.Errorf("invalid metrics address %q: %w", address, err)
+	}
+	return nil
+}
+
+// metricsURL returns the URL of the metrics served on address, reached locally
+// when cloudflared listens on every interface
+func metricsURL(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "http://" + address + "/metrics"
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/metrics"
+}
+
+// scrapeTraffic fetches the metrics at url and adds up the traffic counters
+func (s *Service) scrapeTraffic(ctx context.Context, url string) (*trafficSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrape %s (is cloudflared running? check `orb tunnel status`): %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to scrape %s: %s", url, resp.Status)
+	}
+
+	samples, err := parseMetrics(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics from %s: %w", url, err)
+	}
+	return trafficFrom(samples), nil
+}
+
+// trafficFrom adds up the cloudflared traffic counters by service
+func trafficFrom(samples []metricSample) *trafficSnapshot {
+	snapshot := &trafficSnapshot{at: time.Now(), services: make(map[string]*traffic)}
+	for _, sample := range samples {
+		if math.IsNaN(sample.value) {
+			continue // no value yet; it would poison the totals
+		}
+
+		var service string
+		for _, label := range hostnameLabels {
+			if value, ok := sample.labels[label]; ok {
+				service = value
+				break
+			}
+		}
+
+		t := snapshot.services[service]
+		if t == nil {
+			t = &traffic{codes: make(map[string]float64)}
+		}
+		switch sample.name {
+		case metricRequests:
+			t.requests += sample.value
+		case metricErrors:
+			t.errors += sample.value
+		case metricActive:
+			t.active += sample.value
+		case metricCodes:
+			t.codes[sample.labels["status_code"]] += sample.value
+		default:
+			continue
+		}
+		snapshot.services[service] = t
+	}
+	return snapshot
+}
+
+// printTraffic prints the traffic between two snapshots, for host or every service
+func printTraffic(before, after *trafficSnapshot, host string) error {
+	services := make([]string, 0, len(after.services))
+	for service := range after.services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	var note string
+	if host != "" {
+		if _, ok := after.services[host]; ok {
+			services = []string{host}
+		} else {
+			note = fmt.Sprintf("ℹ️  cloudflared does not break its metrics down by hostname, so these are the totals of every service, %s included", host)
+			services = []string{""}
+		}
+	}
+	if len(services) == 0 {
+		fmt.Println("No traffic metrics yet - cloudflared reports them once it has handled a request")
+		return nil
+	}
+
+	window := after.at.Sub(before.at)
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Service", "Requests", "Req/s", "Errors", "Error Rate", "Active", "Status Codes")
+	for _, service := range services {
+		now := after.services[service]
+		if now == nil {
+			now = &traffic{codes: map[string]float64{}}
+		}
+		then := before.services[service]
+		if then == nil {
+			then = &traffic{codes: map[string]float64{}}
+		}
+
+		requests := counterDelta(then.requests, now.requests)
+		errors := counterDelta(then.errors, now.errors)
+		rate, errorRate := "-", "-"
+		if window > 0 {
+			rate = strconv.FormatFloat(requests/window.Seconds(), 'f', 2, 64)
+		}
+		if requests > 0 {
+			errorRate = strconv.FormatFloat(100*errors/requests, 'f', 1, 64) + "%"
+		}
+
+		name := service
+		if name == "" {
+			name = "all services"
+		}
+		if err := table.Append(name, formatCount(now.requests), rate, formatCount(now.errors), errorRate, formatCount(now.active), formatCodes(now.codes)); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nTraffic at %s (rates over the last %s; totals since cloudflared started):\n", after.at.Format("15:04:05"), window.Round(time.Second))
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	if note != "" {
+		fmt.Println(note)
+	}
+	return nil
+}
+
+// counterDelta is how much a counter grew, treating a drop as a restart from zero
+func counterDelta(before, after float64) float64 {
+	if after < before {
+		return after
+	}
+	return after - before
+}
+
+// formatCount renders a counter value without decimals
+func formatCount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
+
+// formatCodes renders a status code breakdown, e.g. "200: 1400, 404: 12, 502: 3"
+func formatCodes(codes map[string]float64) string {
+	keys := make([]string, 0, len(codes))
+	for code, count := range codes {
+		if count > 0 {
+			keys = append(keys, code)
+		}
+	}
+	if len(keys) == 0 {
+		return "-"
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, code := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %s", code, formatCount(codes[code])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseMetrics reads samples in the Prometheus text exposition format, skipping
+// comments (# HELP, # TYPE) and ignoring timestamps
+func parseMetrics(r io.Reader) ([]metricSample, error) {
+	var samples []metricSample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample, err := parseMetricLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// parseMetricLine parses `name{label="value",...} value [timestamp]`
+func parseMetricLine(line string) (metricSample, error) {
+	sample := metricSample{labels: make(map[string]string)}
+
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return sample, fmt.Errorf("invalid sample %q", line)
+	}
+	sample.name, line = line[:end], line[end:]
+
+	if strings.HasPrefix(line, "{") {
+		rest, err := parseLabels(line[1:], sample.labels)
+		if err != nil {
+			return sample, fmt.Errorf("%s: %w", sample.name, err)
+		}
+		line = rest
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return sample, fmt.Errorf("%s: missing value", sample.name)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("%s: invalid value %q", sample.name, fields[0])
+	}
+	sample.value = value
+	return sample, nil
+}
+
+// parseLabels reads label pairs up to the closing brace into labels and returns
+// what follows it
+func parseLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+
+		eq := strings.Index(s, "=")
+		if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+			return "", fmt.Errorf("invalid labels")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+			case c == '"':
+				s = s[i+1:]
+				closed = true
+			default:
+				value.WriteByte(c)
+			}
+			if closed {
+				break
+			}
+		}
+		if !closed {
+			return "", fmt.Errorf("unterminated value of label %q", name)
+		}
+		labels[name] = value.String()
+	}
+}
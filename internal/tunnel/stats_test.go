@@ -0,0 +1,173 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sampleMetrics is cloudflared's metrics without hostname labels, plus lines the
+// parser must cope with; %d is the request count
+const sampleMetrics = `# HELP cloudflared_tunnel_total_requests Amount of requests proxied through all the tunnels
+# TYPE cloudflared_tunnel_total_requests counter
+cloudflared_tunnel_total_requests %d
+cloudflared_tunnel_request_errors 12
+cloudflared_tunnel_concurrent_requests_per_tunnel{connection_id="0",tunnel_id="tid"} 3
+cloudflared_tunnel_concurrent_requests_per_tunnel{connection_id="1",tunnel_id="tid"} 2
+cloudflared_tunnel_concurrent_requests_per_tunnel{connection_id="2",tunnel_id="tid"} NaN
+cloudflared_tunnel_response_by_code{status_code="200"} 1400
+cloudflared_tunnel_response_by_code{status_code="404"} 88
+cloudflared_tunnel_response_by_code{status_code="502"} 12 1700000000000
+
+go_gc_duration_seconds{quantile="0.5"} NaN
+process_max_fds +Inf
+build_info{goversion="go1.23",note="a \"quoted\", {braced} \\ value"} 1
+`
+
+// hostMetrics breaks the counters down by service, under either label name
+const hostMetrics = `cloudflared_tunnel_total_requests{hostname="api.example.com"} 10
+cloudflared_tunnel_total_requests{host="www.example.com"} 5
+cloudflared_tunnel_request_errors{hostname="api.example.com"} 1
+`
+
+// metricsServer serves body, formatted with a request count that grows by 50 per scrape
+func metricsServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	var scrapes atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		requests := 1450 + 50*scrapes.Add(1)
+		if strings.Contains(body, "%d") {
+			fmt.Fprintf(w, body, requests)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScrapeTraffic(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		services map[string]traffic
+	}{
+		{
+			name: "tunnel totals",
+			body: sampleMetrics,
+			services: map[string]traffic{
+				"": {requests: 1500, errors: 12, active: 5, codes: map[string]float64{"200": 1400, "404": 88, "502": 12}},
+			},
+		},
+		{
+			name: "by hostname",
+			body: hostMetrics,
+			services: map[string]traffic{
+				"api.example.com": {requests: 10, errors: 1, codes: map[string]float64{}},
+				"www.example.com": {requests: 5, codes: map[string]float64{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := testService(t)
+			svc.http = http.DefaultClient
+			server := metricsServer(t, tt.body)
+
+			snapshot, err := svc.scrapeTraffic(context.Background(), server.URL+"/metrics")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshot.services) != len(tt.services) {
+				t.Errorf("got services %v, want %d", snapshot.services, len(tt.services))
+			}
+			for service, want := range tt.services {
+				got := snapshot.services[service]
+				if got == nil {
+					t.Errorf("no traffic for %q", service)
+					continue
+				}
+				if got.requests != want.requests || got.errors != want.errors || got.active != want.active {
+					t.Errorf("%q: requests/errors/active = %v/%v/%v, want %v/%v/%v", service, got.requests, got.errors, got.active, want.requests, want.errors, want.active)
+				}
+				if formatCodes(got.codes) != formatCodes(want.codes) {
+					t.Errorf("%q: codes = %s, want %s", service, formatCodes(got.codes), formatCodes(want.codes))
+				}
+			}
+		})
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	samples, err := parseMetrics(strings.NewReader(fmt.Sprintf(sampleMetrics, 1500)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]metricSample)
+	for _, sample := range samples {
+		byName[sample.name] = sample
+	}
+	if len(samples) != 11 {
+		t.Errorf("got %d samples, want 11 (comments and blank lines skipped)", len(samples))
+	}
+	if v := byName["go_gc_duration_seconds"].value; !math.IsNaN(v) {
+		t.Errorf("NaN sample = %v", v)
+	}
+	if v := byName["process_max_fds"].value; !math.IsInf(v, 1) {
+		t.Errorf("+Inf sample = %v", v)
+	}
+	if v := byName["cloudflared_tunnel_response_by_code"].value; v != 12 {
+		t.Errorf("sample with a timestamp = %v, want 12", v)
+	}
+	if got, want := byName["build_info"].labels["note"], `a "quoted", {braced} \ value`; got != want {
+		t.Errorf("escaped label = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{`metric{label="x} 1`, `metric{label=x} 1`, "metric", "metric one"} {
+		if _, err := parseMetrics(strings.NewReader(bad)); err == nil {
+			t.Errorf("parseMetrics(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestStatsFallsBackToTotals(t *testing.T) {
+	statsInterval = 10 * time.Millisecond
+	t.Cleanup(func() { statsInterval = 5 * time.Second })
+
+	svc, _, _ := testService(t)
+	svc.http = http.DefaultClient
+	ctx := context.Background()
+	if err := svc.Expose(ctx, "api", "8080", "http", ExposeOptions{Access: AccessLevelPublic}); err != nil {
+		t.Fatal(err)
+	}
+	server := metricsServer(t, sampleMetrics)
+	cfg, err := svc.config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Metrics = strings.TrimPrefix(server.URL, "http://")
+	if err := svc.config.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error { return svc.Stats(ctx, "api", false) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"all services", "1550", "200: 1400, 404: 88, 502: 12", "does not break its metrics down by hostname"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Stats output lacks %q:\n%s", want, out)
+		}
+	}
+}
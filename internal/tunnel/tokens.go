@@ -0,0 +1,348 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"orb/internal/audit"
+	"orb/internal/dns"
+	"orb/internal/plan"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// tokenExpiryWarning is how close to its expiry a service token gets flagged by `token list`
+const tokenExpiryWarning = 30 * 24 * time.Hour
+
+// CreateServiceToken creates an Access service token for machine clients such as CI
+// jobs. The client secret is shown once, or written to output (mode 0600) if set.
+func (s *Service) CreateServiceToken(ctx context.Context, name, duration, output string) error {
+	if err := ValidateTokenName(name); err != nil {
+		return err
+	}
+	lifetime, err := ParseTokenDuration(duration)
+	if err != nil {
+		return err
+	}
+
+	if s.dryRun {
+		p := plan.New()
+		p.Add("Service tokens", plan.Create, "token %s (valid for %s)", name, lifetime)
+		if output != "" {
+			p.Add("Files", plan.Create, "%s (client ID and secret, mode 0600)", output)
+		}
+		p.Print()
+		return nil
+	}
+
+	token, err := s.cloudflare.CreateServiceToken(ctx, name, lifetime)
+	if err != nil {
+		return err
+	}
+
+	s.record(audit.Entry{
+		Action:  "access.token.create",
+		Subject: name,
+		After:   audit.Fields("duration", lifetime, "expires", formatTokenExpiry(token.ExpiresAt)),
+		IDs:     audit.Fields("service_token", token.ID),
+	})
+
+	fmt.Printf("✔ Created service token %q (expires %s)\n", name, formatTokenExpiry(token.ExpiresAt))
+	if err := showTokenSecret(token, output); err != nil {
+		return err
+	}
+	fmt.Printf("\nLet it reach a service with `orb access token attach %s <subdomain>`\n", name)
+	return nil
+}
+
+// ListServiceTokens lists the service tokens, the services they are attached to
+// and when they expire, warning about those expiring within 30 days
+func (s *Service) ListServiceTokens(ctx context.Context) error {
+	tokens, err := s.cloudflare.ListServiceTokens(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		fmt.Println("No service tokens found")
+		fmt.Println("\nUse `orb access token create <name> --duration 1y` to create one")
+		return nil
+	}
+
+	attached, err := s.ruleUsage(ctx, dns.RuleServiceToken)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Name", "Client ID", "Expires", "In", "Services")
+	var warnings []string
+	for _, token := range tokens {
+		in := "-"
+		if !token.ExpiresAt.IsZero() {
+			remaining := time.Until(token.ExpiresAt)
+			in = FormatRemaining(remaining)
+			switch {
+			case remaining <= 0:
+				in = "expired"
+				warnings = append(warnings, fmt.Sprintf("service token %q has expired - rotate it with `orb access token rotate %s`", token.Name, token.Name))
+			case remaining < tokenExpiryWarning:
+				warnings = append(warnings, fmt.Sprintf("service token %q expires in %s - rotate it with `orb access token rotate %s`", token.Name, in, token.Name))
+			}
+		}
+		services := strings.Join(attached[token.Name], ", ")
+		if services == "" {
+			services = "-"
+		}
+		if err := table.Append(token.Name, token.ClientID, formatTokenExpiry(token.ExpiresAt), in, services); err != nil {
+			return fmt.Errorf("failed to add table row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nService Tokens (%d):\n", len(tokens))
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	for _, warning := range warnings {
+		fmt.Printf("⚠ Warning: %s\n", warning)
+	}
+	return nil
+}
+
+// RotateServiceToken replaces the client secret of a service token and renews its
+// expiry. The old secret stops working at once, so clients need the new one.
+func (s *Service) RotateServiceToken(ctx context.Context, name, output string) error {
+	if s.dryRun {
+		p := plan.New()
+		p.Add("Service tokens", plan.Modify, "token %s: new client secret, expiry renewed (the old secret stops working)", name)
+		if output != "" {
+			p.Add("Files", plan.Modify, "%s (client ID and secret, mode 0600)", output)
+		}
+		p.Print()
+		return nil
+	}
+
+	token, err := s.cloudflare.RotateServiceToken(ctx, name)
+	if token == nil {
+		return err
+	}
+	if err != nil {
+		// the secret changed anyway - it must still be shown
+		fmt.Printf("⚠ Warning: %v\n", err)
+	}
+
+	s.record(audit.Entry{
+		Action:  "access.token.rotate",
+		Subject: name,
+		After:   audit.Fields("expires", formatTokenExpiry(token.ExpiresAt)),
+		IDs:     audit.Fields("service_token", token.ID),
+	})
+
+	fmt.Printf("✔ Rotated service token %q (expires %s) - the old secret no longer works\n", name, formatTokenExpiry(token.ExpiresAt))
+	return showTokenSecret(token, output)
+}
+
+// RevokeServiceToken detaches a service token from every exposed service and deletes it
+func (s *Service) RevokeServiceToken(ctx context.Context, name string) error {
+	attached, err := s.ruleUsage(ctx, dns.RuleServiceToken)
+	if err != nil {
+		return err
+	}
+	hosts := attached[name]
+
+	if s.dryRun {
+		p := plan.New()
+		for _, host := range hosts {
+			p.Add("Access", plan.Modify, "policy orb-%s-tokens: stop letting service-token:%s in", host, name)
+		}
+		p.Add("Service tokens", plan.Delete, "token %s", name)
+		p.Print()
+		return nil
+	}
+
+	for _, host := range hosts {
+		fmt.Printf("Detaching service token %q from %s...\n", name, host)
+		if err := s.setTokenAccess(ctx, host, name, false); err != nil {
+			return fmt.Errorf("failed to detach service token from %s: %w", host, err)
+		}
+	}
+
+	id, err := s.cloudflare.DeleteServiceToken(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	s.record(audit.Entry{
+		Action:  "access.token.revoke",
+		Subject: name,
+		Before:  audit.Fields("services", strings.Join(hosts, ",")),
+		IDs:     audit.Fields("service_token", id),
+	})
+
+	fmt.Printf("✔ Revoked service token %q\n", name)
+	return nil
+}
+
+// AttachServiceToken lets a service token reach a protected subdomain through its
+// non_identity tokens policy, keeping every other policy
+func (s *Service) AttachServiceToken(ctx context.Context, name, subdomain string) error {
+	return s.changeTokenAccess(ctx, name, subdomain, true)
+}
+
+// DetachServiceToken stops a service token from reaching a subdomain
+func (s *Service) DetachServiceToken(ctx context.Context, name, subdomain string) error {
+	return s.changeTokenAccess(ctx, name, subdomain, false)
+}
+
+// changeTokenAccess implements AttachServiceToken and DetachServiceToken
+func (s *Service) changeTokenAccess(ctx context.Context, name, subdomain string, attach bool) error {
+	if err := ValidateSubdomain(subdomain); err != nil {
+		return err
+	}
+	host := HostnameFor(subdomain, s.env.Domain)
+	if err := s.requireExposed(host); err != nil {
+		return err
+	}
+
+	if attach {
+		tokens, err := s.cloudflare.ListServiceTokens(ctx)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(tokens, func(t dns.ServiceToken) bool { return t.Name == name }) {
+			return fmt.Errorf("service token %q not found - create it with `orb access token create %s` first", name, name)
+		}
+	}
+
+	if s.dryRun {
+		before, err := s.cloudflare.GetAccess(ctx, host)
+		if err != nil {
+			return fmt.Errorf("failed to get access policies: %w", err)
+		}
+		desired, err := tokenAccess(before, host, name, attach)
+		if err != nil {
+			return err
+		}
+		p := plan.New()
+		p.Note("Access", "currently %s", before)
+		planPolicyChanges(p, before, desired)
+		p.Print()
+		return nil
+	}
+
+	if attach {
+		fmt.Printf("Attaching service token %q to %s...\n", name, host)
+	} else {
+		fmt.Printf("Detaching service token %q from %s...\n", name, host)
+	}
+	if err := s.setTokenAccess(ctx, host, name, attach); err != nil {
+		return err
+	}
+
+	if attach {
+		fmt.Printf("✔ Service token %q can reach %s\n", name, host)
+		fmt.Println("  Clients send the CF-Access-Client-Id and CF-Access-Client-Secret headers")
+	} else {
+		fmt.Printf("✔ Service token %q can no longer reach %s\n", name, host)
+	}
+	return nil
+}
+
+// setTokenAccess adds or removes a service token from the tokens policy of host,
+// restoring the previous policies if that fails
+func (s *Service) setTokenAccess(ctx context.Context, host, name string, attach bool) error {
+	before, err := s.cloudflare.GetAccess(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to get access policies: %w", err)
+	}
+	desired, err := tokenAccess(before, host, name, attach)
+	if err != nil {
+		return err
+	}
+
+	appID, err := s.applyAccessChange(ctx, host, before, desired)
+	if err != nil {
+		return fmt.Errorf("failed to update access policies: %w", err)
+	}
+
+	s.record(audit.Entry{
+		Action:  "tunnel.access",
+		Subject: host,
+		Before:  audit.Fields("access", before.String()),
+		After:   audit.Fields("access", desired.String()),
+		IDs:     audit.Fields("access_app", appID),
+	})
+	return nil
+}
+
+// tokenAccess returns the access of host with name added to or removed from its tokens policy
+func tokenAccess(current *dns.Access, host, name string, attach bool) (*dns.Access, error) {
+	if current == nil {
+		return nil, fmt.Errorf("✖ %s is public - anyone can already reach it, no service token needed", host)
+	}
+
+	tokens := current.Tokens()
+	has := slices.Contains(tokens, name)
+	switch {
+	case attach && has:
+		return nil, fmt.Errorf("service token %q can already reach %s", name, host)
+	case !attach && !has:
+		return nil, fmt.Errorf("service token %q is not attached to %s", name, host)
+	case attach:
+		tokens = append(tokens, name)
+	default:
+		tokens = slices.DeleteFunc(tokens, func(t string) bool { return t == name })
+	}
+	return current.WithTokens(host, tokens), nil
+}
+
+// showTokenSecret prints the credentials of a new or rotated token, or writes them
+// to output as environment variables readable only by the current user
+func showTokenSecret(token *dns.ServiceToken, output string) error {
+	if output != "" {
+		content := fmt.Sprintf("CF_ACCESS_CLIENT_ID=%s\nCF_ACCESS_CLIENT_SECRET=%s\n", token.ClientID, token.ClientSecret)
+		if err := writeSecretFile(output, []byte(content)); err != nil {
+			return fmt.Errorf("failed to write credentials (the secret cannot be shown again - rotate the token): %w", err)
+		}
+		fmt.Printf("  Client ID and secret written to %s\n", output)
+		return nil
+	}
+
+	fmt.Printf("  Client ID:     %s\n", token.ClientID)
+	fmt.Printf("  Client secret: %s\n", token.ClientSecret)
+	fmt.Println("⚠ The secret is shown only this once - store it now (or use --output next time)")
+	return nil
+}
+
+// writeSecretFile writes data to a new file only the current user can read and
+// renames it over path, so the secret is never readable by others, even briefly
+func writeSecretFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*") // created 0600
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// formatTokenExpiry renders when a service token expires
+func formatTokenExpiry(at time.Time) string {
+	if at.IsZero() {
+		return "never"
+	}
+	return at.Format("2006-01-02")
+}
@@ -0,0 +1,37 @@
+package tunnel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSecretFileReplacesReadableFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.env")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeSecretFile(path, []byte("CF_ACCESS_CLIENT_SECRET=s3cret\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("mode = %o, want 600", mode)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "CF_ACCESS_CLIENT_SECRET=s3cret\n" {
+		t.Errorf("content = %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
@@ -2,10 +2,13 @@ package tunnel
 
 import (
 	"fmt"
+	"net"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"orb/internal/dns"
 )
 
 var (
@@ -15,6 +18,12 @@ var (
 	portRe = regexp.MustCompile(`^\d{1,5}$`)
 	// expiresRe validates expires duration format (e.g., 1h, 24h, 7d, 30m)
 	expiresRe = regexp.MustCompile(`^(\d+)(m|h|d)$`)
+	// publicPathRe validates a --public-path (e.g., /webhook/*, /health)
+	publicPathRe = regexp.MustCompile(`^/[A-Za-z0-9._~!$&'()*+,;=:@%/-]*$`)
+	// tokenDurationRe validates a service token lifetime (e.g., 720h, 90d, 1y)
+	tokenDurationRe = regexp.MustCompile(`^(\d+)(h|d|y)$`)
+	// tokenNameRe validates a service token name (used as service-token:<name> in rules)
+	tokenNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
 )
 
 // ValidateSubdomain checks if a subdomain string is valid
@@ -44,15 +53,181 @@ func ValidateServiceType(t string) error {
 }
 
 // ValidateAccessLevel checks if an access level is valid
-// Accepts "public", "private", or any group name
+// Accepts "public", "private", or one or more comma-separated group names
 func ValidateAccessLevel(level string) error {
 	if level == "" {
 		return fmt.Errorf("access level cannot be empty")
 	}
-	// Any non-empty string is valid (public, private, or group name)
+	// Any group name is valid; only the list itself is checked
+	_, err := ParseGroupGrants(level)
+	return err
+}
+
+// GroupGrant is one group of a group access level, optionally with its own
+// expiry written as name:duration (e.g. contractors:7d)
+type GroupGrant struct {
+	Name    string
+	Expires string
+}
+
+// String renders the grant as it is written in --access
+func (g GroupGrant) String() string {
+	if g.Expires != "" {
+		return g.Name + ":" + g.Expires
+	}
+	return g.Name
+}
+
+// ParseGroupGrants splits a group access level such as "team,contractors:7d" into
+// its groups. Public and private grant no groups and cannot be mixed with them.
+func ParseGroupGrants(level string) ([]GroupGrant, error) {
+	if level == AccessLevelPublic || level == AccessLevelPrivate {
+		return nil, nil
+	}
+
+	var grants []GroupGrant
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(level, ",") {
+		grant := GroupGrant{Name: strings.TrimSpace(part)}
+		if name, expires, ok := strings.Cut(grant.Name, ":"); ok {
+			grant.Name, grant.Expires = strings.TrimSpace(name), strings.TrimSpace(expires)
+			if err := ValidateExpiresDuration(grant.Expires); err != nil {
+				return nil, fmt.Errorf("invalid expiry for group %q: %w", grant.Name, err)
+			}
+		}
+
+		switch {
+		case grant.Name == "":
+			return nil, fmt.Errorf("invalid access level %q: empty group name", level)
+		case grant.Name == AccessLevelPublic || grant.Name == AccessLevelPrivate:
+			return nil, fmt.Errorf("invalid access level %q: %s cannot be combined with groups", level, grant.Name)
+		case seen[grant.Name]:
+			return nil, fmt.Errorf("invalid access level %q: group %s is listed twice", level, grant.Name)
+		}
+		seen[grant.Name] = true
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
+
+// GroupNames returns the access level granting the groups, without their expiries
+func GroupNames(grants []GroupGrant) string {
+	names := make([]string, len(grants))
+	for i, grant := range grants {
+		names[i] = grant.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// FormatGrants renders grants as written in --access, or level when there are none
+func FormatGrants(grants []GroupGrant, level string) string {
+	if len(grants) == 0 {
+		return level
+	}
+	parts := make([]string, len(grants))
+	for i, grant := range grants {
+		parts[i] = grant.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// hasGroupExpiry reports whether any grant carries its own expiry
+func hasGroupExpiry(grants []GroupGrant) bool {
+	for _, grant := range grants {
+		if grant.Expires != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePublicPath checks a path given to --public-path
+func ValidatePublicPath(path string) error {
+	if path == "/" || path == "/*" {
+		return fmt.Errorf("invalid public path %q: that is the whole service - use --access public instead", path)
+	}
+	if !publicPathRe.MatchString(path) {
+		return fmt.Errorf("invalid public path %q: must start with / and contain no spaces, ? or # (e.g., /webhook/*)", path)
+	}
 	return nil
 }
 
+// ParseAccessRules parses --allow, --require and --exclude values
+// (e.g. email-domain:example.com, ip:203.0.113.0/24, country:CA, service-token:ci, everyone)
+func ParseAccessRules(allow, require, exclude []string) (dns.AccessRules, error) {
+	var rules dns.AccessRules
+	var err error
+	if rules.Allow, err = dns.ParseRules(allow); err != nil {
+		return rules, fmt.Errorf("invalid --allow: %w", err)
+	}
+	if rules.Require, err = dns.ParseRules(require); err != nil {
+		return rules, fmt.Errorf("invalid --require: %w", err)
+	}
+	if rules.Exclude, err = dns.ParseRules(exclude); err != nil {
+		return rules, fmt.Errorf("invalid --exclude: %w", err)
+	}
+	for _, rule := range append(rules.Require, rules.Exclude...) {
+		if rule.Type == dns.RuleServiceToken {
+			return rules, fmt.Errorf("service tokens can only be used with --allow")
+		}
+	}
+	return rules, nil
+}
+
+// ValidateTokenName checks if a service token name is valid
+func ValidateTokenName(name string) error {
+	if !tokenNameRe.MatchString(name) {
+		return fmt.Errorf("invalid service token name %q: use letters, digits, '.', '_' or '-' (e.g., ci, deploy-bot)", name)
+	}
+	return nil
+}
+
+// ParseTokenDuration converts a service token lifetime like 1y, 90d or 720h (or
+// "forever") into the hours Cloudflare expects, e.g. "8760h"
+func ParseTokenDuration(duration string) (string, error) {
+	duration = strings.TrimSpace(duration)
+	if duration == "forever" {
+		return duration, nil
+	}
+	matches := tokenDurationRe.FindStringSubmatch(duration)
+	if matches == nil {
+		return "", fmt.Errorf("invalid --duration %q: use format like 720h, 90d, 1y or forever", duration)
+	}
+
+	value, err := strconv.Atoi(matches[1])
+	if err != nil || value == 0 {
+		return "", fmt.Errorf("invalid --duration %q: must be at least 1h", duration)
+	}
+	switch matches[2] {
+	case "d":
+		value *= 24
+	case "y":
+		value *= 365 * 24
+	}
+	return fmt.Sprintf("%dh", value), nil
+}
+
+// ParseNetwork parses the private network of a route, a CIDR like 10.0.0.0/24 or a
+// single IP address (routed as /32 or /128), into the form Cloudflare stores
+func ParseNetwork(network string) (string, error) {
+	network = strings.TrimSpace(network)
+	if ip := net.ParseIP(network); ip != nil {
+		if ip.To4() != nil {
+			return network + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+
+	ip, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		return "", fmt.Errorf("invalid network %q: use a CIDR like 10.0.0.0/24 or an IP address", network)
+	}
+	if !ip.Equal(ipNet.IP) {
+		return "", fmt.Errorf("invalid network %q: host bits are set - did you mean %s?", network, ipNet)
+	}
+	return ipNet.String(), nil
+}
+
 // ValidateExpiresDuration checks if an expires duration string is valid
 func ValidateExpiresDuration(expires string) error {
 	expires = strings.TrimSpace(expires)